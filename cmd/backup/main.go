@@ -0,0 +1,92 @@
+// Command backup exports users, puzzles and games to a portable archive and restores one into a
+// fresh database, for installations without managed database backups.
+//
+//	backup export sudoku-backup.zip
+//	backup restore sudoku-backup.zip
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"sudoku/internal/backup"
+	"sudoku/internal/secrets"
+	"sudoku/internal/vault"
+)
+
+func main() {
+	if len(os.Args) != 3 || (os.Args[1] != "export" && os.Args[1] != "restore") {
+		fmt.Fprintln(os.Stderr, "usage: backup export|restore <archive.zip>")
+		os.Exit(2)
+	}
+	command, path := os.Args[1], os.Args[2]
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	if err := secrets.LoadFiles(); err != nil {
+		log.Fatal("Failed to load secrets:", err)
+	}
+
+	// Get database URL from environment
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	// Encrypted columns are decrypted on export and encrypted again on restore
+	keyring, err := vault.LoadEnv()
+	if err != nil {
+		log.Fatal("Invalid encryption keys:", err)
+	}
+	vault.Configure(keyring)
+
+	if command == "export" {
+		exportArchive(db, path)
+	} else {
+		restoreArchive(db, path)
+	}
+}
+
+func exportArchive(db *gorm.DB, path string) {
+	// Refuse to overwrite an earlier backup
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatal("Failed to create archive:", err)
+	}
+
+	manifest, err := backup.Export(db, file)
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+		log.Fatal("Failed to export:", err)
+	}
+	log.Printf("Exported %v to %s", manifest.Rows, path)
+}
+
+func restoreArchive(db *gorm.DB, path string) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		log.Fatal("Failed to open archive:", err)
+	}
+	defer archive.Close()
+
+	manifest, err := backup.Restore(db, &archive.Reader)
+	if err != nil {
+		log.Fatal("Failed to restore:", err)
+	}
+	log.Printf("Restored %v from the backup of %s", manifest.Rows, manifest.CreatedAt.Format("2006-01-02 15:04"))
+}
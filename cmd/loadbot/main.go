@@ -0,0 +1,133 @@
+// Command loadbot drives a server with virtual players to measure it under load. Each player
+// registers its own account, then plays Play mode games at a human pace until the run ends:
+// moves, heartbeats, submission, replay upload and the odd leaderboard look. Race pairs play
+// arcade races over WebSockets alongside them. Latencies are printed per operation at the end.
+//
+//	loadbot -server http://localhost:8080 -players 50 -duration 10m -races 5
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"sudoku/internal/client"
+	"sudoku/internal/sudoku"
+)
+
+// config holds the settings shared by every player
+type config struct {
+	difficulty string
+	moveDelay  time.Duration
+	mistakes   float64
+}
+
+func main() {
+	server := flag.String("server", envOr("SUDOKU_SERVER", "http://localhost:8080"), "API base URL")
+	players := flag.Int("players", 10, "Number of players playing solo games")
+	races := flag.Int("races", 0, "Number of player pairs racing each other")
+	duration := flag.Duration("duration", 5*time.Minute, "How long to keep playing")
+	ramp := flag.Duration("ramp", 30*time.Second, "Time over which players are started")
+	difficulty := flag.String("difficulty", "easy", "easy, medium, hard or expert")
+	moveDelay := flag.Duration("move-delay", 3*time.Second, "Average pause between moves")
+	mistakes := flag.Float64("mistakes", 0.05, "Share of moves that place a wrong digit first")
+	password := flag.String("password", "loadbot-password", "Password of the bot accounts")
+	flag.Parse()
+
+	cfg := config{difficulty: *difficulty, moveDelay: *moveDelay, mistakes: *mistakes}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ctx, stop := context.WithTimeout(ctx, *duration)
+	defer stop()
+
+	// Accounts of one run don't collide with earlier runs
+	runID := strconv.FormatInt(time.Now().Unix()%1000000, 36)
+	total := *players + 2**races
+	s := newStats()
+	started := time.Now()
+	log.Printf("Starting %d players (%d racing) against %s for %s", total, 2**races, *server, *duration)
+
+	var wg sync.WaitGroup
+	var raceIDs []chan uint
+	for i := 0; i < *races; i++ {
+		raceIDs = append(raceIDs, make(chan uint))
+	}
+	for i := 0; i < total; i++ {
+		// Spread the starts over the ramp so registration doesn't arrive as one burst
+		if i > 0 && *ramp > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(*ramp / time.Duration(total)):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		p := &player{
+			client: client.New(*server),
+			stats:  s,
+			engine: sudoku.NewService(nil),
+			rng:    rand.New(rand.NewSource(time.Now().UnixNano() + int64(i))),
+			config: cfg,
+		}
+		username := fmt.Sprintf("lb-%s-%d", runID, i)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := s.time("register", func() error {
+				return p.client.Register(username, username+"@loadbot.invalid", *password)
+			})
+			if err != nil {
+				return
+			}
+			if i < *players {
+				loop(ctx, s, "game", p.playGame)
+				return
+			}
+			pair := (i - *players) / 2
+			host := (i-*players)%2 == 0
+			loop(ctx, s, "race", func(ctx context.Context) error {
+				return p.playRace(ctx, host, raceIDs[pair])
+			})
+		}(i)
+	}
+
+	wg.Wait()
+	elapsed := time.Since(started)
+	log.Printf("Finished after %s", elapsed.Round(time.Second))
+	s.report(os.Stdout, elapsed)
+}
+
+// loop plays games one after another until ctx ends, recording the outcome of each whole game
+func loop(ctx context.Context, s *stats, name string, play func(context.Context) error) {
+	for ctx.Err() == nil {
+		started := time.Now()
+		err := play(ctx)
+		if ctx.Err() != nil {
+			return // Cut short, its duration means nothing
+		}
+		s.record(name, time.Since(started), err)
+		if err != nil {
+			// Back off so a failing server isn't hammered in a tight loop
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"sudoku/internal/client"
+	"sudoku/internal/models"
+	"sudoku/internal/race"
+	"sudoku/internal/sudoku"
+)
+
+// Heartbeats are sent at this interval while a game is played, like the web client does
+const heartbeatInterval = 30 * time.Second
+
+// player is one virtual user
+type player struct {
+	client *client.Client
+	stats  *stats
+	engine *sudoku.Service
+	rng    *rand.Rand
+	config config
+}
+
+// Wait for a human-like pause around the configured move delay. Returns false if ctx ends first.
+func (p *player) think(ctx context.Context) bool {
+	delay := time.Duration(float64(p.config.moveDelay) * (0.5 + p.rng.Float64()))
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// The empty cells of a grid in a random order, with the values that solve them
+func (p *player) plan(grid string) ([]sudoku.Cell, sudoku.Board, error) {
+	board := sudoku.StringToBoard(grid)
+	solution, err := p.engine.SolvePuzzle(context.Background(), board)
+	if err != nil {
+		return nil, board, err
+	}
+	var empty []sudoku.Cell
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 {
+				empty = append(empty, sudoku.Cell{Row: i, Col: j})
+			}
+		}
+	}
+	p.rng.Shuffle(len(empty), func(i, j int) { empty[i], empty[j] = empty[j], empty[i] })
+	return empty, solution, nil
+}
+
+// A wrong digit for a cell, to play a mistake that is corrected later
+func (p *player) wrongDigit(right int) int {
+	return (right+p.rng.Intn(8))%9 + 1
+}
+
+// playGame plays a Play mode game from start to submission, then uploads its replay and
+// sometimes looks at the leaderboard. A game cut short by ctx is abandoned.
+func (p *player) playGame(ctx context.Context) error {
+	var game *client.Game
+	err := p.stats.time("start", func() (err error) {
+		game, err = p.client.StartGame(p.config.difficulty, "play")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	empty, solution, err := p.plan(game.Puzzle.StartingGrid)
+	if err != nil {
+		return err
+	}
+	board := sudoku.StringToBoard(game.Puzzle.StartingGrid)
+	started := time.Now()
+	lastHeartbeat := started
+	var events []models.ReplayEvent
+	place := func(cell sudoku.Cell, value int) {
+		board[cell.Row][cell.Col] = value
+		events = append(events, models.ReplayEvent{AtMillis: int(time.Since(started).Milliseconds()), Row: cell.Row, Col: cell.Col, Value: value})
+	}
+
+	for _, cell := range empty {
+		if !p.think(ctx) {
+			return nil
+		}
+		right := solution[cell.Row][cell.Col]
+		if p.rng.Float64() < p.config.mistakes {
+			place(cell, p.wrongDigit(right))
+			if !p.think(ctx) {
+				return nil
+			}
+		}
+		place(cell, right)
+
+		if time.Since(lastHeartbeat) >= heartbeatInterval {
+			lastHeartbeat = time.Now()
+			p.stats.time("heartbeat", func() error { return p.client.Heartbeat(game.GameResultID) })
+		}
+	}
+	p.stats.time("heartbeat", func() error { return p.client.Heartbeat(game.GameResultID) })
+
+	var result *client.SubmitResult
+	err = p.stats.time("submit", func() (err error) {
+		result, err = p.client.SubmitGame(client.SubmitRequest{
+			GameResultID: game.GameResultID,
+			FinalGrid:    sudoku.BoardToString(board),
+			TimeSeconds:  int(time.Since(started).Seconds()),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Correct {
+		return fmt.Errorf("game %d graded incorrect", game.GameResultID)
+	}
+	p.stats.time("replay", func() error { return p.client.SaveReplay(game.GameResultID, events) })
+
+	if p.rng.Float64() < 0.5 {
+		sortBy := []string{"score", "time"}[p.rng.Intn(2)]
+		p.stats.time("leaderboard", func() error {
+			_, err := p.client.Leaderboard(p.config.difficulty, sortBy)
+			return err
+		})
+	}
+	return nil
+}
+
+// playRace plays one arcade race over its WebSocket. The host creates the race and hands its id to
+// the guest through raceIDs; both then race through the puzzle until one of them finishes.
+func (p *player) playRace(ctx context.Context, host bool, raceIDs chan uint) error {
+	var raceID uint
+	if host {
+		err := p.stats.time("race.create", func() error {
+			created, err := p.client.CreateRace(p.config.difficulty)
+			if err == nil {
+				raceID = created.ID
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		select {
+		case raceIDs <- raceID:
+		case <-ctx.Done():
+			return nil
+		}
+		// The guest joins before the socket can be opened
+		select {
+		case <-raceIDs:
+		case <-ctx.Done():
+			return nil
+		}
+	} else {
+		select {
+		case raceID = <-raceIDs:
+		case <-ctx.Done():
+			return nil
+		}
+		err := p.stats.time("race.join", func() error {
+			_, err := p.client.JoinRace(raceID)
+			return err
+		})
+		select {
+		case raceIDs <- raceID:
+		case <-ctx.Done():
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	grid, err := p.client.RaceGrid(raceID)
+	if err != nil {
+		return err
+	}
+	empty, solution, err := p.plan(grid)
+	if err != nil {
+		return err
+	}
+
+	var conn *websocket.Conn
+	err = p.stats.time("race.dial", func() (err error) {
+		conn, err = p.client.DialRace(raceID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Moves are acknowledged by an event carrying their value, which only the mover receives
+	var mu sync.Mutex
+	sent := map[sudoku.Cell]time.Time{}
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for {
+			var event race.Event
+			if err := conn.ReadJSON(&event); err != nil {
+				return
+			}
+			if event.Type == "finished" {
+				return
+			}
+			if event.Value == 0 || event.Row == nil || event.Col == nil {
+				continue
+			}
+			cell := sudoku.Cell{Row: *event.Row, Col: *event.Col}
+			mu.Lock()
+			if at, ok := sent[cell]; ok {
+				p.stats.record("race.move", time.Since(at), nil)
+				delete(sent, cell)
+			}
+			mu.Unlock()
+		}
+	}()
+
+	for _, cell := range empty {
+		if !p.think(ctx) {
+			return nil
+		}
+		select {
+		case <-finished:
+			return nil // The opponent won
+		default:
+		}
+
+		mu.Lock()
+		sent[cell] = time.Now()
+		mu.Unlock()
+		msg := race.Message{Type: "move", Row: cell.Row, Col: cell.Col, Value: solution[cell.Row][cell.Col]}
+		if err := conn.WriteJSON(msg); err != nil {
+			p.stats.record("race.move", 0, err)
+			return err
+		}
+	}
+
+	select {
+	case <-finished:
+		return nil
+	case <-time.After(10 * time.Second):
+		return errors.New("race did not finish after the last move")
+	}
+}
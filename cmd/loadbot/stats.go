@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stats records the latency and outcome of every call the bots make, by operation
+type stats struct {
+	mu  sync.Mutex
+	ops map[string]*series
+}
+
+type series struct {
+	durations []time.Duration
+	errors    int
+	lastError string
+}
+
+func newStats() *stats {
+	return &stats{ops: map[string]*series{}}
+}
+
+// time runs fn and records how long it took under name
+func (s *stats) time(name string, fn func() error) error {
+	started := time.Now()
+	err := fn()
+	s.record(name, time.Since(started), err)
+	return err
+}
+
+func (s *stats) record(name string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.ops[name]
+	if !ok {
+		op = &series{}
+		s.ops[name] = op
+	}
+	if err != nil {
+		op.errors++
+		op.lastError = err.Error()
+		return
+	}
+	op.durations = append(op.durations, d)
+}
+
+// report prints one line per operation: throughput, errors and latency percentiles
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.ops))
+	for name := range s.ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "%-16s %7s %7s %8s %9s %9s %9s %9s\n", "operation", "ok", "errors", "per sec", "p50", "p95", "p99", "max")
+	for _, name := range names {
+		op := s.ops[name]
+		sort.Slice(op.durations, func(i, j int) bool { return op.durations[i] < op.durations[j] })
+		fmt.Fprintf(w, "%-16s %7d %7d %8.2f %9s %9s %9s %9s\n", name, len(op.durations), op.errors,
+			float64(len(op.durations))/elapsed.Seconds(),
+			percentile(op.durations, 50), percentile(op.durations, 95), percentile(op.durations, 99), percentile(op.durations, 100))
+	}
+	for _, name := range names {
+		if op := s.ops[name]; op.errors > 0 {
+			fmt.Fprintf(w, "last %s error: %s\n", name, op.lastError)
+		}
+	}
+}
+
+// The p-th percentile of sorted durations, rounded for display
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p + 99) / 100
+	return sorted[max(i-1, 0)].Round(100 * time.Microsecond)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"sudoku/internal/migrate"
+	"sudoku/internal/models"
+	"sudoku/internal/secrets"
+	"sudoku/internal/sudoku"
+	"sudoku/internal/vault"
+)
+
+func main() {
+	reindex := flag.Bool("reindex", false, "Rewrite every encrypted value and blind index, after changing ENCRYPTION_INDEX_KEY")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	if err := secrets.LoadFiles(); err != nil {
+		log.Fatal("Failed to load secrets:", err)
+	}
+
+	// Get database URL from environment
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	// Make sure the new tables and columns exist before filling them
+	if err := db.AutoMigrate(&models.User{}, &models.Puzzle{}, &models.Game{}, &models.GameResult{}, &models.PushDevice{}); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	linked, err := migrate.BackfillGames(db)
+	if err != nil {
+		log.Fatal("Failed to backfill games:", err)
+	}
+	log.Printf("Linked %d game results to games", linked)
+
+	rated, err := migrate.RatePuzzles(db, sudoku.NewService(db))
+	if err != nil {
+		log.Fatal("Failed to rate puzzles:", err)
+	}
+	log.Printf("Rated %d puzzles", rated)
+
+	// Encrypt sensitive columns written in plain text or with a rotated-out key
+	indexKey, err := vault.LoadIndexKey()
+	if err != nil {
+		log.Fatal("Invalid blind index key:", err)
+	}
+	vault.ConfigureIndex(indexKey)
+	keyring, err := vault.LoadEnv()
+	if err != nil {
+		log.Fatal("Invalid encryption keys:", err)
+	}
+	if keyring == nil {
+		log.Println("No ENCRYPTION_KEYS set, only filling in blind indexes")
+	}
+	vault.Configure(keyring)
+	if !*reindex {
+		// Filling in missing indexes under another key would leave them mixed
+		if err := migrate.CheckIndexes(db); err != nil {
+			log.Fatal("Failed to check blind indexes:", err)
+		}
+	}
+	encrypted, err := migrate.EncryptColumns(db, *reindex)
+	if err != nil {
+		log.Fatal("Failed to encrypt sensitive columns:", err)
+	}
+	log.Printf("Updated %d sensitive rows", encrypted)
+}
@@ -1,22 +1,32 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"sudoku/internal/models"
+	"sudoku/internal/secrets"
 	"sudoku/internal/sudoku"
 )
 
 func main() {
+	symmetry := flag.String("symmetry", "", "clue pattern symmetry: none, rotational or mirror (defaults to each difficulty's profile)")
+	seed := flag.Int64("seed", 0, "generate the same puzzles on every run from this seed (0 for random puzzles)")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file:", err)
 	}
+	if err := secrets.LoadFiles(); err != nil {
+		log.Fatal("Failed to load secrets:", err)
+	}
 
 	// Get database URL from environment
 	databaseURL := os.Getenv("DATABASE_URL")
@@ -29,30 +39,56 @@ func main() {
 	}
 
 	// Auto-migrate models
-	if err := db.AutoMigrate(&models.User{}, &models.Puzzle{}, &models.GameResult{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Puzzle{}, &models.Game{}, &models.GameResult{}, &models.GenerationProfile{},
+		&models.CoachGrant{}, &models.GameAnnotation{}, &models.TechniqueRecommendation{}, &models.PuzzleSkip{},
+		&models.LeaderboardSnapshot{}, &models.ResultReview{},
+		&models.FeaturedPuzzle{}, &models.APIKey{}, &models.APIUsage{}, &models.AnonymousUsage{},
+		&models.PushDevice{}, &models.PushNotification{}, &models.ModerationTerm{}, &models.GameDispute{},
+		&models.OnboardingQuiz{}, &models.OnboardingBoard{}, &models.Announcement{}, &models.AnnouncementDismissal{},
+		&models.AssistantSession{}, &models.AssistantPrompt{}, &models.Blob{}, &models.LargeObject{},
+		&models.PooledPuzzle{}, &models.Race{}, &models.RaceSeries{}, &models.AccountMerge{},
+		&models.Event{}, &models.EventPuzzle{}, &models.EventBadge{}, &models.DatasetExport{},
+		&models.Goal{}, &models.GoalCompletion{}, &models.RivalNudge{},
+		&models.TokenTransaction{}, &models.Setting{}, &models.SamuraiGame{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	sudokuService := sudoku.NewService(db)
 
+	backfillCanonicalHashes(db)
+
 	// Generate and insert puzzles
-	difficulties := []models.Difficulty{models.Easy, models.Medium, models.Hard}
-	for _, difficulty := range difficulties {
+	difficulties := []models.Difficulty{models.Easy, models.Medium, models.Hard, models.Expert}
+	for d, difficulty := range difficulties {
 		for i := 0; i < 5; i++ { // Generate 5 puzzles per difficulty
-			puzzle, solution, err := sudokuService.GeneratePuzzle(difficulty)
+			opts := sudoku.GenerateOptions{Symmetry: models.Symmetry(*symmetry)}
+			if *seed != 0 {
+				puzzleSeed := *seed + int64(d*5+i) // Distinct but reproducible seed per puzzle
+				opts.Seed = &puzzleSeed
+			}
+			puzzle, solution, err := sudokuService.GeneratePuzzle(difficulty, opts)
 			if err != nil {
 				log.Printf("Failed to generate puzzle: %v", err)
 				continue
 			}
 
+			rating := sudokuService.RatePuzzle(puzzle)
 			newPuzzle := models.Puzzle{
-				Difficulty:   difficulty,
-				StartingGrid: sudoku.BoardToString(puzzle),
-				Solution:     sudoku.BoardToString(solution),
+				Difficulty:         difficulty,
+				StartingGrid:       sudoku.BoardToString(puzzle),
+				Solution:           sudoku.BoardToString(solution),
+				RequiresUniqueness: sudokuService.UsesUniqueness(puzzle),
+				Rating:             rating.Difficulty,
+				HardestTechnique:   rating.HardestTechnique,
+				CanonicalHash:      sudoku.CanonicalHash(puzzle),
 			}
 
-			if err := db.Create(&newPuzzle).Error; err != nil {
-				log.Printf("Failed to save puzzle: %v", err)
+			// Reseeding with the same -seed generates the same puzzles, which are skipped
+			result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&newPuzzle)
+			if result.Error != nil {
+				log.Printf("Failed to save puzzle: %v", result.Error)
+			} else if result.RowsAffected == 0 {
+				log.Printf("Skipped %s puzzle already in the database", difficulty)
 			} else {
 				log.Printf("Generated puzzle with ID: %d, Difficulty: %s", newPuzzle.ID, difficulty)
 			}
@@ -61,3 +97,24 @@ func main() {
 
 	log.Println("Database seeding completed!")
 }
+
+// Fill in the canonical hash of puzzles saved before it existed. Of puzzles that turn out to be
+// duplicates, the oldest keeps the hash and the others are left without one and logged.
+func backfillCanonicalHashes(db *gorm.DB) {
+	var puzzles []models.Puzzle
+	if err := db.Unscoped().Where("canonical_hash = ''").Order("id").Find(&puzzles).Error; err != nil {
+		log.Fatal("Failed to load puzzles:", err)
+	}
+
+	for _, puzzle := range puzzles {
+		hash := sudoku.CanonicalHash(sudoku.StringToBoard(puzzle.StartingGrid))
+		var existing models.Puzzle
+		if err := db.Unscoped().Where("canonical_hash = ?", hash).First(&existing).Error; err == nil {
+			log.Printf("Puzzle %d duplicates puzzle %d", puzzle.ID, existing.ID)
+			continue
+		}
+		if err := db.Unscoped().Model(&puzzle).Update("canonical_hash", hash).Error; err != nil {
+			log.Fatal("Failed to save canonical hash:", err)
+		}
+	}
+}
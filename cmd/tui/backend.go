@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sudoku/internal/client"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// backend plays a game either against the API or against the engine in-process
+type backend interface {
+	// Start a new game, returning its starting grid
+	start(difficulty string) (string, error)
+	// A tiered hint on the next move of grid. cell picks the cell filled at sudoku.ValueLevel.
+	hint(grid string, level int, cell *sudoku.Cell) (*sudoku.TieredHint, error)
+	// Filled cells of grid that don't match the solution
+	check(grid string) ([]sudoku.Cell, error)
+	// Grade a finished grid, returning a line describing the result
+	submit(grid string, seconds int, usedHints bool) (string, error)
+}
+
+// remote plays through the API as the logged-in user
+type remote struct {
+	client *client.Client
+	mode   string
+	gameID uint
+}
+
+func (r *remote) start(difficulty string) (string, error) {
+	game, err := r.client.StartGame(difficulty, r.mode)
+	if err != nil {
+		return "", err
+	}
+	r.gameID = game.GameResultID
+	return game.Puzzle.StartingGrid, nil
+}
+
+func (r *remote) hint(grid string, level int, cell *sudoku.Cell) (*sudoku.TieredHint, error) {
+	return r.client.Hint(r.gameID, grid, level, cell)
+}
+
+func (r *remote) check(grid string) ([]sudoku.Cell, error) {
+	return r.client.CheckCells(r.gameID, grid)
+}
+
+func (r *remote) submit(grid string, seconds int, usedHints bool) (string, error) {
+	result, err := r.client.SubmitGame(client.SubmitRequest{
+		GameResultID: r.gameID,
+		FinalGrid:    grid,
+		TimeSeconds:  seconds,
+		UsedHints:    usedHints,
+	})
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !result.Correct:
+		return "Not solved yet, keep going.", nil
+	case result.Disqualified:
+		return fmt.Sprintf("Solved in %ds, disqualified for using hints.", result.TimeSeconds), nil
+	default:
+		return fmt.Sprintf("Solved in %ds for %d points!", result.TimeSeconds, result.Score), nil
+	}
+}
+
+// local generates and grades puzzles with the engine, without a server or database
+type local struct {
+	service  *sudoku.Service
+	puzzle   sudoku.Board
+	solution sudoku.Board
+	levels   int // Hint levels consumed
+}
+
+func newLocal() *local {
+	return &local{service: sudoku.NewService(nil)}
+}
+
+func (l *local) start(difficulty string) (string, error) {
+	puzzle, solution, err := l.service.GeneratePuzzle(models.Difficulty(difficulty), sudoku.GenerateOptions{})
+	if err != nil {
+		return "", err
+	}
+	l.puzzle, l.solution, l.levels = puzzle, solution, 0
+	return sudoku.BoardToString(puzzle), nil
+}
+
+func (l *local) hint(grid string, level int, cell *sudoku.Cell) (*sudoku.TieredHint, error) {
+	board := sudoku.StringToBoard(grid)
+	var move *sudoku.Move
+	var err error
+	if cell != nil {
+		move, err = l.service.GetHint(context.Background(), board, cell.Row, cell.Col)
+	} else {
+		move, err = l.service.FindSolvableCell(context.Background(), board)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.levels += level
+	hint := sudoku.TierMove(move, level)
+	return &hint, nil
+}
+
+func (l *local) check(grid string) ([]sudoku.Cell, error) {
+	diff := sudoku.DiffBoards(l.puzzle, sudoku.StringToBoard(grid), l.solution, true)
+	return diff.IncorrectCells, nil
+}
+
+func (l *local) submit(grid string, seconds int, usedHints bool) (string, error) {
+	board := sudoku.StringToBoard(grid)
+	if !sudoku.IsSolved(board, l.solution) {
+		return "Not solved yet, keep going.", nil
+	}
+	if usedHints {
+		return fmt.Sprintf("Solved in %ds with a filled-in hint.", seconds), nil
+	}
+	score := max(l.service.CalculateScore(l.puzzle, board, l.solution)-sudoku.HintPenalty(l.levels), 0)
+	return fmt.Sprintf("Solved in %ds for %d points!", seconds, score), nil
+}
+
+// Errors worth showing the player as they are
+func describe(err error) string {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Message
+	}
+	return err.Error()
+}
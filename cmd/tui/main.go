@@ -0,0 +1,243 @@
+// Command tui plays sudoku in the terminal, against the API or offline with the engine built in.
+//
+//	tui -user alice -password secret          play on the server at $SUDOKU_SERVER
+//	tui -offline -difficulty hard             play without a server
+//	tui leaderboard -difficulty easy          print the leaderboard
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"sudoku/internal/client"
+	"sudoku/internal/sudoku"
+)
+
+// game is the state of the board being played
+type game struct {
+	backend    backend
+	difficulty string
+	puzzle     sudoku.Board // Starting grid, its digits can't be changed
+	board      sudoku.Board
+	cursor     sudoku.Cell
+	started    time.Time
+	wrong      map[sudoku.Cell]bool // Cells flagged by the last mistake check
+	hint       *sudoku.TieredHint   // Hint shown for the current board, nil if none
+	hintLevels int
+	usedHints  bool // A hint filled a cell
+	status     string
+}
+
+func (g *game) elapsed() int {
+	return int(time.Since(g.started).Seconds())
+}
+
+func (g *game) newGame() error {
+	grid, err := g.backend.start(g.difficulty)
+	if err != nil {
+		return err
+	}
+	g.puzzle = sudoku.StringToBoard(grid)
+	g.board = g.puzzle
+	g.cursor = sudoku.Cell{}
+	g.started = time.Now()
+	g.wrong = map[sudoku.Cell]bool{}
+	g.hint = nil
+	g.hintLevels = 0
+	g.usedHints = false
+	g.status = "Good luck!"
+	return nil
+}
+
+// Place a digit, or clear the cell with 0
+func (g *game) place(value int) {
+	if g.puzzle[g.cursor.Row][g.cursor.Col] != 0 {
+		g.status = "That cell is a given."
+		return
+	}
+	g.board[g.cursor.Row][g.cursor.Col] = value
+	delete(g.wrong, g.cursor)
+	g.hint = nil // The hint was for the previous board
+	g.status = ""
+}
+
+// Ask for the next hint level: technique, then the cell, then its value
+func (g *game) nextHint() {
+	level := sudoku.TechniqueLevel
+	var cell *sudoku.Cell
+	if g.hint != nil {
+		level = g.hint.Level + 1
+		if level == sudoku.ValueLevel {
+			cell = g.hint.Cell
+		}
+	}
+
+	hint, err := g.backend.hint(sudoku.BoardToString(g.board), level, cell)
+	if err != nil {
+		g.status = "Hint failed: " + describe(err)
+		return
+	}
+	g.hintLevels += level
+	g.status = hint.Text
+	if level < sudoku.ValueLevel {
+		g.hint = hint
+		if hint.Cell != nil {
+			g.cursor = *hint.Cell
+		}
+		return
+	}
+	g.board[hint.Cell.Row][hint.Cell.Col] = hint.Value
+	g.usedHints = true
+	g.hint = nil
+}
+
+func (g *game) check() {
+	wrong, err := g.backend.check(sudoku.BoardToString(g.board))
+	if err != nil {
+		g.status = "Check failed: " + describe(err)
+		return
+	}
+	g.wrong = map[sudoku.Cell]bool{}
+	for _, cell := range wrong {
+		g.wrong[cell] = true
+	}
+	if len(wrong) == 0 {
+		g.status = "No mistakes so far."
+	} else {
+		g.status = fmt.Sprintf("%d cells are wrong.", len(wrong))
+	}
+}
+
+func (g *game) submit() {
+	message, err := g.backend.submit(sudoku.BoardToString(g.board), g.elapsed(), g.usedHints)
+	if err != nil {
+		g.status = "Submit failed: " + describe(err)
+		return
+	}
+	g.status = message
+}
+
+// Handle one key press. Returns false to quit.
+func (g *game) handle(key int) bool {
+	switch key {
+	case 'q', 3: // Ctrl-C arrives as a byte in raw mode
+		return false
+	case keyUp, 'k':
+		g.cursor.Row = (g.cursor.Row + 8) % 9
+	case keyDown, 'j':
+		g.cursor.Row = (g.cursor.Row + 1) % 9
+	case keyLeft, 'h':
+		g.cursor.Col = (g.cursor.Col + 8) % 9
+	case keyRight, 'l':
+		g.cursor.Col = (g.cursor.Col + 1) % 9
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		g.place(key - '0')
+	case '0', 'x', ' ', 127, 8:
+		g.place(0)
+	case '?':
+		g.nextHint()
+	case 'c':
+		g.check()
+	case 's':
+		g.submit()
+	case 'n':
+		if err := g.newGame(); err != nil {
+			g.status = "Failed to start a game: " + describe(err)
+		}
+	}
+	return true
+}
+
+func play(b backend, difficulty string) error {
+	g := &game{backend: b, difficulty: difficulty}
+	if err := g.newGame(); err != nil {
+		return err
+	}
+
+	restore, err := rawMode()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		render(g)
+		key, err := readKey(in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !g.handle(key) {
+			fmt.Print(clearScreen)
+			return nil
+		}
+	}
+}
+
+func printLeaderboard(c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("leaderboard", flag.ExitOnError)
+	difficulty := flags.String("difficulty", "", "Only this difficulty (easy, medium, hard, expert)")
+	sortBy := flags.String("type", "score", "Sort by score or time")
+	flags.Parse(args)
+
+	entries, err := c.Leaderboard(*difficulty, *sortBy)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-4s %-20s %-8s %6s %8s\n", "#", "Player", "Level", "Score", "Time")
+	for i, entry := range entries {
+		fmt.Printf("%-4d %-20s %-8s %6d %7ds\n", i+1, entry.Username, entry.Difficulty, entry.Score, entry.TimeSeconds)
+	}
+	return nil
+}
+
+func main() {
+	server := flag.String("server", envOr("SUDOKU_SERVER", "http://localhost:8080"), "API base URL")
+	user := flag.String("user", os.Getenv("SUDOKU_USER"), "Username to log in with")
+	password := flag.String("password", os.Getenv("SUDOKU_PASSWORD"), "Password to log in with")
+	offline := flag.Bool("offline", false, "Play against the built-in engine without a server")
+	difficulty := flag.String("difficulty", "medium", "easy, medium, hard or expert")
+	mode := flag.String("mode", "learn", "Game mode on the server: learn or play")
+	flag.Parse()
+
+	c := client.New(*server)
+	if flag.Arg(0) == "leaderboard" {
+		if err := printLeaderboard(c, flag.Args()[1:]); err != nil {
+			log.Fatal("Failed to fetch leaderboard: ", describe(err))
+		}
+		return
+	}
+
+	var b backend
+	if *offline {
+		b = newLocal()
+	} else {
+		if *user == "" || *password == "" {
+			log.Fatal("-user and -password (or SUDOKU_USER and SUDOKU_PASSWORD) are required to play online; use -offline to play without a server")
+		}
+		if err := c.Login(*user, *password); err != nil {
+			log.Fatal("Failed to log in: ", describe(err))
+		}
+		b = &remote{client: c, mode: *mode}
+	}
+
+	if err := play(b, *difficulty); err != nil {
+		log.Fatal(describe(err))
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
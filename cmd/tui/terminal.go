@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"sudoku/internal/sudoku"
+)
+
+// Keys that aren't a single printable byte
+const (
+	keyUp = iota + 256
+	keyDown
+	keyLeft
+	keyRight
+)
+
+// ANSI styles used when drawing the board
+const (
+	styleReset  = "\x1b[0m"
+	styleGiven  = "\x1b[1m"
+	styleCursor = "\x1b[7m"
+	styleWrong  = "\x1b[31m"
+	styleHinted = "\x1b[43;30m"
+	styleRegion = "\x1b[44m"
+	clearScreen = "\x1b[H\x1b[2J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	lineEnd     = "\r\n" // The terminal doesn't translate newlines in raw mode
+)
+
+// Run stty on the controlling terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// Put the terminal in raw mode so every key press is read as it happens. The returned
+// function restores the previous settings.
+func rawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("stdin is not a terminal: %w", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	fmt.Print(hideCursor)
+	return func() {
+		fmt.Print(showCursor)
+		stty(saved)
+	}, nil
+}
+
+// Read one key press, decoding the arrow key escape sequences
+func readKey(in *bufio.Reader) (int, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0x1b || in.Buffered() < 2 {
+		return int(b), nil
+	}
+	if next, _ := in.ReadByte(); next != '[' {
+		return int(next), nil
+	}
+	switch code, _ := in.ReadByte(); code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	}
+	return 0, nil
+}
+
+// Draw the board with the cursor, given digits, flagged mistakes and the current hint
+func render(g *game) {
+	var b strings.Builder
+	b.WriteString(clearScreen)
+	fmt.Fprintf(&b, "Sudoku — %s%s", g.difficulty, lineEnd+lineEnd)
+
+	for i := 0; i < 9; i++ {
+		if i%3 == 0 {
+			b.WriteString("+-------+-------+-------+" + lineEnd)
+		}
+		for j := 0; j < 9; j++ {
+			if j%3 == 0 {
+				b.WriteString("| ")
+			}
+			cell := sudoku.Cell{Row: i, Col: j}
+			digit := "."
+			if v := g.board[i][j]; v != 0 {
+				digit = fmt.Sprint(v)
+			}
+
+			style := ""
+			switch {
+			case g.puzzle[i][j] != 0:
+				style += styleGiven
+			case g.wrong[cell]:
+				style += styleWrong
+			}
+			switch {
+			case g.cursor == cell:
+				style += styleCursor
+			case g.hint != nil && g.hint.Cell != nil && *g.hint.Cell == cell:
+				style += styleHinted
+			case g.hint != nil && g.hint.Cell == nil && inRegion(g.hint.Region, cell):
+				style += styleRegion
+			}
+			if style != "" {
+				digit = style + digit + styleReset
+			}
+			b.WriteString(digit + " ")
+		}
+		b.WriteString("|" + lineEnd)
+	}
+	b.WriteString("+-------+-------+-------+" + lineEnd + lineEnd)
+
+	fmt.Fprintf(&b, "Time %ds   Hint levels used %d%s", g.elapsed(), g.hintLevels, lineEnd)
+	b.WriteString(g.status + lineEnd + lineEnd)
+	b.WriteString("arrows/hjkl move  1-9 place  0/x/space clear  ? hint (again for more)" + lineEnd)
+	b.WriteString("c check mistakes  s submit  n new game  q quit" + lineEnd)
+	fmt.Print(b.String())
+}
+
+// Whether the cell lies in the row, column or box
+func inRegion(region *sudoku.UnitRef, cell sudoku.Cell) bool {
+	if region == nil {
+		return false
+	}
+	switch region.Kind {
+	case "Row":
+		return cell.Row == region.Index
+	case "Column":
+		return cell.Col == region.Index
+	default:
+		return cell.Row/3*3+cell.Col/3 == region.Index
+	}
+}
@@ -18,8 +18,8 @@ import Profile from './components/Profile';
 // Context
 import { AuthContext } from './context/AuthContext';
 
-// Configure axios
-axios.defaults.baseURL = 'http://localhost:8080';
+// Configure axios; self-hosted builds set REACT_APP_API_URL empty to call the server they are served from
+axios.defaults.baseURL = process.env.REACT_APP_API_URL ?? 'http://localhost:8080';
 
 const theme = createTheme({
   colorScheme: 'dark',
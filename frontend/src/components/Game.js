@@ -49,6 +49,12 @@ function Game() {
     initialBoard: Array(9).fill().map(() => Array(9).fill(0)),
     mode: 'play',
     difficulty: 'easy',
+    variant: 'classic',
+    cages: null,
+    regions: null,
+    parities: null,
+    antiKnight: false,
+    antiKing: false,
     startedAt: null,
     timer: 0,
     usedHints: false,
@@ -57,8 +63,9 @@ function Game() {
   
   const [selectedCell, setSelectedCell] = useState(null);
   const [hintState, setHintState] = useState({
-    step: 'none', // 'none', 'highlighted', 'filled'
-    highlightedCell: null // {row, col}
+    step: 'none', // 'none', 'technique', 'highlighted'
+    highlightedCell: null, // {row, col}
+    text: '' // Explanation of the current hint level
   });
   const [gameStarted, setGameStarted] = useState(false);
   const [gameCompleted, setGameCompleted] = useState(false);
@@ -91,7 +98,7 @@ function Game() {
     return () => clearInterval(interval);
   }, [gameStarted, gameCompleted]);
 
-  const startGame = async (mode, difficulty) => {
+  const startGame = async (mode, difficulty, variant) => {
     setLoading(true);
     setError('');
     
@@ -101,7 +108,10 @@ function Game() {
     try {
       const response = await axios.post('/game/start', {
         mode,
-        difficulty
+        difficulty,
+        variant,
+        anti_knight: gameState.antiKnight,
+        anti_king: gameState.antiKing
       });
       
       console.log('Start game response:', response.data);
@@ -119,6 +129,12 @@ function Game() {
         initialBoard,
         mode,
         difficulty,
+        variant,
+        cages: puzzle.cages ? parseCages(puzzle.cages) : null,
+        regions: puzzle.regions || null,
+        parities: puzzle.parities || null,
+        antiKnight: !!puzzle.anti_knight,
+        antiKing: !!puzzle.anti_king,
         startedAt: new Date(started_at),
         timer: 0,
         usedHints: false,
@@ -146,6 +162,19 @@ function Game() {
     return board;
   };
 
+  // Killer cage layout "sum:cell,cell;..." to the cage of every cell and the sum shown in each cage's first cell
+  const parseCages = (layout) => {
+    const cageOf = Array(81).fill(-1);
+    const sums = {};
+    layout.split(';').forEach((cage, index) => {
+      const [sum, cells] = cage.split(':');
+      const positions = cells.split(',').map(Number);
+      positions.forEach(pos => { cageOf[pos] = index; });
+      sums[Math.min(...positions)] = sum;
+    });
+    return { cageOf, sums };
+  };
+
   const gridToString = (board) => {
     return board.flat().join('');
   };
@@ -233,45 +262,62 @@ function Game() {
     
     try {
       if (hintState.step === 'none') {
-        // Step 1: Find a solvable cell to highlight
+        // Level 1: Name the technique and the house to look in
         const response = await axios.post('/game/hint', {
           game_result_id: gameState.gameResultId,
-          mode: 'find_cell',
+          level: 1,
           current_grid: gridToString(gameState.board)
         });
-        
-        const { row, col } = response.data;
+
+        setHintState({
+          step: 'technique',
+          highlightedCell: null,
+          text: response.data.text
+        });
+        setError(''); // Clear any previous errors
+
+      } else if (hintState.step === 'technique') {
+        // Level 2: Highlight the cell the technique fills
+        const response = await axios.post('/game/hint', {
+          game_result_id: gameState.gameResultId,
+          level: 2,
+          current_grid: gridToString(gameState.board)
+        });
+
+        const { row, col } = response.data.cell;
         setHintState({
           step: 'highlighted',
-          highlightedCell: { row, col }
+          highlightedCell: { row, col },
+          text: response.data.text
         });
         setError(''); // Clear any previous errors
-        
+
       } else if (hintState.step === 'highlighted') {
-        // Step 2: Fill the highlighted cell with the correct value
+        // Level 3: Fill the highlighted cell with the correct value
         const { row, col } = hintState.highlightedCell;
         const response = await axios.post('/game/hint', {
           game_result_id: gameState.gameResultId,
-          mode: 'fill_cell',
+          level: 3,
           row,
           col,
           current_grid: gridToString(gameState.board)
         });
-        
+
         const { value } = response.data;
         const newBoard = [...gameState.board];
         newBoard[row][col] = value;
-        
+
         setGameState(prev => ({
           ...prev,
           board: newBoard,
           usedHints: true
         }));
-        
-        // Reset hint state
+
+        // Reset hint state, keeping the explanation Learn mode sends with the value
         setHintState({
           step: 'none',
-          highlightedCell: null
+          highlightedCell: null,
+          text: response.data.explanation ? response.data.explanation.text : ''
         });
         setError(''); // Clear any previous errors
       }
@@ -280,7 +326,8 @@ function Game() {
       // Reset hint state on error
       setHintState({
         step: 'none',
-        highlightedCell: null
+        highlightedCell: null,
+        text: ''
       });
     }
   };
@@ -291,11 +338,12 @@ function Game() {
       return;
     }
     
-    // Unhighlight hint cell if a hint is active
-    if (hintState.step === 'highlighted') {
+    // Drop the hint if one is active
+    if (hintState.step !== 'none' || hintState.text) {
       setHintState({
         step: 'none',
-        highlightedCell: null
+        highlightedCell: null,
+        text: ''
       });
     }
     
@@ -433,12 +481,77 @@ function Game() {
                 <Button 
                   variant={gameState.mode === 'learn' ? 'filled' : 'outline'}
                   color="black"
-                  onClick={() => setGameState(prev => ({ ...prev, mode: 'learn' }))}
+                  onClick={() => setGameState(prev => ({ ...prev, mode: 'learn', variant: 'classic', antiKnight: false, antiKing: false }))}
                 >
                   📚 Learn Mode (Educational)
                 </Button>
               </Group>
             </div>
+
+            <div>
+              <Title order={3} mb="md" c="white">
+                Select Variant:
+              </Title>
+              <Group justify="center" gap="md">
+                <Button
+                  variant={gameState.variant === 'classic' ? 'filled' : 'outline'}
+                  color="black"
+                  onClick={() => setGameState(prev => ({ ...prev, variant: 'classic' }))}
+                >
+                  Classic
+                </Button>
+                <Button
+                  variant={gameState.variant === 'killer' ? 'filled' : 'outline'}
+                  color="black"
+                  disabled={gameState.mode === 'learn'}
+                  onClick={() => setGameState(prev => ({ ...prev, variant: 'killer' }))}
+                >
+                  Killer (Cage Sums)
+                </Button>
+                <Button
+                  variant={gameState.variant === 'diagonal' ? 'filled' : 'outline'}
+                  color="black"
+                  disabled={gameState.mode === 'learn'}
+                  onClick={() => setGameState(prev => ({ ...prev, variant: 'diagonal' }))}
+                >
+                  X-Sudoku (Diagonals)
+                </Button>
+                <Button
+                  variant={gameState.variant === 'jigsaw' ? 'filled' : 'outline'}
+                  color="black"
+                  disabled={gameState.mode === 'learn'}
+                  onClick={() => setGameState(prev => ({ ...prev, variant: 'jigsaw', antiKnight: false, antiKing: false }))}
+                >
+                  Jigsaw (Irregular Regions)
+                </Button>
+                <Button
+                  variant={gameState.variant === 'evenodd' ? 'filled' : 'outline'}
+                  color="black"
+                  disabled={gameState.mode === 'learn'}
+                  onClick={() => setGameState(prev => ({ ...prev, variant: 'evenodd' }))}
+                >
+                  Even/Odd (Parity Markers)
+                </Button>
+              </Group>
+              <Group justify="center" gap="md" mt="md">
+                <Button
+                  variant={gameState.antiKnight ? 'filled' : 'outline'}
+                  color="black"
+                  disabled={gameState.mode === 'learn' || gameState.variant === 'jigsaw'}
+                  onClick={() => setGameState(prev => ({ ...prev, antiKnight: !prev.antiKnight }))}
+                >
+                  ♞ Anti-Knight
+                </Button>
+                <Button
+                  variant={gameState.antiKing ? 'filled' : 'outline'}
+                  color="black"
+                  disabled={gameState.mode === 'learn' || gameState.variant === 'jigsaw'}
+                  onClick={() => setGameState(prev => ({ ...prev, antiKing: !prev.antiKing }))}
+                >
+                  ♚ Anti-King
+                </Button>
+              </Group>
+            </div>
             
             <div>
               <Title order={3} mb="md" c="white">
@@ -461,7 +574,7 @@ function Game() {
             <Button 
               color="green"
               size="lg"
-              onClick={() => startGame(gameState.mode, gameState.difficulty)}
+              onClick={() => startGame(gameState.mode, gameState.difficulty, gameState.variant)}
               loading={loading}
             >
               {loading ? 'Starting...' : 'Start Game'}
@@ -533,10 +646,20 @@ function Game() {
                     const isSelected = isCellSelected(rowIndex, colIndex);
                     const isHintHighlighted = isCellHintHighlighted(rowIndex, colIndex);
                     const isLastSolved = isCellLastSolved(rowIndex, colIndex);
+                    const pos = rowIndex * 9 + colIndex;
+                    // X-Sudoku shades both long diagonals
+                    const onDiagonal = gameState.variant === 'diagonal' && (rowIndex === colIndex || rowIndex + colIndex === 8);
+                    // Jigsaw regions replace the 3x3 boxes: thick lines wherever the region changes
+                    const regions = gameState.regions;
+                    const boxRight = regions ? colIndex !== 8 && regions[pos] !== regions[pos + 1] : (colIndex + 1) % 3 === 0 && colIndex !== 8;
+                    const boxBottom = regions ? rowIndex !== 8 && regions[pos] !== regions[pos + 9] : rowIndex === 2 || rowIndex === 5;
+                    const cages = gameState.cages;
+                    // Dashed cage outline on the sides facing another cage
+                    const cageEdge = (neighbour) => cages && (neighbour < 0 || neighbour > 80 || cages.cageOf[neighbour] !== cages.cageOf[pos]) ? '1px dashed #555' : 'none';
                     
                     return (
+                      <div key={`${rowIndex}-${colIndex}`} style={{ position: 'relative' }}>
                       <input
-                        key={`${rowIndex}-${colIndex}`}
                         type="text"
                         style={{
                           width: '100%',
@@ -548,16 +671,16 @@ function Game() {
                           backgroundColor: isInitial ? '#d0d0d0' : 
                                         isLastSolved ? '#d4edda' :
                                         isHintHighlighted ? '#fff3e0' :
-                                        isSelected ? '#e3f2fd' : 'white',
+                                        isSelected ? '#e3f2fd' :
+                                        onDiagonal ? '#f3e5f5' : 'white',
                           color: isInitial ? '#000' : 
                                  isLastSolved ? '#155724' : 
                                  '#333',
                           cursor: isInitial ? 'not-allowed' : 'pointer',
                           caretColor: 'transparent', // Hide cursor/caret
                           transition: 'all 0.2s',
-                          borderRight: (colIndex + 1) % 3 === 0 && colIndex !== 8 ? '2px solid #333' : 'none',
-                          borderBottom: Math.floor(rowIndex / 3) === 0 && rowIndex === 2 || 
-                                       Math.floor(rowIndex / 3) === 1 && rowIndex === 5 ? '2px solid #333' : 'none',
+                          borderRight: boxRight ? '2px solid #333' : 'none',
+                          borderBottom: boxBottom ? '2px solid #333' : 'none',
                           outline: isSelected ? '2px solid #007bff' : 'none',
                           animation: isHintHighlighted ? 'hint-pulse 1.5s ease-in-out infinite' : 
                                     isLastSolved ? 'fade-in 0.5s ease-in-out' : 'none'
@@ -567,6 +690,34 @@ function Game() {
                         onClick={() => handleCellClick(rowIndex, colIndex)}
                         maxLength={1}
                       />
+                      {gameState.parities && gameState.parities[pos] !== '.' && (
+                        // Even cells show a grey square, odd cells a grey circle, behind the digit
+                        <div style={{
+                          position: 'absolute',
+                          top: '18%', left: '18%', width: '64%', height: '64%',
+                          backgroundColor: 'rgba(0, 0, 0, 0.12)',
+                          borderRadius: gameState.parities[pos] === 'o' ? '50%' : '0',
+                          pointerEvents: 'none'
+                        }} />
+                      )}
+                      {cages && (
+                        <div style={{
+                          position: 'absolute',
+                          inset: '3px',
+                          pointerEvents: 'none',
+                          borderTop: cageEdge(rowIndex === 0 ? -1 : pos - 9),
+                          borderBottom: cageEdge(rowIndex === 8 ? -1 : pos + 9),
+                          borderLeft: cageEdge(colIndex === 0 ? -1 : pos - 1),
+                          borderRight: cageEdge(colIndex === 8 ? -1 : pos + 1)
+                        }}>
+                          {cages.sums[pos] && (
+                            <span style={{ position: 'absolute', top: '-2px', left: '1px', fontSize: '9px', color: '#555', background: 'inherit' }}>
+                              {cages.sums[pos]}
+                            </span>
+                          )}
+                        </div>
+                      )}
+                      </div>
                     );
                   })
                 ))}
@@ -650,7 +801,7 @@ function Game() {
         {gameState.mode === 'learn' && (
           <Group justify="center" gap={4}>
             <Button variant="outline" color="yellow" size="sm" onClick={getHint}>
-              💡 {hintState.step === 'none' ? 'Hint' : 'Fill'}
+              💡 {hintState.step === 'none' ? 'Hint' : hintState.step === 'technique' ? 'Show Cell' : 'Fill'}
             </Button>
             <Button variant="outline" color="orange" size="sm" onClick={solveStep}>
               🔧 Step
@@ -660,6 +811,9 @@ function Game() {
             </Button>
           </Group>
         )}
+        {hintState.text && (
+          <Text size="sm" c="white" ta="center">{hintState.text}</Text>
+        )}
         <Group justify="center" gap={4}>
           <Button 
             variant="filled" 
@@ -683,6 +837,12 @@ function Game() {
                 initialBoard: Array(9).fill().map(() => Array(9).fill(0)),
                 mode: 'play',
                 difficulty: 'easy',
+                variant: 'classic',
+                cages: null,
+                regions: null,
+                parities: null,
+                antiKnight: false,
+                antiKing: false,
                 startedAt: null,
                 timer: 0,
                 usedHints: false,
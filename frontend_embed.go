@@ -0,0 +1,22 @@
+//go:build embedfrontend
+
+package main
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// The production build of the frontend, created by `npm run build` before building with -tags embedfrontend
+//
+//go:embed all:frontend/build
+var frontendBuild embed.FS
+
+// Serve the embedded frontend from the API server, so the app and the API share one deployment
+func frontendAssets() fs.FS {
+	assets, err := fs.Sub(frontendBuild, "frontend/build")
+	if err != nil {
+		panic(err)
+	}
+	return assets
+}
@@ -0,0 +1,10 @@
+//go:build !embedfrontend
+
+package main
+
+import "io/fs"
+
+// Regular builds serve only the API; the frontend is deployed on its own
+func frontendAssets() fs.FS {
+	return nil
+}
@@ -0,0 +1,47 @@
+package anticheat
+
+import (
+	"time"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+const (
+	// Fastest believable pace for filling a cell
+	minSecondsPerCell = 1
+	// Allowed gap between the reported time and the time measured by the server
+	maxTimeDrift = 5 * time.Minute
+)
+
+// Check returns the reasons a completed game looks suspicious, or nil if it looks fine.
+// submittedAt is the server time at which the result was received.
+func Check(gameResult *models.GameResult, submittedAt time.Time) []string {
+	var reasons []string
+
+	filled := 0
+	start := sudoku.StringToBoard(gameResult.Puzzle.StartingGrid)
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if start[i][j] == 0 {
+				filled++
+			}
+		}
+	}
+	if gameResult.TimeSeconds < filled*minSecondsPerCell {
+		reasons = append(reasons, "solve time is faster than humanly possible")
+	}
+
+	// Wall time is the reference even for clients sending heartbeats: pauses are reported by the
+	// client, so a long one is left for a moderator to judge
+	elapsed := submittedAt.Sub(gameResult.StartedAt)
+	reported := time.Duration(gameResult.TimeSeconds) * time.Second
+	if elapsed-reported > maxTimeDrift {
+		reasons = append(reasons, "reported time is much shorter than the server-measured session")
+	}
+	if reported-elapsed > maxTimeDrift {
+		reasons = append(reasons, "reported time is longer than the session itself")
+	}
+
+	return reasons
+}
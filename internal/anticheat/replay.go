@@ -0,0 +1,46 @@
+package anticheat
+
+import (
+	"time"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Allowed lag between the last replay move and the time the game reports
+const maxReplayLag = 10 * time.Second
+
+// CheckReplay returns the reasons a completed game's replay doesn't back up its result, or nil
+// if the replay, played on the starting grid, reproduces the final grid within the game's time
+func CheckReplay(gameResult *models.GameResult, events []models.ReplayEvent) []string {
+	var reasons []string
+
+	start := sudoku.StringToBoard(gameResult.Puzzle.StartingGrid)
+	board := start
+	changesGiven := false
+	for _, event := range events {
+		if start[event.Row][event.Col] != 0 {
+			changesGiven = true
+			continue
+		}
+		board[event.Row][event.Col] = event.Value
+	}
+	if changesGiven {
+		reasons = append(reasons, "replay changes a given cell")
+	}
+	if sudoku.BoardToString(board) != gameResult.FinalGrid {
+		reasons = append(reasons, "replay does not reproduce the final grid")
+	}
+
+	// Games sending heartbeats are held to the server-measured active time. It only leaves out
+	// pauses the client reported, so a replay running through one goes to review.
+	limit := time.Duration(gameResult.TimeSeconds) * time.Second
+	if gameResult.LastSeenAt != nil {
+		limit = time.Duration(gameResult.ActiveSeconds) * time.Second
+	}
+	if len(events) > 0 && time.Duration(events[len(events)-1].AtMillis)*time.Millisecond > limit+maxReplayLag {
+		reasons = append(reasons, "replay takes longer than the reported time")
+	}
+
+	return reasons
+}
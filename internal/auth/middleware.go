@@ -18,6 +18,10 @@ func AuthMiddleware(authService *Service) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
+			// Browsers can't set headers on WebSocket handshakes, so those may pass the token in the query
+			if authHeader == "" && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") && r.URL.Query().Get("token") != "" {
+				authHeader = "Bearer " + r.URL.Query().Get("token")
+			}
 			if authHeader == "" {
 				http.Error(w, "Authorization header required", http.StatusUnauthorized)
 				return
@@ -44,3 +48,20 @@ func AuthMiddleware(authService *Service) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// AdminMiddleware must run after AuthMiddleware and only lets admin users through
+func AdminMiddleware(authService *Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value(UserIDKey).(uint)
+
+			user, err := authService.GetUserByID(userID)
+			if err != nil || !user.IsAdmin {
+				http.Error(w, "Admin access required", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -9,10 +9,20 @@ import (
 	"gorm.io/gorm"
 
 	"sudoku/internal/models"
+	"sudoku/internal/vault"
 )
 
+var (
+	ErrUsernameTaken = errors.New("username already exists")
+	ErrEmailTaken    = errors.New("email already exists")
+)
+
+// Signing key used until SetSecret is called, only fit for development
+const developmentSecret = "your-secret-key"
+
 type Service struct {
-	db *gorm.DB
+	db     *gorm.DB
+	secret []byte
 }
 
 type Claims struct {
@@ -22,14 +32,22 @@ type Claims struct {
 }
 
 func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+	return &Service{db: db, secret: []byte(developmentSecret)}
 }
 
-func (s *Service) Register(username, email, password string) (*models.User, error) {
+// SetSecret sets the key tokens are signed with. Tokens signed with the previous key stop validating.
+func (s *Service) SetSecret(secret string) {
+	s.secret = []byte(secret)
+}
+
+func (s *Service) Register(username, email, password string, region models.Region) (*models.User, error) {
 	// Check if user already exists
 	var existingUser models.User
-	if err := s.db.Where("username = ? OR email = ?", username, email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("username or email already exists")
+	if err := s.db.Where("username = ? OR email_hash = ?", username, vault.Index(email)).First(&existingUser).Error; err == nil {
+		if existingUser.Username == username {
+			return nil, ErrUsernameTaken
+		}
+		return nil, ErrEmailTaken
 	}
 
 	// Hash password
@@ -39,9 +57,11 @@ func (s *Service) Register(username, email, password string) (*models.User, erro
 	}
 
 	user := &models.User{
-		Username: username,
-		Email:    email,
-		Password: string(hashedPassword),
+		Username:  username,
+		Email:     email,
+		EmailHash: vault.Index(email),
+		Password:  string(hashedPassword),
+		Region:    region,
 	}
 
 	if err := s.db.Create(user).Error; err != nil {
@@ -81,12 +101,12 @@ func (s *Service) GenerateToken(userID uint, username string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte("your-secret-key")) // In production, use environment variable
+	return token.SignedString(s.secret)
 }
 
 func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte("your-secret-key"), nil // In production, use environment variable
+		return s.secret, nil
 	})
 
 	if err != nil {
@@ -107,3 +127,40 @@ func (s *Service) GetUserByID(userID uint) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// UpdatePreferences stores the user's timezone, locale and email choices
+func (s *Service) UpdatePreferences(userID uint, timezone, locale string, digestOptIn bool) (*models.User, error) {
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"timezone":      timezone,
+		"locale":        locale,
+		"digest_opt_in": digestOptIn,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return s.GetUserByID(userID)
+}
+
+// SetRegion records the region of a user who has none yet
+func (s *Service) SetRegion(user *models.User, region models.Region) error {
+	if user.Region != "" || region == "" {
+		return nil
+	}
+	if err := s.db.Model(user).Update("region", region).Error; err != nil {
+		return err
+	}
+	user.Region = region
+	return nil
+}
+
+// Rename changes a user's username
+func (s *Service) Rename(userID uint, username string) (*models.User, error) {
+	var existingUser models.User
+	if err := s.db.Where("username = ? AND id <> ?", username, userID).First(&existingUser).Error; err == nil {
+		return nil, errors.New("username already exists")
+	}
+
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("username", username).Error; err != nil {
+		return nil, err
+	}
+	return s.GetUserByID(userID)
+}
@@ -0,0 +1,281 @@
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"sudoku/internal/migrate"
+	"sudoku/internal/models"
+	"sudoku/internal/vault"
+)
+
+// Version of the archive layout, bumped when restoring needs to treat archives differently
+const FormatVersion = 1
+
+// Rows read from the source database per query
+const batchSize = 1000
+
+var ErrNotEmpty = errors.New("target database already has users; restore needs a fresh database")
+
+// table is one table of the archive. refs maps columns holding the id of a row in an earlier
+// table to that table, so ids can be remapped on restore.
+type table struct {
+	name  string
+	model interface{}
+	refs  map[string]string
+	drop  []string // Columns left out of the archive
+}
+
+// Tables in restore order, every table after the ones it references
+var tables = []table{
+	// Blind indexes depend on the index key, the restore fills them in with the target's key
+	{name: "users", model: &models.User{}, drop: []string{"email_hash"}},
+	{name: "puzzles", model: &models.Puzzle{}},
+	{name: "games", model: &models.Game{}, refs: map[string]string{"user_id": "users", "puzzle_id": "puzzles"}},
+	// Events aren't part of the archive
+	{name: "game_results", model: &models.GameResult{}, refs: map[string]string{"user_id": "users", "puzzle_id": "puzzles", "game_id": "games"}, drop: []string{"event_id"}},
+}
+
+// Manifest describes an archive
+type Manifest struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Rows      map[string]int `json:"rows"` // Rows per table
+}
+
+// Export writes users, puzzles, games and game results to a zip archive: a manifest and one JSON
+// lines file per table. Soft-deleted rows are included. Encrypted columns are written decrypted,
+// so the archive can be restored under other keys and must be stored as carefully as the database.
+func Export(db *gorm.DB, w io.Writer) (*Manifest, error) {
+	archive := zip.NewWriter(w)
+	manifest := &Manifest{Version: FormatVersion, CreatedAt: time.Now(), Rows: map[string]int{}}
+
+	for _, t := range tables {
+		s, err := parse(db, t)
+		if err != nil {
+			return nil, err
+		}
+		file, err := archive.Create(t.name + ".jsonl")
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(file)
+
+		var lastID interface{} = 0
+		for {
+			var rows []map[string]interface{}
+			err := db.Unscoped().Model(t.model).Where("id > ?", lastID).Order("id").Limit(batchSize).Find(&rows).Error
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", t.name, err)
+			}
+			for _, row := range rows {
+				if err := exportRow(s, t, row); err != nil {
+					return nil, err
+				}
+				if err := encoder.Encode(row); err != nil {
+					return nil, err
+				}
+			}
+			manifest.Rows[t.name] += len(rows)
+			if len(rows) < batchSize {
+				break
+			}
+			lastID = rows[len(rows)-1]["id"]
+		}
+	}
+
+	file, err := archive.Create("manifest.json")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(file).Encode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, archive.Close()
+}
+
+// Drop the left out columns and decrypt encrypted ones
+func exportRow(s *schema.Schema, t table, row map[string]interface{}) error {
+	for _, column := range t.drop {
+		delete(row, column)
+	}
+	for column, value := range row {
+		field := s.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		if _, ok := field.Serializer.(vault.Serializer); !ok || value == nil {
+			continue
+		}
+		raw, _ := value.(string)
+		plain, err := vault.Reveal(field, raw)
+		if err != nil {
+			return fmt.Errorf("%s %v: %w", t.name, row["id"], err)
+		}
+		row[column] = plain
+	}
+	return nil
+}
+
+// Restore loads an archive written by Export into a fresh database. The schema is migrated
+// first; columns the current schema no longer has are skipped and new ones take their defaults.
+// Every row gets a new id and references between the tables are remapped. Encrypted columns are
+// encrypted with the configured keys and blind indexes filled in.
+func Restore(db *gorm.DB, archive *zip.Reader) (*Manifest, error) {
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("archive version %d is newer than this tool supports (%d)", manifest.Version, FormatVersion)
+	}
+
+	for _, t := range tables {
+		if err := db.AutoMigrate(t.model); err != nil {
+			return nil, err
+		}
+	}
+	var users int64
+	if err := db.Unscoped().Model(&models.User{}).Count(&users).Error; err != nil {
+		return nil, err
+	}
+	if users > 0 {
+		return nil, ErrNotEmpty
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// New ids by table and old id
+		ids := map[string]map[int64]interface{}{}
+		for _, t := range tables {
+			mapped, err := restoreTable(tx, archive, t, ids)
+			if err != nil {
+				return err
+			}
+			ids[t.name] = mapped
+		}
+		_, err := migrate.EncryptUsers(tx, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func readManifest(archive *zip.Reader) (*Manifest, error) {
+	file, err := archive.Open("manifest.json")
+	if err != nil {
+		return nil, errors.New("archive has no manifest.json")
+	}
+	defer file.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+func restoreTable(tx *gorm.DB, archive *zip.Reader, t table, ids map[string]map[int64]interface{}) (map[int64]interface{}, error) {
+	s, err := parse(tx, t)
+	if err != nil {
+		return nil, err
+	}
+	file, err := archive.Open(t.name + ".jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("archive has no %s.jsonl", t.name)
+	}
+	defer file.Close()
+
+	mapped := map[int64]interface{}{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		row, oldID, err := decodeRow(scanner.Bytes(), s)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", t.name, line, err)
+		}
+		for column, target := range t.refs {
+			ref, ok := row[column].(int64)
+			if !ok {
+				continue
+			}
+			newID, ok := ids[target][ref]
+			if !ok {
+				return nil, fmt.Errorf("%s line %d: %s %d is not in the archive", t.name, line, column, ref)
+			}
+			row[column] = newID
+		}
+
+		if err := tx.Model(t.model).Create(row).Error; err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", t.name, line, err)
+		}
+		mapped[oldID] = row["id"]
+	}
+	return mapped, scanner.Err()
+}
+
+// Decode an archived row into values for the current schema, returning its old id separately
+func decodeRow(data []byte, s *schema.Schema) (map[string]interface{}, int64, error) {
+	var archived map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&archived); err != nil {
+		return nil, 0, err
+	}
+
+	row := map[string]interface{}{}
+	var oldID int64
+	for column, value := range archived {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			continue // Dropped from the schema since the backup was made
+		}
+		value, err := convert(field, value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", column, err)
+		}
+		if field.PrimaryKey {
+			oldID, _ = value.(int64)
+			continue
+		}
+		row[field.DBName] = value
+	}
+	if oldID == 0 {
+		return nil, 0, errors.New("row has no id")
+	}
+	return row, oldID, nil
+}
+
+// Turn a JSON value back into the Go type of its column
+func convert(field *schema.Field, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if field.DataType == schema.Float {
+			return v.Float64()
+		}
+		return v.Int64()
+	case string:
+		if field.DataType == schema.Time {
+			return time.Parse(time.RFC3339Nano, v)
+		}
+	}
+	return value, nil
+}
+
+func parse(db *gorm.DB, t table) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(t.model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
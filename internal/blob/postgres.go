@@ -0,0 +1,84 @@
+package blob
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+// PostgresStore keeps blobs as Postgres large objects. The content lives in pg_largeobject,
+// outside the application's tables, and large_objects maps each key to its object.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Put(key string, data []byte) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Replace the existing object, if any
+		var existing models.LargeObject
+		err := tx.Where("key = ?", key).First(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if err := unlink(tx, existing); err != nil {
+				return err
+			}
+		}
+
+		var oid uint32
+		if err := tx.Raw("SELECT lo_from_bytea(0, ?)", data).Scan(&oid).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.LargeObject{Key: key, OID: oid}).Error
+	})
+}
+
+func (s *PostgresStore) Get(key string) ([]byte, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+	var data []byte
+	result := s.db.Raw("SELECT lo_get(oid) FROM large_objects WHERE key = ?", key).Scan(&data)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *PostgresStore) Delete(key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var object models.LargeObject
+		err := tx.Where("key = ?", key).First(&object).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return unlink(tx, object)
+	})
+}
+
+// Remove a large object and its key mapping
+func unlink(tx *gorm.DB, object models.LargeObject) error {
+	if err := tx.Exec("SELECT lo_unlink(?)", object.OID).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&object).Error
+}
@@ -0,0 +1,101 @@
+package blob
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/models"
+)
+
+// Blobs removed per cleanup run
+const cleanupBatchSize = 100
+
+// How long blobs of each kind are kept, zero keeps them until deleted
+var Retention = map[models.BlobKind]time.Duration{
+	models.ReplayBlob:     180 * 24 * time.Hour,
+	models.ShareImageBlob: 30 * 24 * time.Hour,
+}
+
+// Service stores blobs in the configured Store and tracks them in the blobs table
+type Service struct {
+	db    *gorm.DB
+	store Store
+}
+
+func NewService(db *gorm.DB, store Store) *Service {
+	return &Service{db: db, store: store}
+}
+
+// Put stores the content under the key, replacing an existing blob, and restarts its retention period
+func (s *Service) Put(kind models.BlobKind, key, contentType string, data []byte, gameResultID *uint) (*models.Blob, error) {
+	if err := s.store.Put(key, data); err != nil {
+		return nil, err
+	}
+
+	blob := models.Blob{
+		Key:          key,
+		Kind:         kind,
+		GameResultID: gameResultID,
+		ContentType:  contentType,
+		Size:         len(data),
+	}
+	if retention := Retention[kind]; retention > 0 {
+		expiresAt := time.Now().Add(retention)
+		blob.ExpiresAt = &expiresAt
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "game_result_id", "content_type", "size", "expires_at", "updated_at"}),
+	}).Create(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// Get returns a blob's record and content. ErrNotFound is returned for unknown or expired keys.
+func (s *Service) Get(key string) (*models.Blob, []byte, error) {
+	var blob models.Blob
+	err := s.db.Where("key = ? AND (expires_at IS NULL OR expires_at > ?)", key, time.Now()).First(&blob).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := s.store.Get(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &blob, data, nil
+}
+
+// Delete removes a blob's content and record
+func (s *Service) Delete(key string) error {
+	if err := s.store.Delete(key); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return s.db.Where("key = ?", key).Delete(&models.Blob{}).Error
+}
+
+// Cleanup removes expired blobs. It is meant to run as a periodic job;
+// blobs failing to delete are retried on the next run.
+func (s *Service) Cleanup() error {
+	var blobs []models.Blob
+	err := s.db.Where("expires_at <= ?", time.Now()).Order("expires_at").Limit(cleanupBatchSize).Find(&blobs).Error
+	if err != nil {
+		return err
+	}
+
+	var failed error
+	for _, blob := range blobs {
+		if err := s.Delete(blob.Key); err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
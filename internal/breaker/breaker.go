@@ -0,0 +1,95 @@
+package breaker
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Breaker trips after a run of consecutive database failures. While open, wrapped
+// endpoints fail fast instead of queueing on a database that isn't answering.
+// After the cooldown requests go through again; the first success closes it.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Watch records the outcome of every query made through db
+func (b *Breaker) Watch(db *gorm.DB) error {
+	record := func(tx *gorm.DB) { b.Record(tx.Error) }
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("breaker:create", record); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("breaker:query", record); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("breaker:update", record); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("breaker:delete", record); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register("breaker:row", record); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("breaker:raw", record)
+}
+
+// Record counts a query outcome. Errors reported by Postgres itself (constraint
+// violations, bad SQL) and missing rows mean the database is healthy.
+func (b *Breaker) Record(err error) {
+	var pgErr *pgconn.PgError
+	healthy := err == nil || errors.Is(err, gorm.ErrRecordNotFound) || errors.As(err, &pgErr)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if healthy {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		if time.Now().After(b.openUntil) {
+			log.Printf("Database circuit breaker open after %d failures: %v", b.failures, err)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// RetryAfter returns how long the breaker stays open, zero when it is closed
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Until(b.openUntil)
+}
+
+// Middleware answers 503 with Retry-After while the breaker is open. Use it on
+// non-critical endpoints only, so game submission always reaches the database.
+func Middleware(b *Breaker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait := b.RetryAfter(); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+				http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Client calls the sudoku API on behalf of one user. Log in or register before calling
+// the protected endpoints.
+type Client struct {
+	BaseURL string
+	Token   string // JWT sent on every request, set by Login and Register
+	HTTP    *http.Client
+}
+
+// APIError is returned for responses with an error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Game is a game session opened on the server
+type Game struct {
+	GameResultID uint          `json:"game_result_id"`
+	Puzzle       models.Puzzle `json:"puzzle"`
+	StartedAt    time.Time     `json:"started_at"`
+}
+
+// SubmitRequest is a finished grid sent for grading
+type SubmitRequest struct {
+	GameResultID  uint   `json:"game_result_id"`
+	FinalGrid     string `json:"final_grid"`
+	TimeSeconds   int    `json:"time_seconds"`
+	UsedHints     bool   `json:"used_hints"`
+	UsedAutoSolve bool   `json:"used_auto_solve"`
+}
+
+// SubmitResult is the grade of a submitted game
+type SubmitResult struct {
+	Correct      bool `json:"correct"`
+	Score        int  `json:"score"`
+	Disqualified bool `json:"disqualified"`
+	TimeSeconds  int  `json:"time_seconds"`
+}
+
+// Register creates an account and logs in as it
+func (c *Client) Register(username, email, password string) error {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"username": username, "email": email, "password": password}
+	if err := c.do(http.MethodPost, "/auth/register", body, &resp); err != nil {
+		return err
+	}
+	c.Token = resp.Token
+	return nil
+}
+
+func (c *Client) Login(username, password string) error {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"username": username, "password": password}
+	if err := c.do(http.MethodPost, "/auth/login", body, &resp); err != nil {
+		return err
+	}
+	c.Token = resp.Token
+	return nil
+}
+
+// StartGame opens a game on a new puzzle. mode is "play" or "learn".
+func (c *Client) StartGame(difficulty, mode string) (*Game, error) {
+	var game Game
+	err := c.do(http.MethodPost, "/game/start", map[string]string{"difficulty": difficulty, "mode": mode}, &game)
+	if err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// Hint asks for a tiered hint on the next move of grid. cell picks the cell to fill at sudoku.ValueLevel.
+func (c *Client) Hint(gameResultID uint, grid string, level int, cell *sudoku.Cell) (*sudoku.TieredHint, error) {
+	body := map[string]interface{}{"game_result_id": gameResultID, "current_grid": grid, "level": level}
+	if cell != nil {
+		body["row"], body["col"] = cell.Row, cell.Col
+	}
+	var hint sudoku.TieredHint
+	if err := c.do(http.MethodPost, "/game/hint", body, &hint); err != nil {
+		return nil, err
+	}
+	return &hint, nil
+}
+
+// CheckCells returns the filled cells of grid that don't match the solution
+func (c *Client) CheckCells(gameResultID uint, grid string) ([]sudoku.Cell, error) {
+	var resp struct {
+		Incorrect []sudoku.Cell `json:"incorrect"`
+	}
+	body := map[string]interface{}{"game_result_id": gameResultID, "current_grid": grid}
+	if err := c.do(http.MethodPost, "/game/check-cells", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Incorrect, nil
+}
+
+// Heartbeat reports that the game is being played
+func (c *Client) Heartbeat(gameResultID uint) error {
+	return c.do(http.MethodPost, fmt.Sprintf("/game/%d/heartbeat", gameResultID), struct{}{}, nil)
+}
+
+func (c *Client) SubmitGame(req SubmitRequest) (*SubmitResult, error) {
+	var result SubmitResult
+	if err := c.do(http.MethodPost, "/game/submit", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SaveReplay uploads the move-by-move replay of a game
+func (c *Client) SaveReplay(gameResultID uint, events []models.ReplayEvent) error {
+	body := map[string]interface{}{"events": events}
+	return c.do(http.MethodPut, fmt.Sprintf("/game/%d/replay", gameResultID), body, nil)
+}
+
+// CreateRace opens a race and waits for an opponent to join it
+func (c *Client) CreateRace(difficulty string) (*models.Race, error) {
+	var race models.Race
+	if err := c.do(http.MethodPost, "/race", map[string]string{"difficulty": difficulty}, &race); err != nil {
+		return nil, err
+	}
+	return &race, nil
+}
+
+// JoinRace takes the open seat of a race, starting it
+func (c *Client) JoinRace(raceID uint) (*models.Race, error) {
+	var race models.Race
+	if err := c.do(http.MethodPost, fmt.Sprintf("/race/%d/join", raceID), struct{}{}, &race); err != nil {
+		return nil, err
+	}
+	return &race, nil
+}
+
+// RaceGrid returns the starting grid of a race
+func (c *Client) RaceGrid(raceID uint) (string, error) {
+	var resp struct {
+		StartingGrid string `json:"starting_grid"`
+	}
+	if err := c.do(http.MethodGet, fmt.Sprintf("/race/%d", raceID), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.StartingGrid, nil
+}
+
+// DialRace opens the WebSocket of a running race. Moves are sent as race.Message and
+// events arrive as race.Event.
+func (c *Client) DialRace(raceID uint) (*websocket.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(c.BaseURL, "http") + fmt.Sprintf("/race/%d/ws?token=%s", raceID, url.QueryEscape(c.Token))
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil && resp != nil {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: err.Error()}
+	}
+	return conn, err
+}
+
+// Leaderboard returns the top results, sorted by "score" or "time". An empty difficulty covers all of them.
+func (c *Client) Leaderboard(difficulty, sortBy string) ([]leaderboard.Entry, error) {
+	query := url.Values{}
+	if difficulty != "" {
+		query.Set("difficulty", difficulty)
+	}
+	if sortBy != "" {
+		query.Set("type", sortBy)
+	}
+	entries := []leaderboard.Entry{}
+	if err := c.do(http.MethodGet, "/leaderboard?"+query.Encode(), nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Send a JSON request and decode the JSON response into out, if given
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
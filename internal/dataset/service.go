@@ -0,0 +1,238 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/blob"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Columns a dataset may contain. None of them identify a player on their own; the k-anonymity
+// check keeps rare combinations of them out of the published file.
+const (
+	PuzzleField     = "puzzle"            // Starting grid, 81 digits with 0 for empty cells
+	DifficultyField = "difficulty"        // Difficulty the puzzle was served at
+	RatingField     = "rating"            // Technique-based rating of the puzzle
+	TimeField       = "time"              // Solve time in seconds, rounded down to the time bucket
+	TechniqueField  = "techniques"        // Techniques of the puzzle's solve path, in order of first use
+	HardestField    = "hardest_technique" // Hardest technique the solve needs
+	MonthField      = "month"             // Month the solve was completed, YYYY-MM
+)
+
+// Fields lists every available column in the order they are written
+var Fields = []string{PuzzleField, DifficultyField, RatingField, TimeField, TechniqueField, HardestField, MonthField}
+
+// DefaultFields are exported when an admin doesn't pick any
+var DefaultFields = []string{PuzzleField, DifficultyField, TimeField, TechniqueField}
+
+const (
+	// Fewest distinct players a group of identical rows must have to be published
+	DefaultMinGroupSize = 5
+	// Solve times are rounded down to this many seconds by default
+	DefaultTimeBucket = 30
+	// Time allowed to trace the solve path of one puzzle
+	traceTimeout = 5 * time.Second
+)
+
+// Key is the blob key of an export's file
+func Key(exportID uint) string {
+	return fmt.Sprintf("datasets/%d.csv", exportID)
+}
+
+// ParseFields validates a list of column names and puts them in dataset order.
+// An empty list selects DefaultFields.
+func ParseFields(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return DefaultFields, nil
+	}
+	chosen := map[string]bool{}
+	for _, name := range names {
+		known := false
+		for _, field := range Fields {
+			known = known || field == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		chosen[name] = true
+	}
+	fields := []string{}
+	for _, field := range Fields {
+		if chosen[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// Service builds anonymized solve datasets and publishes them through blob storage
+type Service struct {
+	db            *gorm.DB
+	blobService   *blob.Service
+	sudokuService *sudoku.Service
+}
+
+func NewService(db *gorm.DB, blobService *blob.Service, sudokuService *sudoku.Service) *Service {
+	return &Service{db: db, blobService: blobService, sudokuService: sudokuService}
+}
+
+// A solve as read from the database, before anonymization
+type solve struct {
+	UserID           uint
+	PuzzleID         uint
+	TimeSeconds      int
+	CompletedAt      time.Time
+	StartingGrid     string
+	Difficulty       string
+	Rating           string
+	HardestTechnique string
+}
+
+// ExportPending builds every pending export, oldest first. It is run periodically by the export
+// worker; an export that fails is marked failed with its error and not retried.
+func (s *Service) ExportPending() error {
+	var pending []models.DatasetExport
+	if err := s.db.Where("status = ?", models.ExportPending).Order("created_at").Find(&pending).Error; err != nil {
+		return err
+	}
+
+	for i := range pending {
+		export := &pending[i]
+		updates := map[string]interface{}{}
+		if err := s.export(export); err != nil {
+			log.Printf("Failed to build dataset export %d: %v", export.ID, err)
+			updates["status"] = models.ExportFailed
+			updates["error"] = err.Error()
+		} else {
+			updates["status"] = models.ExportCompleted
+			updates["rows"] = export.Rows
+			updates["suppressed"] = export.Suppressed
+			updates["blob_key"] = export.BlobKey
+			updates["completed_at"] = time.Now()
+		}
+		if err := s.db.Model(export).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build the dataset of an export and store it. Rows are grouped on all their columns and a group
+// is only published if at least MinGroupSize distinct players are in it; the rest are suppressed.
+// Player ids are only used for this count and never written.
+func (s *Service) export(export *models.DatasetExport) error {
+	fields, err := ParseFields(strings.Split(export.Fields, ","))
+	if err != nil {
+		return err
+	}
+	if export.MinGroupSize < 2 || export.TimeBucket < 1 {
+		return errors.New("invalid anonymity settings")
+	}
+
+	var solves []solve
+	err = s.db.Table("game_results").
+		Select("game_results.user_id, game_results.puzzle_id, game_results.time_seconds, game_results.completed_at, "+
+			"puzzles.starting_grid, puzzles.difficulty, puzzles.rating, puzzles.hardest_technique").
+		Joins("JOIN users ON game_results.user_id = users.id").
+		Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").
+		Scopes(models.Active("game_results", "users", "puzzles")).
+		Where("game_results.mode = ? AND game_results.completed = ? AND game_results.disqualified = ?", models.PlayMode, true, false).
+		Where("game_results.voided = ? AND game_results.under_review = ? AND game_results.practice = ?", false, false, false).
+		Where("game_results.time_seconds > 0").
+		Scan(&solves).Error
+	if err != nil {
+		return err
+	}
+
+	traces := map[uint]string{}
+	groups := map[string]map[uint]bool{}
+	rows := map[string]int{}
+	for _, sv := range solves {
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			switch field {
+			case PuzzleField:
+				row[i] = sv.StartingGrid
+			case DifficultyField:
+				row[i] = sv.Difficulty
+			case RatingField:
+				row[i] = sv.Rating
+			case TimeField:
+				row[i] = strconv.Itoa(sv.TimeSeconds / export.TimeBucket * export.TimeBucket)
+			case TechniqueField:
+				trace, ok := traces[sv.PuzzleID]
+				if !ok {
+					trace = s.trace(sv.StartingGrid)
+					traces[sv.PuzzleID] = trace
+				}
+				row[i] = trace
+			case HardestField:
+				row[i] = sv.HardestTechnique
+			case MonthField:
+				row[i] = sv.CompletedAt.UTC().Format("2006-01")
+			}
+		}
+
+		key := strings.Join(row, "\x00")
+		if groups[key] == nil {
+			groups[key] = map[uint]bool{}
+		}
+		groups[key][sv.UserID] = true
+		rows[key]++
+	}
+
+	// Sorting the groups keeps the order of the file from hinting at when or by whom rows were added
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	out.Write(fields)
+	export.Rows, export.Suppressed = 0, 0
+	for _, key := range keys {
+		if len(groups[key]) < export.MinGroupSize {
+			export.Suppressed += rows[key]
+			continue
+		}
+		row := strings.Split(key, "\x00")
+		for n := 0; n < rows[key]; n++ {
+			out.Write(row)
+		}
+		export.Rows += rows[key]
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+
+	export.BlobKey = Key(export.ID)
+	_, err = s.blobService.Put(models.DatasetBlob, export.BlobKey, "text/csv", buf.Bytes(), nil)
+	return err
+}
+
+// Techniques of a puzzle's solve path joined with ">", empty if it can't be traced in time
+func (s *Service) trace(grid string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), traceTimeout)
+	defer cancel()
+
+	path, err := s.sudokuService.SolvePath(ctx, sudoku.StringToBoard(grid))
+	if err != nil {
+		return ""
+	}
+	return strings.Join(path.Techniques, ">")
+}
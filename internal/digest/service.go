@@ -0,0 +1,128 @@
+package digest
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/locale"
+	"sudoku/internal/mail"
+	"sudoku/internal/models"
+	"sudoku/internal/stats"
+)
+
+// Service emails opted-in users a summary of their previous week
+type Service struct {
+	db                 *gorm.DB
+	statsService       *stats.Service
+	leaderboardService *leaderboard.Service
+	sender             mail.Sender
+}
+
+func NewService(db *gorm.DB, statsService *stats.Service, leaderboardService *leaderboard.Service, sender mail.Sender) *Service {
+	return &Service{
+		db:                 db,
+		statsService:       statsService,
+		leaderboardService: leaderboardService,
+		sender:             sender,
+	}
+}
+
+// SendDue sends last week's digest to every opted-in user who hasn't had it yet.
+// It is run periodically; users already sent this week's digest are skipped.
+func (s *Service) SendDue() error {
+	now := time.Now()
+	weekStart := leaderboard.PeriodStart(models.WeeklySnapshot, now.UTC())
+
+	var users []models.User
+	err := s.db.Where("digest_opt_in = ? AND (last_digest_at IS NULL OR last_digest_at < ?)", true, weekStart).
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := s.send(user, weekStart.AddDate(0, 0, -7), now); err != nil {
+			// Leave last_digest_at alone so the next run retries
+			log.Printf("Failed to send digest to user %d: %v", user.ID, err)
+			continue
+		}
+		if err := s.db.Model(&user).Update("last_digest_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) send(user models.User, start, now time.Time) error {
+	summary, err := s.statsService.Summarize(user.ID, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		return err
+	}
+
+	format := locale.NewFormatter(user.Locale, user.Timezone)
+	streak, err := s.statsService.Streak(user.ID, now, format.Location())
+	if err != nil {
+		return err
+	}
+
+	rank, ranked, err := s.leaderboardService.Rank(models.WeeklySnapshot, start, user.ID)
+	if err != nil {
+		return err
+	}
+	previousRank, previouslyRanked, err := s.leaderboardService.Rank(models.WeeklySnapshot, start.AddDate(0, 0, -7), user.ID)
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nHere is your week of %s:\n\n", user.Username, format.Day(start))
+	fmt.Fprintf(&body, "Games played: %s\nPoints earned: %s\n", format.Number(summary.GamesPlayed), format.Number(summary.Points))
+
+	var difficulties []string
+	for difficulty := range summary.BestTimes {
+		difficulties = append(difficulties, string(difficulty))
+	}
+	sort.Strings(difficulties)
+	for _, difficulty := range difficulties {
+		best := time.Duration(summary.BestTimes[models.Difficulty(difficulty)]) * time.Second
+		fmt.Fprintf(&body, "Best %s time: %s\n", difficulty, format.Duration(best))
+	}
+
+	if summary.GoalsMet > 0 {
+		fmt.Fprintf(&body, "Goals met: %s\n", format.Number(summary.GoalsMet))
+	}
+
+	switch {
+	case ranked && previouslyRanked:
+		fmt.Fprintf(&body, "Weekly rank: #%d (%s)\n", rank, rankChange(previousRank, rank))
+	case ranked:
+		fmt.Fprintf(&body, "Weekly rank: #%d (new on the leaderboard)\n", rank)
+	case previouslyRanked:
+		fmt.Fprintf(&body, "Weekly rank: not placed (was #%d)\n", previousRank)
+	}
+
+	if streak > 0 {
+		fmt.Fprintf(&body, "Current streak: %d days. Play today to keep it going!\n", streak)
+	} else {
+		body.WriteString("No active streak. Solve a puzzle today to start one!\n")
+	}
+	body.WriteString("\nYou can turn off this email in your profile settings.\n")
+
+	return s.sender.Send(user.Email, "Your weekly Sudoku digest", body.String())
+}
+
+func rankChange(previous, current int) string {
+	switch {
+	case current < previous:
+		return fmt.Sprintf("up %d", previous-current)
+	case current > previous:
+		return fmt.Sprintf("down %d", current-previous)
+	}
+	return "unchanged"
+}
@@ -0,0 +1,81 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+)
+
+// Service applies event bonuses to results and hands out event badges
+type Service struct {
+	db          *gorm.DB
+	pushService *push.Service
+}
+
+func NewService(db *gorm.DB, pushService *push.Service) *Service {
+	return &Service{db: db, pushService: pushService}
+}
+
+// ApplyBonus multiplies the score of a completed event game by the event's bonus.
+// Games finished after the event closed keep their base score.
+func (s *Service) ApplyBonus(gameResult *models.GameResult) error {
+	if gameResult.EventID == nil || gameResult.CompletedAt == nil || gameResult.Score == 0 {
+		return nil
+	}
+	var event models.Event
+	if err := s.db.First(&event, *gameResult.EventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if event.Active(*gameResult.CompletedAt) && event.BonusMultiplier > 0 {
+		gameResult.Score = int(math.Round(float64(gameResult.Score) * event.BonusMultiplier))
+	}
+	return nil
+}
+
+// AwardBadge gives the user the event's badge once they have solved enough distinct event puzzles
+// inside its window. It returns the badge when this call awarded it, nil otherwise.
+func (s *Service) AwardBadge(userID, eventID uint) (*models.EventBadge, error) {
+	var event models.Event
+	if err := s.db.First(&event, eventID).Error; err != nil {
+		return nil, err
+	}
+	if event.BadgeName == "" {
+		return nil, nil
+	}
+
+	var solved int64
+	err := s.db.Model(&models.GameResult{}).
+		Where("user_id = ? AND event_id = ? AND completed = ?", userID, eventID, true).
+		Where("disqualified = ? AND voided = ?", false, false).
+		Where("completed_at >= ? AND completed_at < ?", event.StartsAt, event.EndsAt).
+		Distinct("puzzle_id").
+		Count(&solved).Error
+	if err != nil {
+		return nil, err
+	}
+	if solved < int64(max(event.BadgeThreshold, 1)) {
+		return nil, nil
+	}
+
+	badge := models.EventBadge{UserID: userID, EventID: eventID, Name: event.BadgeName}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&badge)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	body := fmt.Sprintf("You earned the %s badge in %s.", event.BadgeName, event.Name)
+	if err := s.pushService.Enqueue(userID, "Badge earned", body); err != nil {
+		log.Printf("Failed to notify user %d about badge %d: %v", userID, badge.ID, err)
+	}
+	return &badge, nil
+}
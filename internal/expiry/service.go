@@ -0,0 +1,91 @@
+package expiry
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+)
+
+// Policy decides when an in-progress game is abandoned for inactivity
+type Policy struct {
+	After      time.Duration // Inactivity before the game expires, 0 disables expiry
+	WarnBefore time.Duration // How long before expiring the player is warned
+}
+
+// DefaultPolicy abandons games a week after they were last played, warning a day ahead
+var DefaultPolicy = Policy{After: 7 * 24 * time.Hour, WarnBefore: 24 * time.Hour}
+
+// Service warns players about idle games and expires them
+type Service struct {
+	db          *gorm.DB
+	pushService *push.Service
+	policy      Policy
+}
+
+func NewService(db *gorm.DB, pushService *push.Service, policy Policy) *Service {
+	return &Service{db: db, pushService: pushService, policy: policy}
+}
+
+// Policy returns the expiry policy the service enforces
+func (s *Service) Policy() Policy {
+	return s.policy
+}
+
+// ExpireDue warns players whose games are about to expire and expires the games whose warning
+// has run out. It is run periodically by the expiry worker. Any play on a game, including a
+// heartbeat, touches its updated_at and so restarts the clock; a game is only expired after
+// the player was warned following their last activity and had WarnBefore to respond.
+func (s *Service) ExpireDue() error {
+	if s.policy.After <= 0 {
+		return nil
+	}
+	now := time.Now()
+
+	var idle []models.GameResult
+	err := s.inProgress().Preload("Puzzle", models.WithDeleted).
+		Where("updated_at < ? AND (warned_at IS NULL OR warned_at < updated_at)", now.Add(s.policy.WarnBefore-s.policy.After)).
+		Find(&idle).Error
+	if err != nil {
+		return err
+	}
+
+	for _, gameResult := range idle {
+		body := fmt.Sprintf("Your %s puzzle will be abandoned in %s unless you play it.", gameResult.Puzzle.Difficulty, formatDuration(s.policy.WarnBefore))
+		if err := s.pushService.Enqueue(gameResult.UserID, "Your game is about to expire", body); err != nil {
+			log.Printf("Failed to warn user %d about game %d expiring: %v", gameResult.UserID, gameResult.ID, err)
+			continue
+		}
+		// UpdateColumn leaves updated_at alone, which would otherwise count as activity
+		if err := s.db.Model(&gameResult).UpdateColumn("warned_at", now).Error; err != nil {
+			return err
+		}
+	}
+
+	return s.inProgress().
+		Where("updated_at < ? AND warned_at >= updated_at AND warned_at <= ?", now.Add(-s.policy.After), now.Add(-s.policy.WarnBefore)).
+		Updates(map[string]interface{}{"expired": true}).Error
+}
+
+// Games still waiting to be submitted, skipped or expired
+func (s *Service) inProgress() *gorm.DB {
+	return s.db.Model(&models.GameResult{}).Where("completed_at IS NULL AND skipped = ? AND expired = ?", false, false)
+}
+
+// Format a warning period in whole days or hours
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	case d >= time.Hour:
+		return "an hour"
+	default:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	}
+}
@@ -0,0 +1,205 @@
+package goals
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+	"sudoku/internal/stats"
+)
+
+// MaxGoals is the number of goals a user may have at once
+const MaxGoals = 20
+
+// ErrTooManyGoals is returned by Create when the user already has MaxGoals goals
+var ErrTooManyGoals = fmt.Errorf("a user may have at most %d goals", MaxGoals)
+
+// Progress is a goal with how far the user has come in its current period
+type Progress struct {
+	models.Goal
+	Current     int        `json:"current"`                // Puzzles solved, or best time in seconds for a time goal
+	Met         bool       `json:"met"`                    // Met in the current period, or ever for a time goal
+	PeriodStart *time.Time `json:"period_start,omitempty"` // Start of a count goal's current period
+}
+
+// Service tracks personal goals against the user's scored games and notifies them when one is met
+type Service struct {
+	db           *gorm.DB
+	statsService *stats.Service
+	pushService  *push.Service
+}
+
+func NewService(db *gorm.DB, statsService *stats.Service, pushService *push.Service) *Service {
+	return &Service{db: db, statsService: statsService, pushService: pushService}
+}
+
+// Validate checks a new goal, defaulting count goals to a weekly period
+func Validate(goal *models.Goal) error {
+	switch goal.Difficulty {
+	case "", models.Easy, models.Medium, models.Hard, models.Expert:
+	default:
+		return errors.New("invalid difficulty level")
+	}
+
+	switch goal.Kind {
+	case models.TimeGoal:
+		if goal.Difficulty == "" {
+			return errors.New("time goals need a difficulty")
+		}
+		if goal.Period != "" {
+			return errors.New("time goals have no period")
+		}
+		if goal.Target < 1 || goal.Target > 24*60*60 {
+			return errors.New("target must be between 1 second and 24 hours")
+		}
+	case models.CountGoal:
+		if goal.Period == "" {
+			goal.Period = models.WeeklyGoal
+		}
+		if goal.Period != models.DailyGoal && goal.Period != models.WeeklyGoal {
+			return errors.New("period must be 'daily' or 'weekly'")
+		}
+		if goal.Target < 1 || goal.Target > 1000 {
+			return errors.New("target must be between 1 and 1000 puzzles")
+		}
+	default:
+		return errors.New("kind must be 'time' or 'count'")
+	}
+	return nil
+}
+
+// Create saves a goal checked by Validate for goal.UserID
+func (s *Service) Create(goal *models.Goal) error {
+	var count int64
+	if err := s.db.Model(&models.Goal{}).Where("user_id = ?", goal.UserID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= MaxGoals {
+		return ErrTooManyGoals
+	}
+	return s.db.Create(goal).Error
+}
+
+// Delete removes one of the user's goals. Its completions stay in the user's stats.
+func (s *Service) Delete(userID, goalID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", goalID, userID).Delete(&models.Goal{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// List returns the user's goals with their progress at now. Days and weeks follow loc.
+func (s *Service) List(userID uint, now time.Time, loc *time.Location) ([]Progress, error) {
+	var goals []models.Goal
+	if err := s.db.Where("user_id = ?", userID).Order("created_at").Find(&goals).Error; err != nil {
+		return nil, err
+	}
+
+	progress := make([]Progress, 0, len(goals))
+	for _, goal := range goals {
+		p, err := s.progress(goal, now, loc)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// Check records the user's goals met as of now, after a scored game. The goals met for the first
+// time in their period are returned and the user is notified about them.
+func (s *Service) Check(userID uint, now time.Time, loc *time.Location) ([]models.Goal, error) {
+	var goals []models.Goal
+	if err := s.db.Where("user_id = ?", userID).Find(&goals).Error; err != nil {
+		return nil, err
+	}
+
+	var met []models.Goal
+	for _, goal := range goals {
+		p, err := s.progress(goal, now, loc)
+		if err != nil {
+			return nil, err
+		}
+		if !p.Met {
+			continue
+		}
+
+		completion := models.GoalCompletion{GoalID: goal.ID, UserID: userID, PeriodStart: goal.CreatedAt}
+		if p.PeriodStart != nil {
+			completion.PeriodStart = *p.PeriodStart
+		}
+		result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&completion)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue // Already met in this period
+		}
+		met = append(met, goal)
+
+		if err := s.pushService.Enqueue(userID, "Goal met", "You met your goal: "+Describe(goal)+"."); err != nil {
+			log.Printf("Failed to notify user %d about goal %d: %v", userID, goal.ID, err)
+		}
+	}
+	return met, nil
+}
+
+func (s *Service) progress(goal models.Goal, now time.Time, loc *time.Location) (Progress, error) {
+	p := Progress{Goal: goal}
+	periodStart := goal.CreatedAt
+	if goal.Kind == models.CountGoal {
+		periodStart = leaderboard.PeriodStart(snapshotPeriod(goal.Period), now.In(loc))
+		p.PeriodStart = &periodStart
+	}
+
+	var err error
+	p.Current, p.Met, err = s.statsService.GoalProgress(goal, periodStart)
+	return p, err
+}
+
+// Count goal periods start on the same boundaries as the leaderboard archive
+func snapshotPeriod(period models.GoalPeriod) models.SnapshotPeriod {
+	if period == models.DailyGoal {
+		return models.DailySnapshot
+	}
+	return models.WeeklySnapshot
+}
+
+// Describe puts a goal into words: "solve a hard puzzle within 10:00", "solve 5 puzzles this week"
+func Describe(goal models.Goal) string {
+	difficulty := ""
+	if goal.Difficulty != "" {
+		difficulty = string(goal.Difficulty) + " "
+	}
+	if goal.Kind == models.TimeGoal {
+		return fmt.Sprintf("solve %s %spuzzle within %d:%02d", article(difficulty), difficulty, goal.Target/60, goal.Target%60)
+	}
+
+	noun := "puzzles"
+	if goal.Target == 1 {
+		noun = "puzzle"
+	}
+	period := "this week"
+	if goal.Period == models.DailyGoal {
+		period = "today"
+	}
+	return fmt.Sprintf("solve %d %s%s %s", goal.Target, difficulty, noun, period)
+}
+
+func article(word string) string {
+	if word != "" && (word[0] == 'e' || word[0] == 'a') {
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/stats"
+	"sudoku/internal/sudoku"
+)
+
+type AdminHandler struct {
+	db            *gorm.DB
+	sudokuService *sudoku.Service
+	statsService  *stats.Service
+}
+
+func NewAdminHandler(db *gorm.DB, sudokuService *sudoku.Service, statsService *stats.Service) *AdminHandler {
+	return &AdminHandler{
+		db:            db,
+		sudokuService: sudokuService,
+		statsService:  statsService,
+	}
+}
+
+func (h *AdminHandler) GetGenerationProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.sudokuService.ListGenerationProfiles()
+	if err != nil {
+		http.Error(w, "Failed to fetch generation profiles", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"profiles":   profiles,
+		"techniques": sudoku.TechniqueNames(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AdminHandler) UpdateGenerationProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.GenerationProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	profile.ID = 0
+	profile.Difficulty = models.Difficulty(chi.URLParam(r, "difficulty"))
+	if profile.Symmetry == "" {
+		profile.Symmetry = models.NoSymmetry
+	}
+
+	if err := sudoku.ValidateGenerationProfile(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Insert or replace the stored profile for this difficulty
+	err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "difficulty"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min_clues", "max_clues", "symmetry", "max_technique", "updated_at"}),
+	}).Create(&profile).Error
+	if err != nil {
+		http.Error(w, "Failed to save generation profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+type ResolveReviewRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *AdminHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = string(models.ReviewPending)
+	}
+
+	var reviews []models.ResultReview
+	if err := h.db.Preload("GameResult.User").Preload("GameResult.Puzzle", models.WithDeleted).Where("status = ?", status).Order("created_at ASC").Find(&reviews).Error; err != nil {
+		http.Error(w, "Failed to fetch reviews", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviews)
+}
+
+func (h *AdminHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid review id", http.StatusBadRequest)
+		return
+	}
+
+	var review models.ResultReview
+	if err := h.db.Preload("GameResult.User").Preload("GameResult.Puzzle", models.WithDeleted).First(&review, reviewID).Error; err != nil {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+
+	game := review.GameResult
+	diff := sudoku.DiffBoards(
+		sudoku.StringToBoard(game.Puzzle.StartingGrid),
+		sudoku.StringToBoard(game.FinalGrid),
+		sudoku.StringToBoard(game.Puzzle.Solution),
+		true,
+	)
+
+	response := map[string]interface{}{
+		"review": review,
+		"diff":   diff,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AdminHandler) ClearReview(w http.ResponseWriter, r *http.Request) {
+	h.resolveReview(w, r, models.ReviewCleared)
+}
+
+func (h *AdminHandler) VoidReview(w http.ResponseWriter, r *http.Request) {
+	h.resolveReview(w, r, models.ReviewVoided)
+}
+
+// Close a pending review, void the game result if requested and recompute the owner's totals
+func (h *AdminHandler) resolveReview(w http.ResponseWriter, r *http.Request, status models.ReviewStatus) {
+	adminID := r.Context().Value(auth.UserIDKey).(uint)
+
+	reviewID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid review id", http.StatusBadRequest)
+		return
+	}
+
+	var req ResolveReviewRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var review models.ResultReview
+	if err := h.db.Preload("GameResult").First(&review, reviewID).Error; err != nil {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if review.Status != models.ReviewPending {
+		http.Error(w, "Review is already resolved", http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		review.Status = status
+		review.ReviewerID = &adminID
+		review.ReviewNote = req.Note
+		review.ReviewedAt = &now
+		if err := tx.Omit("GameResult").Save(&review).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"under_review": false}
+		if status == models.ReviewVoided {
+			updates["voided"] = true
+		}
+		if err := tx.Model(&models.GameResult{}).Where("id = ?", review.GameResultID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return stats.RecomputeUser(tx, review.GameResult.UserID)
+	})
+	if err != nil {
+		http.Error(w, "Failed to resolve review", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+func (h *AdminHandler) RecomputeTotals(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+	users, err := h.statsService.RecomputeAll()
+	if err != nil {
+		http.Error(w, "Failed to recompute user totals", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Recomputed totals for %d users in %s", users, time.Since(started))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "User totals recomputed",
+		"users":   users,
+	})
+}
+
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	h.softDelete(w, r, &models.User{}, nil)
+}
+
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, &models.User{}, nil)
+}
+
+func (h *AdminHandler) DeletePuzzle(w http.ResponseWriter, r *http.Request) {
+	h.softDelete(w, r, &models.Puzzle{}, nil)
+}
+
+func (h *AdminHandler) RestorePuzzle(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, &models.Puzzle{}, nil)
+}
+
+func (h *AdminHandler) DeleteGame(w http.ResponseWriter, r *http.Request) {
+	h.softDelete(w, r, &models.GameResult{}, recomputeGameOwner)
+}
+
+func (h *AdminHandler) RestoreGame(w http.ResponseWriter, r *http.Request) {
+	h.restore(w, r, &models.GameResult{}, recomputeGameOwner)
+}
+
+// Keep the owner's totals in line after one of their games is deleted or restored
+func recomputeGameOwner(tx *gorm.DB, id uint) error {
+	var gameResult models.GameResult
+	if err := tx.Unscoped().First(&gameResult, id).Error; err != nil {
+		return err
+	}
+	return stats.RecomputeUser(tx, gameResult.UserID)
+}
+
+// Soft delete the row with the id from the URL, running after in the same transaction
+func (h *AdminHandler) softDelete(w http.ResponseWriter, r *http.Request, model interface{}, after func(tx *gorm.DB, id uint) error) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(model, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if after != nil {
+			return after(tx, id)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Deleted", "id": id})
+}
+
+// Restore a soft-deleted row with the id from the URL, running after in the same transaction
+func (h *AdminHandler) restore(w http.ResponseWriter, r *http.Request, model interface{}, after func(tx *gorm.DB, id uint) error) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(model).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if after != nil {
+			return after(tx, id)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "No deleted row with this id", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to restore", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Restored", "id": id})
+}
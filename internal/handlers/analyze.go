@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+const (
+	// Maximum number of solutions counted by the analyze endpoints
+	maxSolutionCount = 1000
+	// Default and maximum number of solved boards tried for a clue pattern
+	defaultPatternAttempts = 100
+	maxPatternAttempts     = 500
+)
+
+type AnalyzeHandler struct {
+	sudokuService *sudoku.Service
+}
+
+type AnalyzeRequest struct {
+	Grid string `json:"grid"`
+	Size int    `json:"size,omitempty"` // 4, 6, 9 (default) or 16; only /analyze/count reads it
+}
+
+type GenerateSizedRequest struct {
+	Size       int               `json:"size"`
+	Difficulty models.Difficulty `json:"difficulty"`
+	Symmetry   models.Symmetry   `json:"symmetry,omitempty"`
+	Seed       *int64            `json:"seed,omitempty"`
+}
+
+type PatternRequest struct {
+	Mask     string `json:"mask"` // 81 characters, 'x' for clue cells and '.' for empty cells
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+func NewAnalyzeHandler(sudokuService *sudoku.Service) *AnalyzeHandler {
+	return &AnalyzeHandler{sudokuService: sudokuService}
+}
+
+func (h *AnalyzeHandler) CountSolutions(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var count int
+	if req.Size != 0 && req.Size != 9 {
+		shape, err := sudoku.ShapeOf(req.Size)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		grid, err := sudoku.StringToGrid(req.Grid, shape)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if count, err = h.sudokuService.CountGridSolutions(r.Context(), grid, maxSolutionCount); err != nil {
+			writeSolverError(w, err)
+			return
+		}
+	} else {
+		board, err := sudoku.ParseBoard(req.Grid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if count, err = h.sudokuService.CountSolutions(r.Context(), board, maxSolutionCount); err != nil {
+			writeSolverError(w, err)
+			return
+		}
+	}
+
+	response := map[string]interface{}{
+		"solutions": count,
+		"capped":    count >= maxSolutionCount, // The real count may be higher
+		"unique":    count == 1,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *AnalyzeHandler) GenerateFromPattern(w http.ResponseWriter, r *http.Request) {
+	var req PatternRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	mask, err := sudoku.ParseMask(req.Mask)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attempts := req.Attempts
+	if attempts <= 0 {
+		attempts = defaultPatternAttempts
+	}
+	if attempts > maxPatternAttempts {
+		attempts = maxPatternAttempts
+	}
+
+	puzzle, solution, err := h.sudokuService.GenerateFromMask(r.Context(), mask, attempts)
+	if errors.Is(err, sudoku.ErrPatternNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"starting_grid": sudoku.BoardToString(puzzle),
+		"solution":      sudoku.BoardToString(solution),
+		"clues":         mask.Clues(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GenerateSized generates a puzzle of another size than 9x9: 4x4 kids puzzles, 6x6 minis and
+// 16x16 hex sudoku, whose digits past 9 are written A-G
+func (h *AnalyzeHandler) GenerateSized(w http.ResponseWriter, r *http.Request) {
+	var req GenerateSizedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	shape, err := sudoku.ShapeOf(req.Size)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch req.Difficulty {
+	case models.Easy, models.Medium, models.Hard, models.Expert:
+	default:
+		http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
+		return
+	}
+	switch req.Symmetry {
+	case "", models.NoSymmetry, models.RotationalSymmetry, models.MirrorSymmetry:
+	default:
+		http.Error(w, "Invalid symmetry", http.StatusBadRequest)
+		return
+	}
+
+	puzzle, solution, err := h.sudokuService.GenerateGrid(r.Context(), shape, req.Difficulty, sudoku.GenerateOptions{Symmetry: req.Symmetry, Seed: req.Seed})
+	if err != nil && r.Context().Err() != nil {
+		writeSolverError(w, err)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"shape":         shape,
+		"starting_grid": sudoku.GridToString(puzzle),
+		"solution":      sudoku.GridToString(solution),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SolveSamurai solves a Samurai board written as sudoku.ParseSamurai reads it, reporting
+// whether the solution is unique
+func (h *AnalyzeHandler) SolveSamurai(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	board, err := sudoku.ParseSamurai(req.Grid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	solution, err := h.sudokuService.SolveSamurai(r.Context(), board)
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+	count, err := h.sudokuService.CountSamuraiSolutions(r.Context(), board, 2)
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"solution": sudoku.SamuraiToString(solution),
+		"unique":   count == 1,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ValidatePuzzle checks a grid typed in by hand, e.g. from a newspaper: whether it can be solved,
+// whether the solution is unique, how many clues it has and how hard it is. Separators and '.'
+// for empty cells are accepted.
+func (h *AnalyzeHandler) ValidatePuzzle(w http.ResponseWriter, r *http.Request) {
+	var req AnalyzeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	grid, ok := sudoku.NormalizeGrid(req.Grid)
+	if !ok {
+		http.Error(w, "grid must have 81 cells of digits, with 0 or '.' for empty cells", http.StatusBadRequest)
+		return
+	}
+	board := sudoku.StringToBoard(grid)
+
+	clues := 0
+	for _, c := range grid {
+		if c != '0' {
+			clues++
+		}
+	}
+
+	response := map[string]interface{}{
+		"grid":     grid,
+		"clues":    clues,
+		"solvable": false,
+		"unique":   false,
+	}
+
+	// Conflicting clues are reported without searching
+	consistent := h.sudokuService.IsConsistent(board)
+	response["consistent"] = consistent
+	if consistent {
+		solutions, err := h.sudokuService.Solutions(r.Context(), board, 2)
+		if err != nil {
+			writeSolverError(w, err)
+			return
+		}
+		count := len(solutions)
+		response["solvable"] = count > 0
+		response["unique"] = count == 1
+
+		// Show two solutions and where they differ, so it's clear why the puzzle isn't unique
+		if count == 2 {
+			response["ambiguity"] = sudoku.DiffSolutions(solutions[0], solutions[1])
+		}
+
+		// Ratings lean on uniqueness, so only puzzles with one solution get one
+		if count == 1 {
+			rating := h.sudokuService.RatePuzzle(board)
+			response["difficulty"] = rating.Difficulty
+			response["hardest_technique"] = rating.HardestTechnique
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
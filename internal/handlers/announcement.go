@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+)
+
+type AnnouncementHandler struct {
+	db          *gorm.DB
+	pushService *push.Service
+}
+
+type AnnouncementRequest struct {
+	Kind     models.AnnouncementKind `json:"kind"`
+	Title    string                  `json:"title"`
+	Body     string                  `json:"body"`
+	StartsAt *time.Time              `json:"starts_at"` // Defaults to now
+	EndsAt   *time.Time              `json:"ends_at"`
+	Push     bool                    `json:"push"` // Also send a push notification to every registered device
+}
+
+func NewAnnouncementHandler(db *gorm.DB, pushService *push.Service) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		db:          db,
+		pushService: pushService,
+	}
+}
+
+// GetAnnouncements returns the active announcements the user hasn't dismissed
+func (h *AnnouncementHandler) GetAnnouncements(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	now := time.Now()
+	announcements := []models.Announcement{}
+	err := h.db.Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Where("id NOT IN (?)", h.db.Model(&models.AnnouncementDismissal{}).Select("announcement_id").Where("user_id = ?", userID)).
+		Order("starts_at DESC").
+		Find(&announcements).Error
+	if err != nil {
+		http.Error(w, "Failed to fetch announcements", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(announcements)
+}
+
+func (h *AnnouncementHandler) DismissAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var announcement models.Announcement
+	if err := h.db.First(&announcement, id).Error; err != nil {
+		http.Error(w, "Announcement not found", http.StatusNotFound)
+		return
+	}
+
+	// Dismissing twice is a no-op
+	err := h.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.AnnouncementDismissal{AnnouncementID: id, UserID: userID}).Error
+	if err != nil {
+		http.Error(w, "Failed to dismiss announcement", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Dismissed", "id": id})
+}
+
+func (h *AnnouncementHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	switch req.Kind {
+	case models.MaintenanceAnnouncement, models.FeatureAnnouncement, models.TournamentAnnouncement:
+	default:
+		http.Error(w, "Invalid kind. Use 'maintenance', 'feature' or 'tournament'", http.StatusBadRequest)
+		return
+	}
+	if req.Title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	}
+
+	announcement := models.Announcement{
+		Kind:      req.Kind,
+		Title:     req.Title,
+		Body:      req.Body,
+		StartsAt:  time.Now(),
+		EndsAt:    req.EndsAt,
+		CreatedBy: userID,
+	}
+	if req.StartsAt != nil {
+		announcement.StartsAt = *req.StartsAt
+	}
+	if announcement.EndsAt != nil && !announcement.EndsAt.After(announcement.StartsAt) {
+		http.Error(w, "ends_at must be after starts_at", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.Create(&announcement).Error; err != nil {
+		http.Error(w, "Failed to save announcement", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Push {
+		// Queue one notification per user with a device; the delivery worker sends them
+		var userIDs []uint
+		h.db.Model(&models.PushDevice{}).Distinct("user_id").Pluck("user_id", &userIDs)
+		for _, id := range userIDs {
+			if err := h.pushService.Enqueue(id, announcement.Title, announcement.Body); err != nil {
+				log.Printf("Failed to queue announcement %d for user %d: %v", announcement.ID, id, err)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(announcement)
+}
+
+func (h *AnnouncementHandler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Announcement{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("announcement_id = ?", id).Delete(&models.AnnouncementDismissal{}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete announcement", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Deleted", "id": id})
+}
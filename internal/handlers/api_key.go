@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/quota"
+)
+
+type APIKeyHandler struct {
+	db           *gorm.DB
+	quotaService *quota.Service
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+type UpdateQuotaRequest struct {
+	DailySolveLimit   *int `json:"daily_solve_limit"`
+	DailyAnalyzeLimit *int `json:"daily_analyze_limit"`
+}
+
+func NewAPIKeyHandler(db *gorm.DB, quotaService *quota.Service) *APIKeyHandler {
+	return &APIKeyHandler{
+		db:           db,
+		quotaService: quotaService,
+	}
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	key, raw, err := h.quotaService.CreateKey(userID, req.Name)
+	if err != nil {
+		http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"api_key": key,
+		"key":     raw, // Only shown once
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIKeyHandler) GetKeys(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var keys []models.APIKey
+	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		http.Error(w, "Failed to fetch API keys", http.StatusInternalServerError)
+		return
+	}
+
+	response := []map[string]interface{}{}
+	for _, key := range keys {
+		usage, err := h.quotaService.UsageToday(key.ID)
+		if err != nil {
+			http.Error(w, "Failed to fetch API usage", http.StatusInternalServerError)
+			return
+		}
+		response = append(response, map[string]interface{}{
+			"api_key":     key,
+			"usage_today": usage,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *APIKeyHandler) UpdateQuota(w http.ResponseWriter, r *http.Request) {
+	keyID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid API key id", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var key models.APIKey
+	if err := h.db.First(&key, keyID).Error; err != nil {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+
+	if req.DailySolveLimit != nil {
+		key.DailySolveLimit = *req.DailySolveLimit
+	}
+	if req.DailyAnalyzeLimit != nil {
+		key.DailyAnalyzeLimit = *req.DailyAnalyzeLimit
+	}
+	if key.DailySolveLimit < 0 || key.DailyAnalyzeLimit < 0 {
+		http.Error(w, "Limits cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.Save(&key).Error; err != nil {
+		http.Error(w, "Failed to update quota", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
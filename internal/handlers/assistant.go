@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+type AssistantRequest struct {
+	CurrentGrid string `json:"current_grid"`
+}
+
+// Load a Learn mode game of the user for the assistant, writing the error response if not allowed
+func (h *GameHandler) loadAssistedGame(w http.ResponseWriter, r *http.Request, userID uint) (*models.GameResult, bool) {
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	if gameResult.Mode != models.LearnMode {
+		http.Error(w, "The assistant is only available in Learn mode", http.StatusForbidden)
+		return nil, false
+	}
+	return &gameResult, true
+}
+
+// AskAssistant answers "what should I look at?". Each call on the same grid reveals one
+// more stage: unit, candidates, technique, then the cell. A changed grid starts a new session.
+func (h *GameHandler) AskAssistant(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req AssistantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gameResult, ok := h.loadAssistedGame(w, r, userID)
+	if !ok {
+		return
+	}
+
+	move, err := h.sudokuService.LogicalStep(board)
+	if errors.Is(err, sudoku.ErrNoLogicalMove) {
+		writeNoLogicalMove(w)
+		return
+	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	var session models.AssistantSession
+	err = h.db.Where("game_result_id = ?", gameResult.ID).Order("created_at DESC").First(&session).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Failed to load assistant session", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || session.Grid != req.CurrentGrid {
+		session = models.AssistantSession{
+			GameResultID: gameResult.ID,
+			UserID:       userID,
+			Grid:         req.CurrentGrid,
+			TargetRow:    move.Row,
+			TargetCol:    move.Col,
+			TargetValue:  move.Value,
+			Technique:    move.Reason,
+		}
+	}
+	if session.Stage < sudoku.StageCell {
+		session.Stage++
+	}
+
+	prompt := h.sudokuService.AssistantPrompt(board, move, session.Stage)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AssistantPrompt{SessionID: session.ID, Stage: prompt.Stage, Text: prompt.Text}).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to save assistant session", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"session_id": session.ID,
+		"prompt":     prompt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAssistantSessions lists the game's assistant sessions with every prompt, for review
+func (h *GameHandler) GetAssistantSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameResult, ok := h.loadAssistedGame(w, r, userID)
+	if !ok {
+		return
+	}
+
+	sessions := []models.AssistantSession{}
+	err := h.db.Preload("Prompts", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("game_result_id = ?", gameResult.ID).
+		Order("created_at ASC").
+		Find(&sessions).Error
+	if err != nil {
+		http.Error(w, "Failed to fetch assistant sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
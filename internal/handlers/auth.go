@@ -2,19 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
 	"sudoku/internal/auth"
+	"sudoku/internal/locale"
+	"sudoku/internal/models"
+	"sudoku/internal/moderation"
 )
 
 type AuthHandler struct {
-	authService *auth.Service
+	authService       *auth.Service
+	moderationService *moderation.Service
 }
 
 type RegisterRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Region   string `json:"region,omitempty"` // Taken from the request's region header when empty
 }
 
 type LoginRequest struct {
@@ -22,13 +29,22 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type UpdateProfileRequest struct {
+	Timezone    *string `json:"timezone,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
+	DigestOptIn *bool   `json:"digest_opt_in,omitempty"`
+}
+
 type AuthResponse struct {
 	User  interface{} `json:"user"`
 	Token string      `json:"token"`
 }
 
-func NewAuthHandler(authService *auth.Service) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService *auth.Service, moderationService *moderation.Service) *AuthHandler {
+	return &AuthHandler{
+		authService:       authService,
+		moderationService: moderationService,
+	}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -38,7 +54,24 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authService.Register(req.Username, req.Email, req.Password)
+	if err := h.moderationService.ValidateUsername(req.Username); err != nil {
+		h.writeUsernameRejected(w, err, http.StatusBadRequest)
+		return
+	}
+
+	region := models.Region(req.Region)
+	if region == "" {
+		region = requestRegion(r)
+	} else if !models.ValidRegion(region) {
+		http.Error(w, "Invalid region", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authService.Register(req.Username, req.Email, req.Password, region)
+	if errors.Is(err, auth.ErrUsernameTaken) {
+		h.writeUsernameRejected(w, err, http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -60,6 +93,22 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// Explain why a username can't be used and offer available alternatives
+func (h *AuthHandler) writeUsernameRejected(w http.ResponseWriter, err error, status int) {
+	suggestions, suggestErr := h.moderationService.SuggestUsernames()
+	if suggestErr != nil {
+		log.Printf("Failed to suggest usernames: %v", suggestErr)
+		suggestions = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       err.Error(),
+		"suggestions": suggestions,
+	})
+}
+
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -72,6 +121,10 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	// Accounts made before regions were recorded get the region they first log in from
+	if err := h.authService.SetRegion(user, requestRegion(r)); err != nil {
+		log.Printf("Failed to set region of user %d: %v", user.ID, err)
+	}
 
 	response := AuthResponse{
 		User:  user,
@@ -96,8 +149,46 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
-	// This would handle profile updates
-	// For now, just return success
+	var req UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	timezone, tag := user.Timezone, user.Locale
+	if req.Timezone != nil {
+		if *req.Timezone != "" && !locale.ValidTimezone(*req.Timezone) {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+		timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		if *req.Locale != "" && !locale.ValidLocale(*req.Locale) {
+			http.Error(w, "Invalid locale", http.StatusBadRequest)
+			return
+		}
+		tag = *req.Locale
+	}
+	digestOptIn := user.DigestOptIn
+	if req.DigestOptIn != nil {
+		digestOptIn = *req.DigestOptIn
+	}
+
+	user, err = h.authService.UpdatePreferences(userID, timezone, tag, digestOptIn)
+	if err != nil {
+		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Profile updated successfully"})
+	json.NewEncoder(w).Encode(user)
 }
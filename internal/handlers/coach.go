@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/moderation"
+	"sudoku/internal/sudoku"
+)
+
+type CoachHandler struct {
+	db                *gorm.DB
+	moderationService *moderation.Service
+}
+
+type GrantCoachRequest struct {
+	CoachUsername string `json:"coach_username"`
+}
+
+type AnnotationRequest struct {
+	Row  *int   `json:"row,omitempty"`
+	Col  *int   `json:"col,omitempty"`
+	Note string `json:"note"`
+}
+
+type RecommendationRequest struct {
+	Technique string `json:"technique"`
+	Message   string `json:"message"`
+}
+
+func NewCoachHandler(db *gorm.DB, moderationService *moderation.Service) *CoachHandler {
+	return &CoachHandler{
+		db:                db,
+		moderationService: moderationService,
+	}
+}
+
+// Check whether the coach has been granted access to the player's games
+func hasCoachAccess(db *gorm.DB, coachID, playerID uint) bool {
+	var count int64
+	db.Model(&models.CoachGrant{}).Where("coach_id = ? AND player_id = ?", coachID, playerID).Count(&count)
+	return count > 0
+}
+
+// Parse a numeric URL parameter
+func urlParamID(r *http.Request, name string) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *CoachHandler) GrantCoach(w http.ResponseWriter, r *http.Request) {
+	var req GrantCoachRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var coach models.User
+	if err := h.db.Where("username = ?", req.CoachUsername).First(&coach).Error; err != nil {
+		http.Error(w, "Coach not found", http.StatusNotFound)
+		return
+	}
+	if coach.ID == userID {
+		http.Error(w, "You cannot coach yourself", http.StatusBadRequest)
+		return
+	}
+
+	grant := models.CoachGrant{PlayerID: userID, CoachID: coach.ID}
+	if err := h.db.Where(&grant).FirstOrCreate(&grant).Error; err != nil {
+		http.Error(w, "Failed to grant coach access", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grant)
+}
+
+func (h *CoachHandler) RevokeCoach(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	coachID, ok := urlParamID(r, "coachID")
+	if !ok {
+		http.Error(w, "Invalid coach id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.Where("player_id = ? AND coach_id = ?", userID, coachID).Delete(&models.CoachGrant{}).Error; err != nil {
+		http.Error(w, "Failed to revoke coach access", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Coach access revoked"})
+}
+
+func (h *CoachHandler) GetMyCoaches(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var grants []models.CoachGrant
+	if err := h.db.Preload("Coach").Where("player_id = ?", userID).Find(&grants).Error; err != nil {
+		http.Error(w, "Failed to fetch coaches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grants)
+}
+
+func (h *CoachHandler) GetMyPlayers(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var grants []models.CoachGrant
+	if err := h.db.Preload("Player").Where("coach_id = ?", userID).Find(&grants).Error; err != nil {
+		http.Error(w, "Failed to fetch players", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grants)
+}
+
+func (h *CoachHandler) GetPlayerGames(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	playerID, ok := urlParamID(r, "playerID")
+	if !ok {
+		http.Error(w, "Invalid player id", http.StatusBadRequest)
+		return
+	}
+	if !hasCoachAccess(h.db, userID, playerID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var gameResults []models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).Where("user_id = ?", playerID).Order("created_at DESC").Limit(50).Find(&gameResults).Error; err != nil {
+		http.Error(w, "Failed to fetch game history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(gameResults)
+}
+
+func (h *CoachHandler) ReviewGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameResult, ok := h.loadCoachedGame(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var annotations []models.GameAnnotation
+	h.db.Preload("Coach").Where("game_result_id = ?", gameResult.ID).Order("created_at ASC").Find(&annotations)
+
+	diff := sudoku.DiffBoards(
+		sudoku.StringToBoard(gameResult.Puzzle.StartingGrid),
+		sudoku.StringToBoard(gameResult.FinalGrid),
+		sudoku.StringToBoard(gameResult.Puzzle.Solution),
+		true,
+	)
+
+	response := map[string]interface{}{
+		"game":        gameResult,
+		"diff":        diff,
+		"annotations": annotations,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *CoachHandler) AnnotateGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req AnnotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Note == "" {
+		http.Error(w, "Note is required", http.StatusBadRequest)
+		return
+	}
+	if (req.Row != nil && (*req.Row < 0 || *req.Row > 8)) || (req.Col != nil && (*req.Col < 0 || *req.Col > 8)) {
+		http.Error(w, "Row and Col must be between 0 and 8", http.StatusBadRequest)
+		return
+	}
+	if err := h.moderationService.ValidateText(req.Note); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gameResult, ok := h.loadCoachedGame(w, r, userID)
+	if !ok {
+		return
+	}
+
+	annotation := models.GameAnnotation{
+		GameResultID: gameResult.ID,
+		CoachID:      userID,
+		Row:          req.Row,
+		Col:          req.Col,
+		Note:         req.Note,
+	}
+	if err := h.db.Create(&annotation).Error; err != nil {
+		http.Error(w, "Failed to save annotation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotation)
+}
+
+// Annotations left by coaches on one of the user's own games
+func (h *CoachHandler) GetGameAnnotations(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var annotations []models.GameAnnotation
+	if err := h.db.Preload("Coach").Where("game_result_id = ?", gameID).Order("created_at ASC").Find(&annotations).Error; err != nil {
+		http.Error(w, "Failed to fetch annotations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(annotations)
+}
+
+func (h *CoachHandler) RecommendTechnique(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	playerID, ok := urlParamID(r, "playerID")
+	if !ok {
+		http.Error(w, "Invalid player id", http.StatusBadRequest)
+		return
+	}
+	if !hasCoachAccess(h.db, userID, playerID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req RecommendationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Technique == "" {
+		http.Error(w, "Technique is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.moderationService.ValidateText(req.Message); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recommendation := models.TechniqueRecommendation{
+		PlayerID:  playerID,
+		CoachID:   userID,
+		Technique: req.Technique,
+		Message:   req.Message,
+	}
+	if err := h.db.Create(&recommendation).Error; err != nil {
+		http.Error(w, "Failed to save recommendation", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recommendation)
+}
+
+func (h *CoachHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var recommendations []models.TechniqueRecommendation
+	if err := h.db.Preload("Coach").Where("player_id = ?", userID).Order("created_at DESC").Find(&recommendations).Error; err != nil {
+		http.Error(w, "Failed to fetch recommendations", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recommendations)
+}
+
+// Load the game from the URL and check the current user coaches its owner
+func (h *CoachHandler) loadCoachedGame(w http.ResponseWriter, r *http.Request, coachID uint) (*models.GameResult, bool) {
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return nil, false
+	}
+	if !hasCoachAccess(h.db, coachID, gameResult.UserID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return &gameResult, true
+}
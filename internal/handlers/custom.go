@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+type StartCustomGameRequest struct {
+	StartingGrid string `json:"starting_grid"`  // 81 cells, 0 or '.' for empty ones
+	Mode         string `json:"mode,omitempty"` // "play" (default) or "learn"
+}
+
+// StartCustomGame starts a game on a starting grid the user entered themselves. The grid must
+// have exactly one solution. Custom games are practice, even on a grid that is already saved,
+// since the user may well know its solution: they never reach the leaderboards or scored totals.
+func (h *GameHandler) StartCustomGame(w http.ResponseWriter, r *http.Request) {
+	var req StartCustomGameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	mode := models.PlayMode
+	switch req.Mode {
+	case "", "play":
+	case "learn":
+		mode = models.LearnMode
+	default:
+		http.Error(w, "Invalid game mode", http.StatusBadRequest)
+		return
+	}
+
+	grid, ok := sudoku.NormalizeGrid(req.StartingGrid)
+	if !ok {
+		http.Error(w, "starting_grid must have 81 cells of digits, with 0 or '.' for empty cells", http.StatusBadRequest)
+		return
+	}
+	board := sudoku.StringToBoard(grid)
+	if !h.sudokuService.IsConsistent(board) {
+		http.Error(w, "Puzzle has conflicting clues", http.StatusBadRequest)
+		return
+	}
+
+	solutions, err := h.sudokuService.Solutions(r.Context(), board, 2)
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+	if len(solutions) == 0 {
+		http.Error(w, "Puzzle has no solution", http.StatusBadRequest)
+		return
+	}
+	if len(solutions) > 1 {
+		// Show two solutions and where they differ, so it's clear why the puzzle is rejected
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":     "Puzzle must have exactly one solution",
+			"ambiguity": sudoku.DiffSolutions(solutions[0], solutions[1]),
+		})
+		return
+	}
+
+	puzzle, err := h.customPuzzle(board, solutions[0])
+	if err != nil {
+		http.Error(w, "Failed to save puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	gameResult, err := h.openGame(userID, puzzle, mode, gameOptions{Practice: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"puzzle":         puzzle,
+		"started_at":     gameResult.StartedAt,
+		"practice":       gameResult.Practice,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Find the saved puzzle with exactly this starting grid, or save it as a user-submitted puzzle
+// filed under the difficulty its solve is rated at. A grid that repeats a saved puzzle in another
+// disguise keeps its own layout, so the user plays what they typed in; it is saved without a
+// canonical hash and the saved puzzle stays the one served to others.
+func (h *GameHandler) customPuzzle(board, solution sudoku.Board) (*models.Puzzle, error) {
+	var existing models.Puzzle
+	err := h.db.Where("starting_grid = ? AND variant = ?", sudoku.BoardToString(board), models.Classic).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	puzzle := h.newPuzzle("", board, solution)
+	puzzle.Difficulty = puzzle.Rating
+	puzzle.UserSubmitted = true
+	result := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(puzzle)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		puzzle.CanonicalHash = ""
+		if err := h.db.Create(puzzle).Error; err != nil {
+			return nil, err
+		}
+	}
+	return puzzle, nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/blob"
+	"sudoku/internal/dataset"
+	"sudoku/internal/models"
+)
+
+type DatasetHandler struct {
+	db          *gorm.DB
+	blobService *blob.Service
+}
+
+type DatasetExportRequest struct {
+	Fields       []string `json:"fields"`         // Defaults to dataset.DefaultFields
+	MinGroupSize int      `json:"min_group_size"` // Defaults to dataset.DefaultMinGroupSize
+	TimeBucket   int      `json:"time_bucket"`    // Defaults to dataset.DefaultTimeBucket
+}
+
+func NewDatasetHandler(db *gorm.DB, blobService *blob.Service) *DatasetHandler {
+	return &DatasetHandler{
+		db:          db,
+		blobService: blobService,
+	}
+}
+
+// GetDatasets lists the published datasets, newest first
+func (h *DatasetHandler) GetDatasets(w http.ResponseWriter, r *http.Request) {
+	exports := []models.DatasetExport{}
+	err := h.db.Where("status = ?", models.ExportCompleted).Order("completed_at DESC").Find(&exports).Error
+	if err != nil {
+		http.Error(w, "Failed to fetch datasets", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exports)
+}
+
+// DownloadDataset serves the CSV file of a published dataset
+func (h *DatasetHandler) DownloadDataset(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var export models.DatasetExport
+	if err := h.db.Where("status = ?", models.ExportCompleted).First(&export, id).Error; err != nil {
+		http.Error(w, "Dataset not found", http.StatusNotFound)
+		return
+	}
+
+	stored, data, err := h.blobService.Get(export.BlobKey)
+	if errors.Is(err, blob.ErrNotFound) {
+		http.Error(w, "Dataset not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load dataset", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", stored.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"sudoku-solves-%d.csv\"", export.ID))
+	w.Write(data)
+}
+
+// GetExports lists every export with its status, for admins following a build
+func (h *DatasetHandler) GetExports(w http.ResponseWriter, r *http.Request) {
+	exports := []models.DatasetExport{}
+	if err := h.db.Order("created_at DESC").Find(&exports).Error; err != nil {
+		http.Error(w, "Failed to fetch exports", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exports)
+}
+
+// CreateExport queues an anonymized dataset export for the export worker
+func (h *DatasetHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req DatasetExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	fields, err := dataset.ParseFields(req.Fields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.MinGroupSize == 0 {
+		req.MinGroupSize = dataset.DefaultMinGroupSize
+	}
+	if req.MinGroupSize < 2 {
+		http.Error(w, "min_group_size must be at least 2", http.StatusBadRequest)
+		return
+	}
+	if req.TimeBucket == 0 {
+		req.TimeBucket = dataset.DefaultTimeBucket
+	}
+	if req.TimeBucket < 1 {
+		http.Error(w, "time_bucket must be a positive number of seconds", http.StatusBadRequest)
+		return
+	}
+
+	export := models.DatasetExport{
+		Status:        models.ExportPending,
+		Fields:        strings.Join(fields, ","),
+		MinGroupSize:  req.MinGroupSize,
+		TimeBucket:    req.TimeBucket,
+		RequestedByID: adminID,
+	}
+	if err := h.db.Create(&export).Error; err != nil {
+		http.Error(w, "Failed to queue export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(export)
+}
+
+// DeleteExport unpublishes a dataset and removes its file
+func (h *DatasetHandler) DeleteExport(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var export models.DatasetExport
+	if err := h.db.First(&export, id).Error; err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if export.BlobKey != "" {
+		if err := h.blobService.Delete(export.BlobKey); err != nil {
+			http.Error(w, "Failed to delete dataset file", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := h.db.Delete(&export).Error; err != nil {
+		http.Error(w, "Failed to delete export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Deleted", "id": id})
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+)
+
+type DeviceHandler struct {
+	pushService *push.Service
+}
+
+type DeviceRequest struct {
+	Platform models.PushPlatform `json:"platform"`
+	Token    string              `json:"token"`
+}
+
+func NewDeviceHandler(pushService *push.Service) *DeviceHandler {
+	return &DeviceHandler{pushService: pushService}
+}
+
+func (h *DeviceHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	var req DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !push.ValidPlatform(req.Platform) {
+		http.Error(w, "Invalid platform. Use 'fcm', 'apns' or 'webpush'", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		http.Error(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	device, err := h.pushService.Register(userID, req.Platform, req.Token)
+	if err != nil {
+		http.Error(w, "Failed to register device", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(device)
+}
+
+func (h *DeviceHandler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+	var req DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	if err := h.pushService.Unregister(userID, req.Token); err != nil {
+		http.Error(w, "Failed to unregister device", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Device unregistered"})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/anticheat"
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/stats"
+	"sudoku/internal/sudoku"
+)
+
+// Disputes a player may open per game
+const maxDisputesPerGame = 1
+
+type DisputeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// DisputeGame re-validates a game graded incorrect against its stored grid.
+// A grid that decodes to a valid solution is regraded, one that can't be
+// decoded or lost its clues is reopened for resubmission, anything else is rejected.
+func (h *GameHandler) DisputeGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	var req DisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if gameResult.CompletedAt == nil || gameResult.Completed || gameResult.Skipped || gameResult.Voided {
+		http.Error(w, "Only games graded incorrect can be disputed", http.StatusConflict)
+		return
+	}
+
+	var disputes int64
+	h.db.Model(&models.GameDispute{}).Where("game_result_id = ?", gameResult.ID).Count(&disputes)
+	if disputes >= maxDisputesPerGame {
+		http.Error(w, "Game has already been disputed", http.StatusConflict)
+		return
+	}
+
+	dispute := models.GameDispute{
+		GameResultID:  gameResult.ID,
+		UserID:        userID,
+		Reason:        req.Reason,
+		PreviousGrid:  gameResult.FinalGrid,
+		PreviousScore: gameResult.Score,
+	}
+
+	startBoard := sudoku.StringToBoard(gameResult.Puzzle.StartingGrid)
+	solutionBoard := sudoku.StringToBoard(gameResult.Puzzle.Solution)
+
+	var flags []string
+	grid, decoded := sudoku.NormalizeGrid(gameResult.FinalGrid)
+	switch {
+	case !decoded:
+		dispute.Outcome = models.DisputeReopened
+		dispute.Detail = "Stored grid could not be decoded"
+		reopenGame(&gameResult, gameResult.Puzzle.StartingGrid)
+	case !sudoku.KeepsGivens(startBoard, sudoku.StringToBoard(grid)):
+		dispute.Outcome = models.DisputeReopened
+		dispute.Detail = "Stored grid does not match the puzzle's clues"
+		reopenGame(&gameResult, gameResult.Puzzle.StartingGrid)
+	case sudoku.IsSolved(sudoku.StringToBoard(grid), solutionBoard) || (!isVariant(&gameResult.Puzzle) && h.sudokuService.ValidateSolution(sudoku.StringToBoard(grid))):
+		// Either the grid only needed decoding or it is a valid alternative solution. A grid
+		// breaking a variant puzzle's cages or diagonals can still pass the classic rules, so those must match.
+		dispute.Outcome = models.DisputeRegraded
+		dispute.Detail = "Stored grid is a valid solution"
+		gameResult.FinalGrid = grid
+		gameResult.Completed = true
+		if gameResult.Mode == models.PlayMode && !gameResult.UsedHints && !gameResult.UsedAutoSolve {
+			// Score against the grid itself so alternative solutions earn full points
+			gameResult.Score = max(h.sudokuService.CalculateScore(startBoard, sudoku.StringToBoard(grid), sudoku.StringToBoard(grid))-sudoku.HintPenalty(gameResult.HintLevels), 0)
+			if err := h.eventService.ApplyBonus(&gameResult); err != nil {
+				http.Error(w, "Failed to apply event bonus", http.StatusInternalServerError)
+				return
+			}
+			if !gameResult.Practice {
+				flags = anticheat.Check(&gameResult, *gameResult.CompletedAt)
+				gameResult.UnderReview = len(flags) > 0
+			}
+		}
+	default:
+		dispute.Outcome = models.DisputeRejected
+		dispute.Detail = "Stored grid is not a valid solution"
+	}
+	dispute.NewScore = gameResult.Score
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&dispute).Error; err != nil {
+			return err
+		}
+		if dispute.Outcome == models.DisputeRejected {
+			return nil
+		}
+		if err := tx.Save(&gameResult).Error; err != nil {
+			return err
+		}
+		if len(flags) > 0 {
+			review := models.ResultReview{
+				GameResultID: gameResult.ID,
+				Reasons:      strings.Join(flags, "; "),
+				Status:       models.ReviewPending,
+			}
+			if err := tx.Create(&review).Error; err != nil {
+				return err
+			}
+		}
+		return stats.RecomputeUser(tx, userID)
+	})
+	if err != nil {
+		http.Error(w, "Failed to resolve dispute", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"dispute":      dispute,
+		"correct":      gameResult.Completed,
+		"score":        gameResult.Score,
+		"under_review": gameResult.UnderReview,
+	}
+	h.awardEventBadge(&gameResult, response)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Put a finished game back in progress so it can be resubmitted
+func reopenGame(gameResult *models.GameResult, grid string) {
+	gameResult.FinalGrid = grid
+	gameResult.CompletedAt = nil
+	if gameResult.LastSeenAt != nil {
+		// Don't count the time between the submission and the dispute
+		now := time.Now()
+		gameResult.LastSeenAt = &now
+	}
+}
+
+func (h *AdminHandler) GetDisputes(w http.ResponseWriter, r *http.Request) {
+	query := h.db.Order("created_at DESC")
+	if outcome := r.URL.Query().Get("outcome"); outcome != "" {
+		query = query.Where("outcome = ?", outcome)
+	}
+
+	var disputes []models.GameDispute
+	if err := query.Find(&disputes).Error; err != nil {
+		http.Error(w, "Failed to fetch disputes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(disputes)
+}
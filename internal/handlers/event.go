@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+)
+
+type EventHandler struct {
+	db                 *gorm.DB
+	leaderboardService *leaderboard.Service
+}
+
+type EventRequest struct {
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	BonusMultiplier float64   `json:"bonus_multiplier"` // Defaults to 1
+	BadgeName       string    `json:"badge_name"`
+	BadgeThreshold  int       `json:"badge_threshold"` // Defaults to every puzzle of the event
+	PuzzleIDs       []uint    `json:"puzzle_ids"`
+}
+
+// Largest bonus an event may give
+const maxBonusMultiplier = 5
+
+func NewEventHandler(db *gorm.DB, leaderboardService *leaderboard.Service) *EventHandler {
+	return &EventHandler{
+		db:                 db,
+		leaderboardService: leaderboardService,
+	}
+}
+
+// GetEvents lists the running and upcoming events, soonest first
+func (h *EventHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	events := []models.Event{}
+	if err := h.db.Where("ends_at > ?", time.Now()).Order("starts_at ASC").Find(&events).Error; err != nil {
+		http.Error(w, "Failed to fetch events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// GetEvent returns an event with its puzzle set. The puzzles stay hidden until the event starts.
+func (h *EventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var event models.Event
+	if err := h.db.First(&event, id).Error; err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !time.Now().Before(event.StartsAt) {
+		if err := h.db.Preload("Puzzle").Where("event_id = ?", id).Order("position ASC").Find(&event.Puzzles).Error; err != nil {
+			http.Error(w, "Failed to fetch event puzzles", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
+
+// GetEventLeaderboard returns the best results of games played in the event, bonus included
+func (h *EventHandler) GetEventLeaderboard(w http.ResponseWriter, r *http.Request) {
+	sortBy := r.URL.Query().Get("type")
+	if sortBy == "" {
+		sortBy = "score"
+	}
+
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var event models.Event
+	if err := h.db.First(&event, id).Error; err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+
+	results, err := h.leaderboardService.WithContext(r.Context()).Top(leaderboard.Filter{EventID: event.ID, From: event.StartsAt, To: event.EndsAt}, sortBy)
+	if err != nil {
+		http.Error(w, "Failed to fetch event leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// GetBadges lists the event badges the user has earned, newest first
+func (h *EventHandler) GetBadges(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	badges := []models.EventBadge{}
+	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&badges).Error; err != nil {
+		http.Error(w, "Failed to fetch badges", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(badges)
+}
+
+func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req EventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		http.Error(w, "starts_at and ends_at are required", http.StatusBadRequest)
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		http.Error(w, "ends_at must be after starts_at", http.StatusBadRequest)
+		return
+	}
+	if req.BonusMultiplier == 0 {
+		req.BonusMultiplier = 1
+	}
+	if req.BonusMultiplier < 1 || req.BonusMultiplier > maxBonusMultiplier {
+		http.Error(w, "bonus_multiplier must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+	if len(req.PuzzleIDs) == 0 {
+		http.Error(w, "At least one puzzle is required", http.StatusBadRequest)
+		return
+	}
+	if req.BadgeThreshold <= 0 || req.BadgeThreshold > len(req.PuzzleIDs) {
+		req.BadgeThreshold = len(req.PuzzleIDs)
+	}
+
+	event := models.Event{
+		Name:            req.Name,
+		Description:     req.Description,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		BonusMultiplier: req.BonusMultiplier,
+		BadgeName:       req.BadgeName,
+		BadgeThreshold:  req.BadgeThreshold,
+		CreatedByID:     adminID,
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		for i, puzzleID := range req.PuzzleIDs {
+			var puzzle models.Puzzle
+			if err := tx.First(&puzzle, puzzleID).Error; err != nil {
+				return err
+			}
+			entry := models.EventPuzzle{EventID: event.ID, PuzzleID: puzzle.ID, Position: i + 1}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			entry.Puzzle = puzzle
+			event.Puzzles = append(event.Puzzles, entry)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Puzzle not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to save event", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(event)
+}
+
+// DeleteEvent cancels an event. Badges already earned are kept.
+func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Event{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("event_id = ?", id).Delete(&models.EventPuzzle{}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete event", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Deleted", "id": id})
+}
+
+// StartEventGame starts a play mode game on one of the puzzles of a running event
+func (h *GameHandler) StartEventGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		PuzzleID uint `json:"puzzle_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var event models.Event
+	if err := h.db.First(&event, id).Error; err != nil {
+		http.Error(w, "Event not found", http.StatusNotFound)
+		return
+	}
+	if !event.Active(time.Now()) {
+		http.Error(w, "Event is not running", http.StatusForbidden)
+		return
+	}
+
+	var entry models.EventPuzzle
+	if err := h.db.Preload("Puzzle").Where("event_id = ? AND puzzle_id = ?", event.ID, req.PuzzleID).First(&entry).Error; err != nil {
+		http.Error(w, "Puzzle is not part of the event", http.StatusNotFound)
+		return
+	}
+
+	gameResult, err := h.openGame(userID, &entry.Puzzle, models.PlayMode, gameOptions{EventID: &event.ID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"puzzle":         entry.Puzzle,
+		"started_at":     gameResult.StartedAt,
+		"event":          event,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Add the event badge to a response if the game just earned it
+func (h *GameHandler) awardEventBadge(gameResult *models.GameResult, response map[string]interface{}) {
+	if gameResult.EventID == nil || !gameResult.Completed || gameResult.Disqualified {
+		return
+	}
+	badge, err := h.eventService.AwardBadge(gameResult.UserID, *gameResult.EventID)
+	if err != nil {
+		log.Printf("Failed to check event badge for game %d: %v", gameResult.ID, err)
+	}
+	if badge != nil {
+		response["badge"] = badge
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+type FeaturedHandler struct {
+	db                 *gorm.DB
+	sudokuService      *sudoku.Service
+	leaderboardService *leaderboard.Service
+}
+
+type FeaturePuzzleRequest struct {
+	PuzzleID     uint              `json:"puzzle_id"`
+	StartingGrid string            `json:"starting_grid"` // Used to feature a new puzzle when puzzle_id is empty
+	Difficulty   models.Difficulty `json:"difficulty"`
+	Title        string            `json:"title"`
+	AuthorName   string            `json:"author_name"`
+	AuthorBlurb  string            `json:"author_blurb"`
+	StartsAt     time.Time         `json:"starts_at"`
+	EndsAt       time.Time         `json:"ends_at"`
+}
+
+func NewFeaturedHandler(db *gorm.DB, sudokuService *sudoku.Service, leaderboardService *leaderboard.Service) *FeaturedHandler {
+	return &FeaturedHandler{
+		db:                 db,
+		sudokuService:      sudokuService,
+		leaderboardService: leaderboardService,
+	}
+}
+
+// The featured puzzle whose window contains the current time, latest start first
+func currentFeatured(db *gorm.DB) (*models.FeaturedPuzzle, error) {
+	now := time.Now()
+	var featured models.FeaturedPuzzle
+	err := db.Preload("Puzzle").
+		Where("starts_at <= ? AND ends_at > ?", now, now).
+		Order("starts_at DESC").
+		First(&featured).Error
+	if err != nil {
+		return nil, err
+	}
+	return &featured, nil
+}
+
+func (h *FeaturedHandler) GetFeatured(w http.ResponseWriter, r *http.Request) {
+	featured, err := currentFeatured(h.db)
+	if err != nil {
+		http.Error(w, "No featured puzzle right now", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(featured)
+}
+
+func (h *FeaturedHandler) GetFeaturedResults(w http.ResponseWriter, r *http.Request) {
+	sortBy := r.URL.Query().Get("type")
+	if sortBy == "" {
+		sortBy = "time"
+	}
+
+	featured, err := currentFeatured(h.db)
+	if err != nil {
+		http.Error(w, "No featured puzzle right now", http.StatusNotFound)
+		return
+	}
+
+	results, err := h.leaderboardService.WithContext(r.Context()).Top(leaderboard.Filter{PuzzleID: featured.PuzzleID, From: featured.StartsAt, To: featured.EndsAt}, sortBy)
+	if err != nil {
+		http.Error(w, "Failed to fetch featured results", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (h *FeaturedHandler) CreateFeatured(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req FeaturePuzzleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Title == "" {
+		http.Error(w, "Title is required", http.StatusBadRequest)
+		return
+	}
+	if req.StartsAt.IsZero() {
+		req.StartsAt = time.Now()
+	}
+	if req.EndsAt.IsZero() {
+		req.EndsAt = req.StartsAt.AddDate(0, 0, 7)
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		http.Error(w, "ends_at must be after starts_at", http.StatusBadRequest)
+		return
+	}
+
+	var puzzle models.Puzzle
+	if req.PuzzleID != 0 {
+		if err := h.db.First(&puzzle, req.PuzzleID).Error; err != nil {
+			http.Error(w, "Puzzle not found", http.StatusNotFound)
+			return
+		}
+	} else {
+		// Feature a brand new puzzle, which must have a unique solution
+		board, err := sudoku.ParseBoard(req.StartingGrid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		count, err := h.sudokuService.CountSolutions(r.Context(), board, 2)
+		if err != nil {
+			writeSolverError(w, err)
+			return
+		}
+		if count != 1 {
+			http.Error(w, "Puzzle must have exactly one solution", http.StatusBadRequest)
+			return
+		}
+		if _, err := h.sudokuService.GetGenerationProfile(req.Difficulty); err != nil {
+			http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
+			return
+		}
+		solution, err := h.sudokuService.SolvePuzzle(r.Context(), board)
+		if err != nil {
+			writeSolverError(w, err)
+			return
+		}
+
+		// A puzzle that is already saved, in any disguise, is featured as it is instead of saved twice
+		hash := sudoku.CanonicalHash(board)
+		if err := h.db.Where("canonical_hash = ?", hash).First(&puzzle).Error; err != nil {
+			rating := h.sudokuService.RatePuzzle(board)
+			puzzle = models.Puzzle{
+				Difficulty:         req.Difficulty,
+				StartingGrid:       req.StartingGrid,
+				Solution:           sudoku.BoardToString(solution),
+				RequiresUniqueness: h.sudokuService.UsesUniqueness(board),
+				Rating:             rating.Difficulty,
+				HardestTechnique:   rating.HardestTechnique,
+				CanonicalHash:      hash,
+			}
+			if err := h.db.Create(&puzzle).Error; err != nil {
+				http.Error(w, "Failed to save puzzle", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	featured := models.FeaturedPuzzle{
+		PuzzleID:    puzzle.ID,
+		Puzzle:      puzzle,
+		Title:       req.Title,
+		AuthorName:  req.AuthorName,
+		AuthorBlurb: req.AuthorBlurb,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		CreatedByID: adminID,
+	}
+	if err := h.db.Omit("Puzzle").Create(&featured).Error; err != nil {
+		http.Error(w, "Failed to feature puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(featured)
+}
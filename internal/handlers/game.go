@@ -1,27 +1,55 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"sudoku/internal/anticheat"
 	"sudoku/internal/auth"
+	"sudoku/internal/event"
+	"sudoku/internal/goals"
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/locale"
 	"sudoku/internal/models"
+	"sudoku/internal/pool"
 	"sudoku/internal/sudoku"
+	"sudoku/internal/vault"
+	"sudoku/internal/wallet"
+)
+
+const (
+	// Number of times a generated puzzle may be redrawn because the user skipped it before
+	maxGenerationAttempts = 5
+	// Number of puzzles a user may skip per day
+	maxSkipsPerDay = 3
 )
 
 type GameHandler struct {
-	db            *gorm.DB
-	sudokuService *sudoku.Service
+	db                 *gorm.DB
+	sudokuService      *sudoku.Service
+	leaderboardService *leaderboard.Service
+	poolService        *pool.Service
+	eventService       *event.Service
+	goalService        *goals.Service
+	walletService      *wallet.Service
 }
 
 type StartGameRequest struct {
 	Difficulty string `json:"difficulty"`
 	Mode       string `json:"mode"`
+	Variant    string `json:"variant,omitempty"` // "classic" (default), "killer", "diagonal", "jigsaw" or "evenodd"
+	AntiKnight bool   `json:"anti_knight,omitempty"`
+	AntiKing   bool   `json:"anti_king,omitempty"`
 }
 
 type SubmitGameRequest struct {
@@ -32,10 +60,15 @@ type SubmitGameRequest struct {
 	UsedAutoSolve bool   `json:"used_auto_solve"`
 }
 
-func NewGameHandler(db *gorm.DB, sudokuService *sudoku.Service) *GameHandler {
+func NewGameHandler(db *gorm.DB, sudokuService *sudoku.Service, leaderboardService *leaderboard.Service, poolService *pool.Service, eventService *event.Service, goalService *goals.Service, walletService *wallet.Service) *GameHandler {
 	return &GameHandler{
-		db:            db,
-		sudokuService: sudokuService,
+		db:                 db,
+		sudokuService:      sudokuService,
+		leaderboardService: leaderboardService,
+		poolService:        poolService,
+		eventService:       eventService,
+		goalService:        goalService,
+		walletService:      walletService,
 	}
 }
 
@@ -47,7 +80,15 @@ func (h *GameHandler) StartGame(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := r.Context().Value(auth.UserIDKey).(uint)
-	log.Printf("StartGame called - UserID: %d, Difficulty: %s, Mode: %s", userID, req.Difficulty, req.Mode)
+	log.Printf("StartGame called - UserID: %d, Difficulty: %s, Mode: %s, Variant: %s", userID, req.Difficulty, req.Mode, req.Variant)
+
+	// Without an explicit difficulty, start at the level placed by the onboarding quiz
+	if req.Difficulty == "" {
+		var user models.User
+		if err := h.db.Select("starting_difficulty").First(&user, userID).Error; err == nil {
+			req.Difficulty = string(user.StartingDifficulty)
+		}
+	}
 
 	// Validate difficulty
 	var difficulty models.Difficulty
@@ -58,6 +99,8 @@ func (h *GameHandler) StartGame(w http.ResponseWriter, r *http.Request) {
 		difficulty = models.Medium
 	case "hard":
 		difficulty = models.Hard
+	case "expert":
+		difficulty = models.Expert
 	default:
 		http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
 		return
@@ -75,35 +118,217 @@ func (h *GameHandler) StartGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate a new puzzle dynamically
-	puzzleBoard, solutionBoard, err := h.sudokuService.GeneratePuzzle(difficulty)
+	var puzzle *models.Puzzle
+	var gameResult *models.GameResult
+	var err error
+	variant := models.Variant(req.Variant)
+	switch variant {
+	case "", models.Classic:
+		variant = models.Classic
+	case models.Killer, models.Diagonal, models.Jigsaw, models.EvenOdd:
+	default:
+		http.Error(w, "Invalid variant", http.StatusBadRequest)
+		return
+	}
+	chess := sudoku.GenerateOptions{AntiKnight: req.AntiKnight, AntiKing: req.AntiKing}
+	if !chessFits(variant, chess) {
+		http.Error(w, "These chess constraints can't be added to the variant", http.StatusBadRequest)
+		return
+	}
+
+	if variant == models.Classic && !chess.AntiKnight && !chess.AntiKing {
+		puzzle, gameResult, err = h.createGame(userID, difficulty, mode)
+	} else {
+		// The techniques Learn mode teaches don't know about cages, diagonals, regions, parity or chess moves
+		if mode == models.LearnMode {
+			http.Error(w, "Variant puzzles can only be played in play mode", http.StatusBadRequest)
+			return
+		}
+		puzzle, gameResult, err = h.createVariantGame(userID, difficulty, mode, variant, chess)
+	}
 	if err != nil {
-		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Save the generated puzzle to the database
-	puzzle := &models.Puzzle{
-		Difficulty:   difficulty,
-		StartingGrid: sudoku.BoardToString(puzzleBoard),
-		Solution:     sudoku.BoardToString(solutionBoard),
+	response := map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"puzzle":         puzzle,
+		"started_at":     gameResult.StartedAt,
 	}
-	if err := h.db.Create(puzzle).Error; err != nil {
-		http.Error(w, "Failed to save generated puzzle", http.StatusInternalServerError)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Draw a puzzle for the user from the pool, or generate one if the pool has run dry,
+// and open a game session on it. Puzzles the user has skipped before are never served again.
+func (h *GameHandler) createGame(userID uint, difficulty models.Difficulty, mode models.GameMode) (*models.Puzzle, *models.GameResult, error) {
+	puzzle, err := h.poolService.Take(difficulty, userID)
+	if err == nil {
+		gameResult, err := h.openGame(userID, puzzle, mode, gameOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		return puzzle, gameResult, nil
+	}
+	if !errors.Is(err, pool.ErrEmpty) {
+		log.Printf("Failed to draw a %s puzzle from the pool: %v", difficulty, err)
+	}
+
+	// Generated puzzles that are already saved, in any disguise, are thrown away and generated again
+	for attempt := 0; attempt < maxGenerationAttempts && puzzle == nil; attempt++ {
+		// Generate a new puzzle dynamically
+		puzzleBoard, solutionBoard, err := h.sudokuService.GeneratePuzzle(difficulty, sudoku.GenerateOptions{})
+		if err != nil {
+			return nil, nil, errors.New("Failed to generate puzzle")
+		}
+		startingGrid := sudoku.BoardToString(puzzleBoard)
+
+		var skipped int64
+		h.db.Model(&models.PuzzleSkip{}).
+			Joins("JOIN puzzles ON puzzle_skips.puzzle_id = puzzles.id").
+			Scopes(models.Active("puzzles")).
+			Where("puzzle_skips.user_id = ? AND puzzles.starting_grid = ?", userID, startingGrid).
+			Count(&skipped)
+		if skipped > 0 {
+			continue
+		}
+
+		candidate := h.newPuzzle(difficulty, puzzleBoard, solutionBoard)
+		result := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(candidate)
+		if result.Error != nil {
+			return nil, nil, errors.New("Failed to save generated puzzle")
+		}
+		if result.RowsAffected > 0 {
+			puzzle = candidate
+		}
+	}
+	if puzzle == nil {
+		return nil, nil, errors.New("Failed to generate puzzle")
+	}
+
+	gameResult, err := h.openGame(userID, puzzle, mode, gameOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return puzzle, gameResult, nil
+}
+
+// Build the model of a generated puzzle with its technique rating
+func (h *GameHandler) newPuzzle(difficulty models.Difficulty, puzzleBoard, solutionBoard sudoku.Board) *models.Puzzle {
+	rating := h.sudokuService.RatePuzzle(puzzleBoard)
+	return &models.Puzzle{
+		Difficulty:         difficulty,
+		Variant:            models.Classic,
+		StartingGrid:       sudoku.BoardToString(puzzleBoard),
+		Solution:           sudoku.BoardToString(solutionBoard),
+		RequiresUniqueness: h.sudokuService.UsesUniqueness(puzzleBoard),
+		Rating:             rating.Difficulty,
+		HardestTechnique:   rating.HardestTechnique,
+		CanonicalHash:      sudoku.CanonicalHash(puzzleBoard),
+	}
+}
+
+// gameOptions marks a new game session as something other than a regular game
+type gameOptions struct {
+	Practice bool  // Never reaches the leaderboards or the user's scored totals
+	EventID  *uint // Played in an event, for its bonus, leaderboard and badge
+}
+
+// Open a game session for the user on an existing puzzle, recorded as the first attempt of a new game
+func (h *GameHandler) openGame(userID uint, puzzle *models.Puzzle, mode models.GameMode, opts gameOptions) (*models.GameResult, error) {
+	var gameResult *models.GameResult
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		game := models.Game{UserID: userID, PuzzleID: puzzle.ID, Mode: mode, Attempts: 1}
+		if err := tx.Create(&game).Error; err != nil {
+			return err
+		}
+
+		gameResult = &models.GameResult{
+			GameID:    game.ID,
+			Attempt:   1,
+			UserID:    userID,
+			PuzzleID:  puzzle.ID,
+			Mode:      mode,
+			Practice:  opts.Practice,
+			EventID:   opts.EventID,
+			StartedAt: time.Now(),
+			FinalGrid: puzzle.StartingGrid, // Initialize FinalGrid with the puzzle's starting state
+		}
+		return tx.Create(gameResult).Error
+	})
+	if err != nil {
+		return nil, errors.New("Failed to create game session")
+	}
+
+	return gameResult, nil
+}
+
+func (h *GameHandler) StartFeaturedGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	featured, err := currentFeatured(h.db)
+	if err != nil {
+		http.Error(w, "No featured puzzle right now", http.StatusNotFound)
+		return
+	}
+
+	// Featured puzzles are always played competitively so they land on the results board
+	gameResult, err := h.openGame(userID, &featured.Puzzle, models.PlayMode, gameOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Create game result
-	gameResult := &models.GameResult{
-		UserID:    userID,
-		PuzzleID:  puzzle.ID,
-		Mode:      mode,
-		StartedAt: time.Now(),
-		FinalGrid: puzzle.StartingGrid, // Initialize FinalGrid with the puzzle's starting state
+	response := map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"puzzle":         featured.Puzzle,
+		"started_at":     gameResult.StartedAt,
+		"featured":       featured,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartTechniqueGame starts a Learn mode game on a new puzzle whose solve path needs the requested technique
+func (h *GameHandler) StartTechniqueGame(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Technique string `json:"technique"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
 
-	if err := h.db.Create(gameResult).Error; err != nil {
-		http.Error(w, "Failed to create game session", http.StatusInternalServerError)
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	puzzleBoard, solutionBoard, err := h.sudokuService.GeneratePuzzleRequiring(req.Technique)
+	if errors.Is(err, sudoku.ErrUnknownTechnique) {
+		http.Error(w, "Unknown technique", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, sudoku.ErrTechniqueNotFound) {
+		http.Error(w, "No puzzle needing this technique was found, please try again", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	// The puzzle is filed under the difficulty its solve is rated at
+	puzzle := h.newPuzzle("", puzzleBoard, solutionBoard)
+	puzzle.Difficulty = puzzle.Rating
+	if err := h.db.Create(puzzle).Error; err != nil {
+		http.Error(w, "Failed to save generated puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	gameResult, err := h.openGame(userID, puzzle, models.LearnMode, gameOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -111,6 +336,144 @@ func (h *GameHandler) StartGame(w http.ResponseWriter, r *http.Request) {
 		"game_result_id": gameResult.ID,
 		"puzzle":         puzzle,
 		"started_at":     gameResult.StartedAt,
+		"technique":      req.Technique,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *GameHandler) SkipGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if gameResult.CompletedAt != nil || gameResult.Skipped || gameResult.Expired {
+		http.Error(w, "Game is already finished", http.StatusConflict)
+		return
+	}
+
+	// Enforce the daily skip limit, counting days in the user's timezone
+	startOfDay := locale.StartOfDay(r.Context(), time.Now())
+	var skipsToday int64
+	h.db.Model(&models.PuzzleSkip{}).Where("user_id = ? AND created_at >= ?", userID, startOfDay).Count(&skipsToday)
+	if skipsToday >= maxSkipsPerDay {
+		http.Error(w, "Daily skip limit reached", http.StatusTooManyRequests)
+		return
+	}
+
+	// Abandon the game without touching the user's stats and blacklist the puzzle
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		gameResult.Skipped = true
+		if err := tx.Save(&gameResult).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PuzzleSkip{
+			UserID:       userID,
+			PuzzleID:     gameResult.PuzzleID,
+			GameResultID: gameResult.ID,
+		}).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to skip game", http.StatusInternalServerError)
+		return
+	}
+
+	// Issue a replacement with the same difficulty and mode
+	puzzle, replacement, err := h.createGame(userID, gameResult.Puzzle.Difficulty, gameResult.Mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"skipped_game_result_id": gameResult.ID,
+		"skips_remaining":        maxSkipsPerDay - skipsToday - 1,
+		"game_result_id":         replacement.ID,
+		"puzzle":                 puzzle,
+		"started_at":             replacement.StartedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Add the time since the last heartbeat to the active solve time, unless the client reports the
+// game was paused in between. Silence alone never pauses the timer, so playing offline between
+// two heartbeats still counts.
+func recordActivity(gameResult *models.GameResult, now time.Time, paused bool) {
+	lastSeen := gameResult.StartedAt
+	if gameResult.LastSeenAt != nil {
+		lastSeen = *gameResult.LastSeenAt
+	}
+	if gap := now.Sub(lastSeen); gap > 0 && !paused {
+		gameResult.ActiveSeconds += int(gap.Seconds())
+	}
+	gameResult.LastSeenAt = &now
+}
+
+func (h *GameHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Paused bool `json:"paused"` // The game was paused since the previous heartbeat
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if gameResult.CompletedAt != nil || gameResult.Skipped || gameResult.Expired {
+		http.Error(w, "Game is already finished", http.StatusConflict)
+		return
+	}
+
+	recordActivity(&gameResult, time.Now(), req.Paused)
+
+	// Only touch the activity columns so a heartbeat never races with grid updates
+	err := h.db.Model(&gameResult).Updates(map[string]interface{}{
+		"last_seen_at":   gameResult.LastSeenAt,
+		"active_seconds": gameResult.ActiveSeconds,
+	}).Error
+	if err != nil {
+		http.Error(w, "Failed to record heartbeat", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"active_seconds": gameResult.ActiveSeconds,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -128,7 +491,7 @@ func (h *GameHandler) SubmitGame(w http.ResponseWriter, r *http.Request) {
 
 	// Get game result
 	var gameResult models.GameResult
-	if err := h.db.Preload("Puzzle").First(&gameResult, req.GameResultID).Error; err != nil {
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
 	}
@@ -138,14 +501,30 @@ func (h *GameHandler) SubmitGame(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
+	if gameResult.Expired {
+		http.Error(w, "Game has expired", http.StatusConflict)
+		return
+	}
+	if gameResult.Skipped || gameResult.Voided {
+		http.Error(w, "Game is no longer open", http.StatusConflict)
+		return
+	}
 
 	// Update game result
 	now := time.Now()
+	solvedBefore := gameResult.Completed
 	gameResult.FinalGrid = req.FinalGrid
 	gameResult.TimeSeconds = req.TimeSeconds
-	gameResult.UsedHints = req.UsedHints
-	gameResult.UsedAutoSolve = req.UsedAutoSolve
+	if gameResult.LastSeenAt != nil {
+		// Clients sending heartbeats are scored on the server-measured active time
+		recordActivity(&gameResult, now, false)
+		gameResult.TimeSeconds = gameResult.ActiveSeconds
+	}
+	// Hints and auto-solve recorded by the server stick whatever the client reports
+	gameResult.UsedHints = gameResult.UsedHints || req.UsedHints
+	gameResult.UsedAutoSolve = gameResult.UsedAutoSolve || req.UsedAutoSolve
 	gameResult.CompletedAt = &now
+	gameResult.NeedsReplay = true
 
 	// Validate solution
 	isCorrect := sudoku.IsSolved(sudoku.StringToBoard(req.FinalGrid), sudoku.StringToBoard(gameResult.Puzzle.Solution))
@@ -153,47 +532,86 @@ func (h *GameHandler) SubmitGame(w http.ResponseWriter, r *http.Request) {
 
 	if isCorrect {
 		// Calculate score for play mode
-		if gameResult.Mode == models.PlayMode && !req.UsedHints && !req.UsedAutoSolve {
+		if gameResult.Mode == models.PlayMode && !gameResult.UsedHints && !gameResult.UsedAutoSolve {
 			initialBoard := sudoku.StringToBoard(gameResult.Puzzle.StartingGrid)
 			finalBoard := sudoku.StringToBoard(req.FinalGrid)
 			solutionBoard := sudoku.StringToBoard(gameResult.Puzzle.Solution)
-			gameResult.Score = h.sudokuService.CalculateScore(initialBoard, finalBoard, solutionBoard)
+			gameResult.Score = max(h.sudokuService.CalculateScore(initialBoard, finalBoard, solutionBoard)-sudoku.HintPenalty(gameResult.HintLevels), 0)
+			if err := h.eventService.ApplyBonus(&gameResult); err != nil {
+				log.Printf("Failed to apply event bonus to game %d: %v", gameResult.ID, err)
+			}
 
 			// Update user stats
-			h.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
-				"total_points": gorm.Expr("total_points + ?", gameResult.Score),
-				"games_played": gorm.Expr("games_played + 1"),
-			})
+			if !gameResult.Practice {
+				h.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+					"total_points":   gorm.Expr("total_points + ?", gameResult.Score),
+					"ranking_points": gorm.Expr("ranking_points + ?", gameResult.Score), // At full weight until the next decay run
+					"games_played":   gorm.Expr("games_played + 1"),
+				})
+			}
+		}
+
+		// Retries only count towards practice stats
+		if gameResult.Practice {
+			h.db.Model(&models.User{}).Where("id = ?", userID).Update("practice_games", gorm.Expr("practice_games + 1"))
 		}
 	}
 
 	// Disqualify if hints or auto-solve used in play mode
-	if gameResult.Mode == models.PlayMode && (req.UsedHints || req.UsedAutoSolve) {
+	if gameResult.Mode == models.PlayMode && (gameResult.UsedHints || gameResult.UsedAutoSolve) {
 		gameResult.Disqualified = true
 	}
 
+	// Send suspicious scored results to the moderation queue
+	var flags []string
+	if gameResult.Score > 0 && !gameResult.Practice {
+		flags = anticheat.Check(&gameResult, now)
+		gameResult.UnderReview = len(flags) > 0
+	}
+
 	if err := h.db.Save(&gameResult).Error; err != nil {
 		http.Error(w, "Failed to save game result", http.StatusInternalServerError)
 		return
 	}
 
+	if len(flags) > 0 {
+		review := models.ResultReview{
+			GameResultID: gameResult.ID,
+			Reasons:      strings.Join(flags, "; "),
+			Status:       models.ReviewPending,
+		}
+		if err := h.db.Create(&review).Error; err != nil {
+			log.Printf("Failed to queue game %d for review: %v", gameResult.ID, err)
+		}
+	}
+
 	response := map[string]interface{}{
 		"correct":      isCorrect,
 		"score":        gameResult.Score,
 		"disqualified": gameResult.Disqualified,
 		"time_seconds": gameResult.TimeSeconds,
 	}
+	h.awardEventBadge(&gameResult, response)
+	h.checkGoals(r.Context(), &gameResult, response)
+	if isCorrect && !solvedBefore && gameResult.Mode == models.LearnMode && !gameResult.UsedAutoSolve {
+		h.rewardLearnGame(&gameResult, response)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetHint gives a tiered hint on the next move: level 1 names the technique and the house to look in,
+// level 2 points at the cell and level 3 fills it. Every level revealed is counted against the game's
+// score once, so stepping up from level 1 to 3 on the same move costs what asking for level 3 does.
+// In Learn mode "mode": "eliminate" points out candidates to remove instead.
 func (h *GameHandler) GetHint(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		GameResultID uint   `json:"game_result_id"`
-		Mode         string `json:"mode"` // "find_cell" or "fill_cell"
+		Level        int    `json:"level"` // 1 technique, 2 cell, 3 value
+		Mode         string `json:"mode"`  // "eliminate" asks for an elimination instead of a tiered hint
 		Row          *int   `json:"row,omitempty"`
-		Col          *int   `json:"col,omitempty"`
+		Col          *int   `json:"col,omitempty"` // Row and Col pick the cell filled at level 3
 		CurrentGrid  string `json:"current_grid"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -205,7 +623,7 @@ func (h *GameHandler) GetHint(w http.ResponseWriter, r *http.Request) {
 
 	// Get game result
 	var gameResult models.GameResult
-	if err := h.db.Preload("Puzzle").First(&gameResult, req.GameResultID).Error; err != nil {
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
 	}
@@ -216,44 +634,172 @@ func (h *GameHandler) GetHint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	board := sudoku.StringToBoard(req.CurrentGrid)
-	var hint *sudoku.Move
-	var err error
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Learn mode only hands out moves that human techniques can reach
+	learnMode := gameResult.Mode == models.LearnMode
 
-	if req.Mode == "find_cell" {
-		// Find a solvable cell to highlight
-		hint, err = h.sudokuService.FindSolvableCell(board)
+	if req.Mode == "eliminate" {
+		// Point out candidates to remove instead of a value, so the learner still fills the cell
+		if !learnMode {
+			http.Error(w, "Elimination hints are only available in Learn mode", http.StatusForbidden)
+			return
+		}
+		deduction, err := h.sudokuService.FindElimination(r.Context(), board)
+		if errors.Is(err, sudoku.ErrNoLogicalMove) {
+			writeNoLogicalMove(w)
+			return
+		}
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeSolverError(w, err)
 			return
 		}
-	} else if req.Mode == "fill_cell" {
-		// Fill the specified cell with the correct value
-		if req.Row == nil || req.Col == nil {
-			http.Error(w, "Row and Col are required for fill_cell mode", http.StatusBadRequest)
+
+		deduction.Highlight = sudoku.HighlightDeduction(deduction)
+		deduction.Explanation = sudoku.ExplainDeduction(deduction)
+		w.Header().Set("Content-Type", "application/json")
+		if gameResult.CompletedAt != nil {
+			json.NewEncoder(w).Encode(deduction)
 			return
 		}
 
-		hint, err = h.sudokuService.GetHint(board, *req.Row, *req.Col)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		spend := models.TokenTransaction{Reason: models.EliminationPurchase, Technique: deduction.Technique}
+		charged, ok := h.chargeHint(w, &gameResult, spend, wallet.EliminationPrice(deduction.Technique))
+		if !ok {
 			return
 		}
+		json.NewEncoder(w).Encode(struct {
+			*sudoku.Deduction
+			Cost    int `json:"cost"`
+			Balance int `json:"balance"`
+		}{deduction, -charged.Amount, charged.Balance})
+		return
+	}
+	if req.Mode != "" {
+		http.Error(w, "Invalid mode. Use 'eliminate' or ask for a hint level", http.StatusBadRequest)
+		return
+	}
+	if req.Level < sudoku.TechniqueLevel || req.Level > sudoku.ValueLevel {
+		http.Error(w, "Level must be 1 (technique), 2 (cell) or 3 (value)", http.StatusBadRequest)
+		return
+	}
 
-		// Mark that hints were used and update the board state
-		board[*req.Row][*req.Col] = hint.Value
-		gameResult.FinalGrid = sudoku.BoardToString(board)
-		gameResult.UsedHints = true
-		h.db.Save(&gameResult)
+	var move *sudoku.Move
+	variant := isVariant(&gameResult.Puzzle)
+	if req.Row != nil || req.Col != nil {
+		// Fill a cell of the player's choosing
+		if req.Level != sudoku.ValueLevel || req.Row == nil || req.Col == nil {
+			http.Error(w, "Row and Col may only be given together, at level 3", http.StatusBadRequest)
+			return
+		}
+		if *req.Row < 0 || *req.Row > 8 || *req.Col < 0 || *req.Col > 8 {
+			http.Error(w, "Row and Col must be between 0 and 8", http.StatusBadRequest)
+			return
+		}
+		if variant {
+			move, err = h.variantHint(r.Context(), &gameResult.Puzzle, board, req.Row, req.Col)
+		} else if learnMode {
+			move, err = h.sudokuService.LogicalHintForCell(r.Context(), board, *req.Row, *req.Col)
+		} else {
+			move, err = h.sudokuService.GetHint(r.Context(), board, *req.Row, *req.Col)
+		}
+	} else if variant {
+		move, err = h.variantHint(r.Context(), &gameResult.Puzzle, board, nil, nil)
+	} else if learnMode {
+		move, err = h.sudokuService.FindLogicalCell(r.Context(), board)
 	} else {
-		http.Error(w, "Invalid mode. Use 'find_cell' or 'fill_cell'", http.StatusBadRequest)
+		move, err = h.sudokuService.FindSolvableCell(r.Context(), board)
+	}
+	if errors.Is(err, sudoku.ErrNoLogicalMove) {
+		writeNoLogicalMove(w)
 		return
 	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	hint := sudoku.TierMove(move, req.Level)
+	if learnMode && req.Level == sudoku.ValueLevel {
+		// Walk the learner through why the value is forced
+		hint.Explanation = sudoku.ExplainMove(board, move)
+	}
+
+	// Asking again about the move last hinted at only counts the levels not yet revealed
+	cell := move.Row*9 + move.Col + 1
+	previous, revealed := 0, req.Level
+	if gameResult.HintedCell == cell {
+		previous = min(gameResult.HintedLevel, req.Level)
+		revealed = max(gameResult.HintedLevel, req.Level)
+	}
+
+	// Learn mode hints are paid for in learn tokens, more for harder techniques and deeper levels.
+	// Hints on a finished game change nothing and are free.
+	var charged *models.TokenTransaction
+	if price := wallet.HintPrice(hint.Technique, req.Level) - wallet.HintPrice(hint.Technique, previous); learnMode && gameResult.CompletedAt == nil && price > 0 {
+		spend := models.TokenTransaction{Reason: models.HintPurchase, Technique: hint.Technique, Level: req.Level}
+		var ok bool
+		if charged, ok = h.chargeHint(w, &gameResult, spend, price); !ok {
+			return
+		}
+	}
+
+	if gameResult.CompletedAt == nil {
+		updates := map[string]interface{}{
+			"hint_levels":  gorm.Expr("hint_levels + ?", req.Level-previous),
+			"hinted_cell":  cell,
+			"hinted_level": revealed,
+		}
+		if req.Level == sudoku.ValueLevel {
+			// Mark that a hint filled a cell and update the board state
+			board[move.Row][move.Col] = move.Value
+			updates["final_grid"] = sudoku.BoardToString(board)
+			updates["used_hints"] = true
+		}
+		h.db.Model(&gameResult).Updates(updates)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if charged != nil {
+		json.NewEncoder(w).Encode(struct {
+			sudoku.TieredHint
+			Cost    int `json:"cost"`
+			Balance int `json:"balance"`
+		}{hint, -charged.Amount, charged.Balance})
+		return
+	}
 	json.NewEncoder(w).Encode(hint)
 }
 
+// Report a solver failure, telling boards that are too expensive to solve apart from invalid ones
+func writeSolverError(w http.ResponseWriter, err error) {
+	var budgetErr *sudoku.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		http.Error(w, "Puzzle is too complex to solve within the server's limits", http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		http.Error(w, "Solver was stopped before it finished", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// Tell the learner that no human technique applies and which technique to study next
+func writeNoLogicalMove(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":            "No logical move can be found with the techniques taught in Learn mode",
+		"study_technique":  sudoku.StudyTechnique,
+		"known_techniques": sudoku.TechniqueNames(),
+	})
+}
+
 func (h *GameHandler) SolveStep(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		GameResultID uint   `json:"game_result_id"`
@@ -268,7 +814,7 @@ func (h *GameHandler) SolveStep(w http.ResponseWriter, r *http.Request) {
 
 	// Get game result
 	var gameResult models.GameResult
-	if err := h.db.Preload("Puzzle").First(&gameResult, req.GameResultID).Error; err != nil {
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
 	}
@@ -280,22 +826,135 @@ func (h *GameHandler) SolveStep(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get next step
-	board := sudoku.StringToBoard(req.CurrentGrid)
-	move, err := h.sudokuService.SolveStep(board)
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	var move *sudoku.Move
+	if isVariant(&gameResult.Puzzle) {
+		move, err = h.variantHint(r.Context(), &gameResult.Puzzle, board, nil, nil)
+	} else {
+		move, err = h.sudokuService.SolveStep(r.Context(), board)
+	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	// Learn mode steps are paid for like value hints, except on a finished game
+	var charged *models.TokenTransaction
+	if gameResult.Mode == models.LearnMode && gameResult.CompletedAt == nil {
+		var ok bool
+		if charged, ok = h.chargeSteps(w, &gameResult, []sudoku.Move{*move}); !ok {
+			return
+		}
+	}
 
 	// Update board and save to DB
 	board[move.Row][move.Col] = move.Value
 	gameResult.FinalGrid = sudoku.BoardToString(board)
 	h.db.Save(&gameResult)
 
+	move.Highlight = sudoku.HighlightMove(move)
 	w.Header().Set("Content-Type", "application/json")
+	if charged != nil {
+		json.NewEncoder(w).Encode(struct {
+			*sudoku.Move
+			Cost    int `json:"cost"`
+			Balance int `json:"balance"`
+		}{move, -charged.Amount, charged.Balance})
+		return
+	}
 	json.NewEncoder(w).Encode(move)
 }
 
+// Most moves one solve-steps request may ask for, enough to finish any board
+const maxSolveSteps = 81
+
+// SolveSteps applies the next "count" moves in one round trip so clients can animate several
+// steps of assistance. The board after the last move is saved and returned with the moves.
+// More than one step counts as auto-solve, like SolvePath.
+func (h *GameHandler) SolveSteps(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GameResultID uint   `json:"game_result_id"`
+		CurrentGrid  string `json:"current_grid"`
+		Count        int    `json:"count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Count < 1 || req.Count > maxSolveSteps {
+		http.Error(w, "Count must be between 1 and "+strconv.Itoa(maxSolveSteps), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	// Get game result
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var moves []sudoku.Move
+	if isVariant(&gameResult.Puzzle) {
+		moves, err = h.variantSteps(r.Context(), &gameResult.Puzzle, board, req.Count)
+	} else {
+		moves, err = h.sudokuService.SolveSteps(r.Context(), board, req.Count)
+	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	// Learn mode steps are paid for like value hints, except on a finished game
+	var charged *models.TokenTransaction
+	if gameResult.Mode == models.LearnMode && gameResult.CompletedAt == nil {
+		var ok bool
+		if charged, ok = h.chargeSteps(w, &gameResult, moves); !ok {
+			return
+		}
+	}
+
+	// Update board and save to DB
+	for i := range moves {
+		board[moves[i].Row][moves[i].Col] = moves[i].Value
+		moves[i].Highlight = sudoku.HighlightMove(&moves[i])
+	}
+	gameResult.FinalGrid = sudoku.BoardToString(board)
+	if len(moves) > 1 {
+		gameResult.UsedAutoSolve = true
+	}
+	h.db.Save(&gameResult)
+
+	response := map[string]interface{}{
+		"moves":        moves,
+		"current_grid": gameResult.FinalGrid,
+		"complete":     !strings.Contains(gameResult.FinalGrid, "0"),
+	}
+	if charged != nil {
+		response["cost"] = -charged.Amount
+		response["balance"] = charged.Balance
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (h *GameHandler) SolvePuzzle(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		GameResultID uint   `json:"game_result_id"`
@@ -310,7 +969,7 @@ func (h *GameHandler) SolvePuzzle(w http.ResponseWriter, r *http.Request) {
 
 	// Get game result
 	var gameResult models.GameResult
-	if err := h.db.Preload("Puzzle").First(&gameResult, req.GameResultID).Error; err != nil {
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
 	}
@@ -322,11 +981,19 @@ func (h *GameHandler) SolvePuzzle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Solve puzzle
-	board := sudoku.StringToBoard(req.CurrentGrid)
-	solvedBoard, success := h.sudokuService.SolvePuzzle(board)
-
-	if !success {
-		http.Error(w, "Puzzle cannot be solved", http.StatusBadRequest)
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var solvedBoard sudoku.Board
+	if isVariant(&gameResult.Puzzle) {
+		solvedBoard, err = h.solveVariant(r.Context(), &gameResult.Puzzle, board)
+	} else {
+		solvedBoard, err = h.sudokuService.SolvePuzzle(r.Context(), board)
+	}
+	if err != nil {
+		writeSolverError(w, err)
 		return
 	}
 
@@ -342,6 +1009,212 @@ func (h *GameHandler) SolvePuzzle(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// SolvePath returns every move needed to solve the grid, in order, so the client can
+// play a walkthrough. Revealing the path counts as auto-solve.
+func (h *GameHandler) SolvePath(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GameResultID uint   `json:"game_result_id"`
+		CurrentGrid  string `json:"current_grid"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	// Get game result
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var path *sudoku.Path
+	if isVariant(&gameResult.Puzzle) {
+		path, err = h.variantPath(r.Context(), &gameResult.Puzzle, board)
+	} else {
+		path, err = h.sudokuService.SolvePath(r.Context(), board)
+	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	// Mark that auto-solve was used
+	gameResult.UsedAutoSolve = true
+	h.db.Save(&gameResult)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(path)
+}
+
+// CheckGame tells the player whether their current grid has gone wrong: cells breaking the rules,
+// empty cells left without a candidate, or a grid that can no longer be completed. The solution is
+// never revealed. In Play mode a check counts as a hint.
+func (h *GameHandler) CheckGame(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GameResultID uint   `json:"game_result_id"`
+		CurrentGrid  string `json:"current_grid"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !sudoku.KeepsGivens(sudoku.StringToBoard(gameResult.Puzzle.StartingGrid), board) {
+		http.Error(w, "Grid changes the puzzle's clues", http.StatusBadRequest)
+		return
+	}
+
+	var conflicts *sudoku.Conflicts
+	if isVariant(&gameResult.Puzzle) {
+		conflicts, err = h.variantConflicts(r.Context(), &gameResult.Puzzle, board)
+	} else {
+		conflicts, err = h.sudokuService.FindConflicts(r.Context(), board)
+	}
+	if err != nil {
+		writeSolverError(w, err)
+		return
+	}
+
+	if gameResult.Mode == models.PlayMode && gameResult.CompletedAt == nil && !gameResult.UsedHints {
+		h.db.Model(&gameResult).Update("used_hints", true)
+	}
+
+	response := map[string]interface{}{
+		"mistake":    len(conflicts.Duplicates) > 0 || len(conflicts.Dead) > 0 || !conflicts.Solvable,
+		"duplicates": conflicts.Duplicates,
+		"dead":       conflicts.Dead,
+		"solvable":   conflicts.Solvable,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CheckCells lists the cells the player filled that don't match the puzzle's solution, for a
+// "show mistakes" toggle. The correct values are never returned. In Play mode a check counts as a hint.
+func (h *GameHandler) CheckCells(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GameResultID uint   `json:"game_result_id"`
+		CurrentGrid  string `json:"current_grid"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	start := sudoku.StringToBoard(gameResult.Puzzle.StartingGrid)
+	if !sudoku.KeepsGivens(start, board) {
+		http.Error(w, "Grid changes the puzzle's clues", http.StatusBadRequest)
+		return
+	}
+
+	diff := sudoku.DiffBoards(start, board, sudoku.StringToBoard(gameResult.Puzzle.Solution), true)
+
+	if gameResult.Mode == models.PlayMode && gameResult.CompletedAt == nil && !gameResult.UsedHints {
+		h.db.Model(&gameResult).Update("used_hints", true)
+	}
+
+	incorrect := diff.IncorrectCells
+	if incorrect == nil {
+		incorrect = []sudoku.Cell{}
+	}
+	response := map[string]interface{}{
+		"incorrect": incorrect,
+		"filled":    diff.Filled,
+		"empty":     diff.Empty,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *GameHandler) GetGameDiff(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Spectators only get counts, the owner and their coaches also get the cell positions
+	detailed := gameResult.UserID == userID || hasCoachAccess(h.db, userID, gameResult.UserID)
+
+	diff := sudoku.DiffBoards(
+		sudoku.StringToBoard(gameResult.Puzzle.StartingGrid),
+		sudoku.StringToBoard(gameResult.FinalGrid),
+		sudoku.StringToBoard(gameResult.Puzzle.Solution),
+		detailed,
+	)
+
+	response := map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"detailed":       detailed,
+		"diff":           diff,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (h *GameHandler) GetGameHistory(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(auth.UserIDKey).(uint)
 	limitStr := r.URL.Query().Get("limit")
@@ -354,7 +1227,7 @@ func (h *GameHandler) GetGameHistory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var gameResults []models.GameResult
-	if err := h.db.Preload("Puzzle").Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&gameResults).Error; err != nil {
+	if err := h.db.WithContext(r.Context()).Preload("Puzzle", models.WithDeleted).Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&gameResults).Error; err != nil {
 		http.Error(w, "Failed to fetch game history", http.StatusInternalServerError)
 		return
 	}
@@ -371,35 +1244,14 @@ func (h *GameHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 		sortBy = "score"
 	}
 
-	var query *gorm.DB
-	if difficulty != "" {
-		query = h.db.Table("game_results").
-			Select("users.username, game_results.score, game_results.time_seconds, game_results.completed_at, puzzles.difficulty").
-			Joins("JOIN users ON game_results.user_id = users.id").
-			Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").
-			Where("game_results.mode = ? AND puzzles.difficulty = ? AND game_results.completed = ? AND game_results.disqualified = ?", models.PlayMode, difficulty, true, false)
-	} else {
-		query = h.db.Table("game_results").
-			Select("users.username, game_results.score, game_results.time_seconds, game_results.completed_at, puzzles.difficulty").
-			Joins("JOIN users ON game_results.user_id = users.id").
-			Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").
-			Where("game_results.mode = ? AND game_results.completed = ? AND game_results.disqualified = ?", models.PlayMode, true, false)
-	}
-
-	var results []map[string]interface{}
-	if sortBy == "time" {
-		query.Order("game_results.time_seconds ASC").Limit(10).Find(&results)
-	} else {
-		query.Order("game_results.score DESC").Limit(10).Find(&results)
+	results, err := h.leaderboardService.WithContext(r.Context()).Top(leaderboard.Filter{Difficulty: difficulty}, sortBy)
+	if err != nil {
+		http.Error(w, "Failed to fetch leaderboard", http.StatusInternalServerError)
+		return
 	}
 
 	// Debug logging
 	log.Printf("Leaderboard query returned %d results for difficulty=%s, sortBy=%s", len(results), difficulty, sortBy)
-	
-	// Always return an array, even if empty
-	if results == nil {
-		results = []map[string]interface{}{}
-	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
@@ -411,6 +1263,7 @@ func (h *GameHandler) GetAllCompletedGames(w http.ResponseWriter, r *http.Reques
 		Select("users.username, game_results.score, game_results.time_seconds, game_results.completed, game_results.disqualified, game_results.mode, game_results.created_at, puzzles.difficulty").
 		Joins("JOIN users ON game_results.user_id = users.id").
 		Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").
+		Scopes(models.Active("game_results", "users", "puzzles")).
 		Order("game_results.created_at DESC").
 		Limit(20).
 		Find(&results)
@@ -430,8 +1283,8 @@ func (h *GameHandler) GetAllCompletedGames(w http.ResponseWriter, r *http.Reques
 func (h *GameHandler) CreateDummyLeaderboardData(w http.ResponseWriter, r *http.Request) {
 	// Check if we already have some completed games
 	var count int64
-	h.db.Table("game_results").Where("completed = ? AND disqualified = ? AND mode = ?", true, false, models.PlayMode).Count(&count)
-	
+	h.db.Model(&models.GameResult{}).Where("completed = ? AND disqualified = ? AND mode = ?", true, false, models.PlayMode).Count(&count)
+
 	if count > 0 {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"message": "Dummy data already exists"})
@@ -451,6 +1304,7 @@ func (h *GameHandler) CreateDummyLeaderboardData(w http.ResponseWriter, r *http.
 		var existingUser models.User
 		if err := h.db.Where("username = ?", user.Username).First(&existingUser).Error; err != nil {
 			// User doesn't exist, create it
+			user.EmailHash = vault.Index(user.Email)
 			h.db.Create(&user)
 		}
 	}
@@ -475,6 +1329,7 @@ func (h *GameHandler) CreateDummyLeaderboardData(w http.ResponseWriter, r *http.
 	}
 
 	for _, puzzle := range dummyPuzzles {
+		puzzle.CanonicalHash = sudoku.CanonicalHash(sudoku.StringToBoard(puzzle.StartingGrid))
 		h.db.Create(&puzzle)
 	}
 
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/goals"
+	"sudoku/internal/locale"
+	"sudoku/internal/models"
+)
+
+type GoalHandler struct {
+	goalService *goals.Service
+}
+
+type GoalRequest struct {
+	Kind       models.GoalKind   `json:"kind"`             // "time" or "count"
+	Difficulty models.Difficulty `json:"difficulty"`       // Required for time goals, optional for count goals
+	Target     int               `json:"target"`           // Seconds for a time goal, puzzles for a count goal
+	Period     models.GoalPeriod `json:"period,omitempty"` // "daily" or "weekly" (default) for count goals
+}
+
+func NewGoalHandler(goalService *goals.Service) *GoalHandler {
+	return &GoalHandler{goalService: goalService}
+}
+
+// GetGoals lists the user's goals with their progress. Days and weeks follow the user's timezone.
+func (h *GoalHandler) GetGoals(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	progress, err := h.goalService.List(userID, time.Now(), locale.Location(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to fetch goals", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+func (h *GoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	var req GoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+	goal := models.Goal{UserID: userID, Kind: req.Kind, Difficulty: req.Difficulty, Target: req.Target, Period: req.Period}
+	if err := goals.Validate(&goal); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err := h.goalService.Create(&goal)
+	if errors.Is(err, goals.ErrTooManyGoals) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create goal", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(goal)
+}
+
+func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+	err := h.goalService.Delete(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete goal", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Record the goals a scored game completes and add them to the submit response
+func (h *GameHandler) checkGoals(ctx context.Context, gameResult *models.GameResult, response map[string]interface{}) {
+	if !gameResult.Completed || gameResult.Mode != models.PlayMode || gameResult.Disqualified || gameResult.Practice {
+		return
+	}
+	met, err := h.goalService.Check(gameResult.UserID, *gameResult.CompletedAt, locale.Location(ctx))
+	if err != nil {
+		log.Printf("Failed to check goals for game %d: %v", gameResult.ID, err)
+	}
+	if len(met) > 0 {
+		response["goals_met"] = met
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+)
+
+type LeaderboardHandler struct {
+	leaderboardService *leaderboard.Service
+}
+
+func NewLeaderboardHandler(leaderboardService *leaderboard.Service) *LeaderboardHandler {
+	return &LeaderboardHandler{leaderboardService: leaderboardService}
+}
+
+func (h *LeaderboardHandler) GetArchive(w http.ResponseWriter, r *http.Request) {
+	period := models.SnapshotPeriod(r.URL.Query().Get("period"))
+	if period == "" {
+		period = models.DailySnapshot
+	}
+	difficulty := r.URL.Query().Get("difficulty")
+	sortBy := r.URL.Query().Get("type")
+	if sortBy == "" {
+		sortBy = "score"
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", r.URL.Query().Get("date"), time.Local)
+	if err != nil {
+		http.Error(w, "date must be formatted as YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.leaderboardService.WithContext(r.Context()).Archived(period, date, difficulty, sortBy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
+
+func (h *LeaderboardHandler) GetArchivePeriods(w http.ResponseWriter, r *http.Request) {
+	period := models.SnapshotPeriod(r.URL.Query().Get("period"))
+	if period == "" {
+		period = models.DailySnapshot
+	}
+
+	starts, err := h.leaderboardService.WithContext(r.Context()).ArchivedPeriods(period)
+	if err != nil {
+		http.Error(w, "Failed to fetch archived periods", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(starts)
+}
+
+// GetRegional returns the live board of one region, by default the one serving the request
+func (h *LeaderboardHandler) GetRegional(w http.ResponseWriter, r *http.Request) {
+	region := models.Region(r.URL.Query().Get("region"))
+	if region == "" {
+		region = requestRegion(r)
+	}
+	if !models.ValidRegion(region) {
+		http.Error(w, "Invalid region", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.leaderboardService.WithContext(r.Context()).Regional(region, r.URL.Query().Get("difficulty"), r.URL.Query().Get("type"))
+	if err != nil {
+		http.Error(w, "Failed to fetch leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetMerged returns the live global board merged from the regional ones
+func (h *LeaderboardHandler) GetMerged(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.leaderboardService.WithContext(r.Context()).Merged(r.URL.Query().Get("difficulty"), r.URL.Query().Get("type"))
+	if err != nil {
+		http.Error(w, "Failed to fetch leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetGlobal ranks players by their ranking points across all their scored games
+func (h *LeaderboardHandler) GetGlobal(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.leaderboardService.WithContext(r.Context()).Global()
+	if err != nil {
+		http.Error(w, "Failed to fetch leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
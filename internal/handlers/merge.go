@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/merge"
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+)
+
+type MergeHandler struct {
+	mergeService *merge.Service
+	pushService  *push.Service
+}
+
+type ProposeMergeRequest struct {
+	SourceUserID uint `json:"source_user_id"` // Duplicate account, deleted once merged
+	TargetUserID uint `json:"target_user_id"` // Account that is kept
+}
+
+func NewMergeHandler(mergeService *merge.Service, pushService *push.Service) *MergeHandler {
+	return &MergeHandler{mergeService: mergeService, pushService: pushService}
+}
+
+// ProposeMerge lets an admin propose folding a duplicate account into another.
+// Nothing changes until the holders of both accounts confirm.
+func (h *MergeHandler) ProposeMerge(w http.ResponseWriter, r *http.Request) {
+	var req ProposeMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	adminID := r.Context().Value(auth.UserIDKey).(uint)
+
+	proposed, err := h.mergeService.Propose(req.SourceUserID, req.TargetUserID, adminID)
+	if errors.Is(err, merge.ErrSameAccount) {
+		http.Error(w, "Source and target must be different accounts", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, merge.ErrAlreadyActive) {
+		http.Error(w, "One of the accounts already has a pending merge", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to propose merge", http.StatusInternalServerError)
+		return
+	}
+
+	for _, userID := range []uint{proposed.SourceUserID, proposed.TargetUserID} {
+		if err := h.pushService.Enqueue(userID, "Confirm account merge", "An admin proposed merging two of your accounts. Confirm it from both accounts to combine them."); err != nil {
+			log.Printf("Failed to queue merge %d notification for user %d: %v", proposed.ID, userID, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(proposed)
+}
+
+// GetPendingMerges lists the merges waiting on the user's confirmation
+func (h *MergeHandler) GetPendingMerges(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	merges, err := h.mergeService.Pending(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch merges", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(merges)
+}
+
+// ConfirmMerge records the user's consent; the second confirmation runs the merge
+func (h *MergeHandler) ConfirmMerge(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.mergeService.Confirm)
+}
+
+// DeclineMerge cancels a pending merge
+func (h *MergeHandler) DeclineMerge(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.mergeService.Decline)
+}
+
+func (h *MergeHandler) respond(w http.ResponseWriter, r *http.Request, action func(mergeID, userID uint) (*models.AccountMerge, error)) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	mergeID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid merge id", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := action(mergeID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, merge.ErrNotInvolved) {
+		http.Error(w, "Merge not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, merge.ErrNotPending) {
+		http.Error(w, "Merge is no longer pending", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update merge", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/moderation"
+)
+
+type ModerationHandler struct {
+	db                *gorm.DB
+	authService       *auth.Service
+	moderationService *moderation.Service
+}
+
+type ModerationTermRequest struct {
+	Term    string `json:"term"`
+	Allowed bool   `json:"allowed"`
+}
+
+type RenameUserRequest struct {
+	Username string `json:"username"`
+	Override bool   `json:"override"` // skip the blocklist check, format rules still apply
+}
+
+func NewModerationHandler(db *gorm.DB, authService *auth.Service, moderationService *moderation.Service) *ModerationHandler {
+	return &ModerationHandler{
+		db:                db,
+		authService:       authService,
+		moderationService: moderationService,
+	}
+}
+
+func (h *ModerationHandler) GetTerms(w http.ResponseWriter, r *http.Request) {
+	var terms []models.ModerationTerm
+	if err := h.db.Order("term ASC").Find(&terms).Error; err != nil {
+		http.Error(w, "Failed to fetch moderation terms", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(terms)
+}
+
+func (h *ModerationHandler) AddTerm(w http.ResponseWriter, r *http.Request) {
+	var req ModerationTermRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if moderation.NormalizeTerm(req.Term) == "" {
+		http.Error(w, "Term must contain letters", http.StatusBadRequest)
+		return
+	}
+
+	term := models.ModerationTerm{Term: req.Term, Allowed: req.Allowed}
+	if err := h.db.Create(&term).Error; err != nil {
+		http.Error(w, "Failed to save moderation term", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(term)
+}
+
+func (h *ModerationHandler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	result := h.db.Delete(&models.ModerationTerm{}, id)
+	if result.Error != nil {
+		http.Error(w, "Failed to delete moderation term", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": "Deleted", "id": id})
+}
+
+// RenameUser lets an admin replace an offensive or impersonating username
+func (h *ModerationHandler) RenameUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req RenameUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.moderationService.ValidateUsername(req.Username)
+	if errors.Is(err, moderation.ErrBlockedContent) && req.Override {
+		err = nil
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.authService.GetUserByID(id); err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	user, err := h.authService.Rename(id, req.Username)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
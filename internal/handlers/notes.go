@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Body size limit for saved notes; a full grid of candidates is well under it
+const maxNotesSize = 16 << 10
+
+// ownedGame loads the game named in the URL if it belongs to the user
+func (h *GameHandler) ownedGame(w http.ResponseWriter, r *http.Request) (*models.GameResult, bool) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return nil, false
+	}
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return &gameResult, true
+}
+
+// GetNotes returns the pencil marks saved for a game, 81 lists of candidates
+func (h *GameHandler) GetNotes(w http.ResponseWriter, r *http.Request) {
+	gameResult, ok := h.ownedGame(w, r)
+	if !ok {
+		return
+	}
+
+	notes := gameResult.Notes
+	if len(notes) == 0 {
+		notes = make(models.PencilMarks, 81)
+	}
+	for i := range notes {
+		if notes[i] == nil {
+			notes[i] = []int{}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"notes":          notes,
+	})
+}
+
+// SaveNotes replaces the pencil marks of an unfinished game, so they survive reloads and
+// switching devices. An empty list clears them.
+func (h *GameHandler) SaveNotes(w http.ResponseWriter, r *http.Request) {
+	gameResult, ok := h.ownedGame(w, r)
+	if !ok {
+		return
+	}
+	if gameResult.CompletedAt != nil || gameResult.Skipped || gameResult.Expired {
+		http.Error(w, "Game is already finished", http.StatusConflict)
+		return
+	}
+
+	var req struct {
+		Notes models.PencilMarks `json:"notes"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxNotesSize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	notes, ok := cleanNotes(req.Notes)
+	if !ok {
+		http.Error(w, "Notes must list the candidates (1-9) of all 81 cells, row by row", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.Model(gameResult).Select("notes").Updates(&models.GameResult{Notes: notes}).Error; err != nil {
+		http.Error(w, "Failed to save notes", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCandidates computes the pencil marks of every cell of the current grid with the solver's
+// candidate logic, in the shape saved by SaveNotes, for a "fill notes" button
+func (h *GameHandler) GetCandidates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		GameResultID uint   `json:"game_result_id"`
+		CurrentGrid  string `json:"current_grid"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.CurrentGrid) != 81 {
+		http.Error(w, "Current grid must have 81 cells", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, req.GameResultID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	board, err := sudoku.ParseBoard(req.CurrentGrid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var candidates sudoku.CandidateGrid
+	if isVariant(&gameResult.Puzzle) {
+		if candidates, err = h.variantCandidates(&gameResult.Puzzle, board); err != nil {
+			http.Error(w, "Failed to read the puzzle's layout", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		candidates = h.sudokuService.ComputeCandidates(board)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"candidates":     candidates.Lists(),
+	})
+}
+
+// Check that notes cover the board with digits 1-9, returning them sorted without repeats.
+// Empty notes come back as nil to clear the column.
+func cleanNotes(notes models.PencilMarks) (models.PencilMarks, bool) {
+	if len(notes) == 0 {
+		return nil, true
+	}
+	if len(notes) != 81 {
+		return nil, false
+	}
+
+	cleaned := make(models.PencilMarks, 81)
+	for i, cell := range notes {
+		var seen [10]bool
+		cleaned[i] = []int{}
+		for _, value := range cell {
+			if value < 1 || value > 9 {
+				return nil, false
+			}
+			if !seen[value] {
+				seen[value] = true
+				cleaned[i] = append(cleaned[i], value)
+			}
+		}
+		sort.Ints(cleaned[i])
+	}
+	return cleaned, true
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Mini-boards of the placement quiz, easiest first. Each is a generated puzzle of the
+// difficulty with all but Blanks cells filled in.
+var onboardingLevels = []struct {
+	Difficulty       models.Difficulty
+	Blanks           int
+	TimeLimitSeconds int
+}{
+	{models.Easy, 12, 120},
+	{models.Medium, 20, 240},
+	{models.Hard, 28, 360},
+}
+
+type OnboardingHandler struct {
+	db            *gorm.DB
+	sudokuService *sudoku.Service
+}
+
+type OnboardingSubmitRequest struct {
+	Grid string `json:"grid"`
+}
+
+func NewOnboardingHandler(db *gorm.DB, sudokuService *sudoku.Service) *OnboardingHandler {
+	return &OnboardingHandler{
+		db:            db,
+		sudokuService: sudokuService,
+	}
+}
+
+// StartOnboarding creates a placement quiz and hands out its first board
+func (h *OnboardingHandler) StartOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	now := time.Now()
+	quiz := models.OnboardingQuiz{UserID: userID}
+	for position, level := range onboardingLevels {
+		puzzle, solution, err := h.sudokuService.GeneratePuzzle(level.Difficulty, sudoku.GenerateOptions{})
+		if err != nil {
+			http.Error(w, "Failed to generate quiz", http.StatusInternalServerError)
+			return
+		}
+		board := models.OnboardingBoard{
+			Position:         position,
+			Difficulty:       level.Difficulty,
+			StartingGrid:     sudoku.BoardToString(sudoku.MiniBoard(puzzle, solution, level.Blanks)),
+			Solution:         sudoku.BoardToString(solution),
+			TimeLimitSeconds: level.TimeLimitSeconds,
+		}
+		if position == 0 {
+			board.IssuedAt = &now
+		}
+		quiz.Boards = append(quiz.Boards, board)
+	}
+
+	if err := h.db.Create(&quiz).Error; err != nil {
+		http.Error(w, "Failed to save quiz", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"quiz_id":      quiz.ID,
+		"total_boards": len(quiz.Boards),
+		"board":        quiz.Boards[0],
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SubmitOnboarding grades the current board. A solved board within its time limit issues
+// the next one; a miss or the last board ends the quiz and stores the placement on the profile.
+func (h *OnboardingHandler) SubmitOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	quizID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid quiz id", http.StatusBadRequest)
+		return
+	}
+
+	var req OnboardingSubmitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	grid, err := sudoku.ParseBoard(req.Grid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var quiz models.OnboardingQuiz
+	err = h.db.Preload("Boards", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		First(&quiz, quizID).Error
+	if err != nil {
+		http.Error(w, "Quiz not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if quiz.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if quiz.CompletedAt != nil {
+		http.Error(w, "Quiz is already finished", http.StatusConflict)
+		return
+	}
+
+	current := 0
+	for current < len(quiz.Boards) && quiz.Boards[current].SubmittedAt != nil {
+		current++
+	}
+	board := &quiz.Boards[current]
+
+	now := time.Now()
+	board.SubmittedGrid = req.Grid
+	board.SubmittedAt = &now
+	board.TimeSeconds = int(now.Sub(*board.IssuedAt).Seconds())
+	board.Passed = sudoku.IsSolved(grid, sudoku.StringToBoard(board.Solution)) && board.TimeSeconds <= board.TimeLimitSeconds
+
+	var next *models.OnboardingBoard
+	if board.Passed && current+1 < len(quiz.Boards) {
+		next = &quiz.Boards[current+1]
+		next.IssuedAt = &now
+	} else {
+		h.place(&quiz)
+		quiz.CompletedAt = &now
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(board).Error; err != nil {
+			return err
+		}
+		if next != nil {
+			return tx.Save(next).Error
+		}
+		if err := tx.Save(&quiz).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"starting_difficulty": quiz.PlacedDifficulty,
+			"recommended_lesson":  quiz.RecommendedLesson,
+		}).Error
+	})
+	if err != nil {
+		http.Error(w, "Failed to save quiz", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"passed":       board.Passed,
+		"time_seconds": board.TimeSeconds,
+		"next_board":   next,
+	}
+	if quiz.CompletedAt != nil {
+		response["placed_difficulty"] = quiz.PlacedDifficulty
+		response["recommended_lesson"] = quiz.RecommendedLesson
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Place the user at the hardest board passed and recommend the technique behind the
+// first board missed, or the one after everything the quiz covered
+func (h *OnboardingHandler) place(quiz *models.OnboardingQuiz) {
+	quiz.PlacedDifficulty = models.Easy
+	for _, board := range quiz.Boards {
+		if board.SubmittedAt == nil {
+			break
+		}
+		hardest, _ := h.sudokuService.HardestTechnique(sudoku.StringToBoard(board.StartingGrid))
+		if !board.Passed {
+			if hardest == "" {
+				hardest = "Naked Single"
+			}
+			quiz.RecommendedLesson = hardest
+			return
+		}
+		quiz.PlacedDifficulty = board.Difficulty
+		quiz.RecommendedLesson = sudoku.NextTechnique(hardest)
+	}
+}
+
+// GetOnboarding returns the user's latest placement quiz
+func (h *OnboardingHandler) GetOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var quiz models.OnboardingQuiz
+	err := h.db.Preload("Boards", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&quiz).Error
+	if err != nil {
+		http.Error(w, "No onboarding quiz taken", http.StatusNotFound)
+		return
+	}
+
+	// Only show boards that have been handed out
+	var issued []models.OnboardingBoard
+	for _, board := range quiz.Boards {
+		if board.IssuedAt != nil {
+			issued = append(issued, board)
+		}
+	}
+	quiz.Boards = issued
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quiz)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/ocr"
+	"sudoku/internal/sudoku"
+)
+
+// Largest photo accepted for a paper solve
+const maxPhotoSize = 10 << 20
+
+type PaperHandler struct {
+	db     *gorm.DB
+	reader ocr.Reader
+}
+
+// NewPaperHandler creates the handler; with a nil reader photos can't be checked
+func NewPaperHandler(db *gorm.DB, reader ocr.Reader) *PaperHandler {
+	return &PaperHandler{db: db, reader: reader}
+}
+
+// PaperResult is the grade of a photographed paper solve
+type PaperResult struct {
+	GameResultID uint          `json:"game_result_id"`
+	Correct      bool          `json:"correct"`
+	Grid         string        `json:"grid"`       // Digits read from the photo, 0 for unreadable or empty cells
+	Incorrect    []sudoku.Cell `json:"incorrect"`  // Cells read with a digit other than the solution's
+	Unreadable   []sudoku.Cell `json:"unreadable"` // Cells left empty or that couldn't be read
+}
+
+// SubmitPaper grades a photo of a printed puzzle, sent as the "photo" field of a multipart
+// form, against the puzzle's solution and records it in the user's history as a paper solve
+func (h *PaperHandler) SubmitPaper(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+	if h.reader == nil {
+		http.Error(w, "Photo checking is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	puzzleID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid puzzle id", http.StatusBadRequest)
+		return
+	}
+	var puzzle models.Puzzle
+	if err := h.db.First(&puzzle, puzzleID).Error; err != nil {
+		http.Error(w, "Puzzle not found", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoSize)
+	file, header, err := r.FormFile("photo")
+	if err != nil {
+		http.Error(w, "A photo of the grid is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	contentType := header.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		http.Error(w, "The photo must be an image", http.StatusBadRequest)
+		return
+	}
+	photo, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read photo", http.StatusBadRequest)
+		return
+	}
+
+	grid, err := h.reader.ReadGrid(r.Context(), photo, contentType)
+	if errors.Is(err, ocr.ErrNoGrid) {
+		http.Error(w, "No grid found in the photo", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to read paper solve of puzzle %d: %v", puzzle.ID, err)
+		http.Error(w, "Failed to read the photo", http.StatusBadGateway)
+		return
+	}
+
+	result := gradePaper(grid, puzzle.Solution)
+	gameResult, err := h.recordPaper(userID, &puzzle, grid, result.Correct)
+	if err != nil {
+		http.Error(w, "Failed to record paper solve", http.StatusInternalServerError)
+		return
+	}
+	result.GameResultID = gameResult.ID
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// Compare the grid read from a photo with the solution cell by cell
+func gradePaper(grid, solution string) PaperResult {
+	result := PaperResult{Grid: grid, Incorrect: []sudoku.Cell{}, Unreadable: []sudoku.Cell{}}
+	for pos := 0; pos < 81; pos++ {
+		cell := sudoku.Cell{Row: pos / 9, Col: pos % 9}
+		switch {
+		case grid[pos] == '0':
+			result.Unreadable = append(result.Unreadable, cell)
+		case grid[pos] != solution[pos]:
+			result.Incorrect = append(result.Incorrect, cell)
+		}
+	}
+	result.Correct = len(result.Incorrect) == 0 && len(result.Unreadable) == 0
+	return result
+}
+
+// Save a paper solve as a finished game. Paper solves aren't timed, so like practice games they
+// are kept off the leaderboards and the user's totals.
+func (h *PaperHandler) recordPaper(userID uint, puzzle *models.Puzzle, grid string, correct bool) (*models.GameResult, error) {
+	now := time.Now()
+	gameResult := &models.GameResult{
+		Attempt:   1,
+		UserID:    userID,
+		PuzzleID:  puzzle.ID,
+		Mode:      models.PlayMode,
+		Completed: correct,
+		Practice:  true,
+		Paper:     true,
+		FinalGrid: grid,
+		StartedAt: now,
+	}
+	if correct {
+		gameResult.CompletedAt = &now
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		game := models.Game{UserID: userID, PuzzleID: puzzle.ID, Mode: models.PlayMode, Attempts: 1}
+		if err := tx.Create(&game).Error; err != nil {
+			return err
+		}
+		gameResult.GameID = game.ID
+		return tx.Create(gameResult).Error
+	})
+	return gameResult, err
+}
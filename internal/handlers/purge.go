@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+	"sudoku/internal/stats"
+)
+
+// PurgeRequest selects the leaderboard entries to void. Zero values mean no restriction,
+// but at least one criterion is required.
+type PurgeRequest struct {
+	UserID   uint      `json:"user_id"`
+	PuzzleID uint      `json:"puzzle_id"`
+	From     time.Time `json:"from"` // Completed at or after
+	To       time.Time `json:"to"`   // Completed before
+}
+
+// PurgeLeaderboard voids every scored result matching the criteria, then recomputes the
+// totals of the affected users and rebuilds the archived boards of the affected periods,
+// all in one transaction. Used to clean up after cheating incidents or scoring bugs.
+func (h *AdminHandler) PurgeLeaderboard(w http.ResponseWriter, r *http.Request) {
+	var req PurgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 && req.PuzzleID == 0 && req.From.IsZero() && req.To.IsZero() {
+		http.Error(w, "At least one of user_id, puzzle_id, from or to is required", http.StatusBadRequest)
+		return
+	}
+
+	var voided, users, snapshots int
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.GameResult{}).
+			Where("mode = ? AND completed = ? AND voided = ?", models.PlayMode, true, false)
+		if req.UserID != 0 {
+			query = query.Where("user_id = ?", req.UserID)
+		}
+		if req.PuzzleID != 0 {
+			query = query.Where("puzzle_id = ?", req.PuzzleID)
+		}
+		if !req.From.IsZero() {
+			query = query.Where("completed_at >= ?", req.From)
+		}
+		if !req.To.IsZero() {
+			query = query.Where("completed_at < ?", req.To)
+		}
+
+		var results []models.GameResult
+		if err := query.Select("id", "user_id", "completed_at").Find(&results).Error; err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(results))
+		affectedUsers := make(map[uint]bool)
+		type periodKey struct {
+			period models.SnapshotPeriod
+			start  time.Time
+		}
+		affectedPeriods := make(map[periodKey]bool)
+		for _, result := range results {
+			ids = append(ids, result.ID)
+			affectedUsers[result.UserID] = true
+			if result.CompletedAt != nil {
+				// Periods are archived in server time, see SnapshotDue
+				completed := result.CompletedAt.In(time.Local)
+				for _, period := range []models.SnapshotPeriod{models.DailySnapshot, models.WeeklySnapshot} {
+					affectedPeriods[periodKey{period, leaderboard.PeriodStart(period, completed)}] = true
+				}
+			}
+		}
+
+		err := tx.Model(&models.GameResult{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"voided": true, "under_review": false}).Error
+		if err != nil {
+			return err
+		}
+		voided = len(ids)
+
+		for userID := range affectedUsers {
+			if err := stats.RecomputeUser(tx, userID); err != nil {
+				return err
+			}
+		}
+		users = len(affectedUsers)
+
+		archive := leaderboard.NewService(tx)
+		for key := range affectedPeriods {
+			rebuilt, err := archive.Rebuild(key.period, key.start)
+			if err != nil {
+				return err
+			}
+			if rebuilt {
+				snapshots++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, "Failed to purge leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Leaderboard purge voided %d results, recomputed %d users and rebuilt %d archived periods", voided, users, snapshots)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"voided":    voided,
+		"users":     users,
+		"snapshots": snapshots,
+	})
+}
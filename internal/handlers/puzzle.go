@@ -4,18 +4,78 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 
+	"sudoku/internal/auth"
 	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
 )
 
+// Number of sample puzzles shown by the difficulty preview
+const previewSize = 3
+
 type PuzzleHandler struct {
-	db *gorm.DB
+	db            *gorm.DB
+	sudokuService *sudoku.Service
+}
+
+func NewPuzzleHandler(db *gorm.DB, sudokuService *sudoku.Service) *PuzzleHandler {
+	return &PuzzleHandler{db: db, sudokuService: sudokuService}
 }
 
-func NewPuzzleHandler(db *gorm.DB) *PuzzleHandler {
-	return &PuzzleHandler{db: db}
+// PuzzlePreview is a sample starting grid of a difficulty with the grader's breakdown of it
+type PuzzlePreview struct {
+	StartingGrid string        `json:"starting_grid"`
+	Grade        *sudoku.Grade `json:"grade"`
+}
+
+// GetPreview shows what a difficulty means: a few random puzzles rated at it, without their
+// solutions, and what solving each takes. Puzzles are generated when none are saved yet.
+func (h *PuzzleHandler) GetPreview(w http.ResponseWriter, r *http.Request) {
+	difficulty := models.Difficulty(r.URL.Query().Get("difficulty"))
+	switch difficulty {
+	case models.Easy, models.Medium, models.Hard, models.Expert:
+	default:
+		http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
+		return
+	}
+
+	var grids []string
+	err := h.db.WithContext(r.Context()).Model(&models.Puzzle{}).
+		Where("rating = ? AND variant = ? AND user_submitted = ?", difficulty, models.Classic, false).
+		Order("RANDOM()").
+		Limit(previewSize).
+		Pluck("starting_grid", &grids).Error
+	if err != nil {
+		http.Error(w, "Failed to fetch puzzles", http.StatusInternalServerError)
+		return
+	}
+	if len(grids) == 0 {
+		puzzle, _, err := h.sudokuService.GeneratePuzzle(difficulty, sudoku.GenerateOptions{})
+		if err != nil {
+			http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+			return
+		}
+		grids = append(grids, sudoku.BoardToString(puzzle))
+	}
+
+	previews := make([]PuzzlePreview, 0, len(grids))
+	for _, grid := range grids {
+		grade, err := h.sudokuService.GradePuzzle(r.Context(), sudoku.StringToBoard(grid))
+		if err != nil {
+			writeSolverError(w, err)
+			return
+		}
+		previews = append(previews, PuzzlePreview{StartingGrid: grid, Grade: grade})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"difficulty": difficulty,
+		"puzzles":    previews,
+	})
 }
 
 func (h *PuzzleHandler) GetPuzzles(w http.ResponseWriter, r *http.Request) {
@@ -29,12 +89,12 @@ func (h *PuzzleHandler) GetPuzzles(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	query := h.db.Model(&models.Puzzle{})
+	query := h.db.Model(&models.Puzzle{}).Where("user_submitted = ?", false)
 
 	if difficulty != "" {
 		// Validate difficulty
 		switch models.Difficulty(difficulty) {
-		case models.Easy, models.Medium, models.Hard:
+		case models.Easy, models.Medium, models.Hard, models.Expert:
 			query = query.Where("difficulty = ?", difficulty)
 		default:
 			http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
@@ -42,6 +102,16 @@ func (h *PuzzleHandler) GetPuzzles(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if rating := r.URL.Query().Get("rating"); rating != "" {
+		switch models.Difficulty(rating) {
+		case models.Easy, models.Medium, models.Hard, models.Expert:
+			query = query.Where("rating = ?", rating)
+		default:
+			http.Error(w, "Invalid rating", http.StatusBadRequest)
+			return
+		}
+	}
+
 	var puzzles []models.Puzzle
 	if err := query.Limit(limit).Find(&puzzles).Error; err != nil {
 		http.Error(w, "Failed to fetch puzzles", http.StatusInternalServerError)
@@ -51,3 +121,84 @@ func (h *PuzzleHandler) GetPuzzles(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(puzzles)
 }
+
+// PuzzleAttempt is one of the user's attempts at a puzzle
+type PuzzleAttempt struct {
+	GameResultID uint            `json:"game_result_id"`
+	GameID       uint            `json:"game_id"`
+	Attempt      int             `json:"attempt"`
+	Mode         models.GameMode `json:"mode"`
+	Completed    bool            `json:"completed"`
+	TimeSeconds  int             `json:"time_seconds"`
+	StartedAt    time.Time       `json:"started_at"`
+	CompletedAt  *time.Time      `json:"completed_at"`
+}
+
+// GetMyHistory tells the user whether and how they played a puzzle before, so clients
+// can show past solves and warn before a replay
+func (h *PuzzleHandler) GetMyHistory(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	puzzleID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid puzzle id", http.StatusBadRequest)
+		return
+	}
+
+	var puzzle models.Puzzle
+	if err := h.db.Select("id").First(&puzzle, puzzleID).Error; err != nil {
+		http.Error(w, "Puzzle not found", http.StatusNotFound)
+		return
+	}
+
+	var results []models.GameResult
+	err := h.db.WithContext(r.Context()).
+		Where("user_id = ? AND puzzle_id = ?", userID, puzzleID).
+		Order("started_at DESC").
+		Find(&results).Error
+	if err != nil {
+		http.Error(w, "Failed to fetch puzzle history", http.StatusInternalServerError)
+		return
+	}
+
+	attempts := make([]PuzzleAttempt, 0, len(results))
+	games := make(map[uint]bool)
+	var lastSolved *PuzzleAttempt
+	bestTime := 0
+	for _, result := range results {
+		attempt := PuzzleAttempt{
+			GameResultID: result.ID,
+			GameID:       result.GameID,
+			Attempt:      result.Attempt,
+			Mode:         result.Mode,
+			Completed:    result.Completed,
+			TimeSeconds:  result.TimeSeconds,
+			StartedAt:    result.StartedAt,
+			CompletedAt:  result.CompletedAt,
+		}
+		attempts = append(attempts, attempt)
+		games[result.GameID] = true
+
+		if result.Completed && !result.Voided {
+			if lastSolved == nil {
+				solved := attempt
+				lastSolved = &solved
+			}
+			if bestTime == 0 || result.TimeSeconds < bestTime {
+				bestTime = result.TimeSeconds
+			}
+		}
+	}
+
+	response := map[string]interface{}{
+		"puzzle_id":         puzzle.ID,
+		"plays":             len(games),
+		"attempts":          attempts,
+		"solved":            lastSolved != nil,
+		"last_solved":       lastSolved,
+		"best_time_seconds": bestTime,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
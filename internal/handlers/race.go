@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/pool"
+	"sudoku/internal/race"
+	"sudoku/internal/rivals"
+	"sudoku/internal/sudoku"
+)
+
+// RaceHandler serves the arcade versus mode. Races are unranked and never touch game results.
+type RaceHandler struct {
+	db            *gorm.DB
+	sudokuService *sudoku.Service
+	poolService   *pool.Service
+	rivalService  *rivals.Service
+	hub           *race.Hub
+	upgrader      websocket.Upgrader
+}
+
+type CreateRaceRequest struct {
+	Difficulty models.Difficulty `json:"difficulty"`
+	BestOf     int               `json:"best_of"` // Races in the series, 1 or left out for a single race
+}
+
+// Check the request, replying with an error when it's invalid
+func (req *CreateRaceRequest) valid(w http.ResponseWriter) bool {
+	switch req.Difficulty {
+	case models.Easy, models.Medium, models.Hard, models.Expert:
+	default:
+		http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
+		return false
+	}
+	switch req.BestOf {
+	case 0, 1, 3, 5, 7:
+	default:
+		http.Error(w, "Best of must be 1, 3, 5 or 7", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func NewRaceHandler(db *gorm.DB, sudokuService *sudoku.Service, poolService *pool.Service, rivalService *rivals.Service, hub *race.Hub, allowOrigin func(origin string) bool) *RaceHandler {
+	return &RaceHandler{
+		db:            db,
+		sudokuService: sudokuService,
+		poolService:   poolService,
+		rivalService:  rivalService,
+		hub:           hub,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" {
+					return true // Not a browser
+				}
+				return allowOrigin(origin)
+			},
+		},
+	}
+}
+
+// CreateRace opens a race on a new puzzle and waits for an opponent to join
+func (h *RaceHandler) CreateRace(w http.ResponseWriter, r *http.Request) {
+	var req CreateRaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !req.valid(w) {
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	puzzle, err := h.racePuzzle(req.Difficulty, userID)
+	if err != nil {
+		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	newRace := models.Race{PuzzleID: puzzle.ID, HostID: userID, Status: models.RaceWaiting}
+	if err := h.openRace(&newRace, req.BestOf); err != nil {
+		http.Error(w, "Failed to create race", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newRace)
+}
+
+// ChallengeRival opens a race and nudges one of the player's rivals to join it. The same rival
+// can be challenged once per cooldown.
+func (h *RaceHandler) ChallengeRival(w http.ResponseWriter, r *http.Request) {
+	var req CreateRaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !req.valid(w) {
+		return
+	}
+
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+	rivalID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.rivalService.CanNudge(userID, rivalID, time.Now())
+	switch {
+	case errors.Is(err, rivals.ErrNotRival):
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	case errors.Is(err, rivals.ErrNudgedRecently):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	case err != nil:
+		http.Error(w, "Failed to fetch rivals", http.StatusInternalServerError)
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	puzzle, err := h.racePuzzle(req.Difficulty, userID)
+	if err != nil {
+		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		return
+	}
+	newRace := models.Race{PuzzleID: puzzle.ID, HostID: userID, Status: models.RaceWaiting}
+	if err := h.openRace(&newRace, req.BestOf); err != nil {
+		http.Error(w, "Failed to create race", http.StatusInternalServerError)
+		return
+	}
+	if err := h.rivalService.Nudge(userID, user.Username, rivalID, newRace.ID); err != nil {
+		http.Error(w, "Failed to challenge rival", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newRace)
+}
+
+// Save a new race, opening a series for it when more than one race is to be played
+func (h *RaceHandler) openRace(newRace *models.Race, bestOf int) error {
+	if bestOf <= 1 {
+		return h.db.Create(newRace).Error
+	}
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		series := models.RaceSeries{BestOf: bestOf, HostID: newRace.HostID, GuestID: newRace.GuestID}
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		newRace.SeriesID = &series.ID
+		return tx.Create(newRace).Error
+	})
+}
+
+// Draw a puzzle from the pool, generating one if it has run dry
+func (h *RaceHandler) racePuzzle(difficulty models.Difficulty, userID uint) (*models.Puzzle, error) {
+	puzzle, err := h.poolService.Take(difficulty, userID)
+	if err == nil {
+		return puzzle, nil
+	}
+	if !errors.Is(err, pool.ErrEmpty) {
+		log.Printf("Failed to draw a %s puzzle from the pool: %v", difficulty, err)
+	}
+
+	puzzleBoard, solutionBoard, err := h.sudokuService.GeneratePuzzle(difficulty, sudoku.GenerateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	rating := h.sudokuService.RatePuzzle(puzzleBoard)
+	puzzle = &models.Puzzle{
+		Difficulty:         difficulty,
+		StartingGrid:       sudoku.BoardToString(puzzleBoard),
+		Solution:           sudoku.BoardToString(solutionBoard),
+		RequiresUniqueness: h.sudokuService.UsesUniqueness(puzzleBoard),
+		Rating:             rating.Difficulty,
+		HardestTechnique:   rating.HardestTechnique,
+		CanonicalHash:      sudoku.CanonicalHash(puzzleBoard),
+	}
+	if err := h.db.Create(puzzle).Error; err != nil {
+		return nil, err
+	}
+	return puzzle, nil
+}
+
+// JoinRace takes the open seat of a waiting race and starts it
+func (h *RaceHandler) JoinRace(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	raceID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid race id", http.StatusBadRequest)
+		return
+	}
+
+	var joined models.Race
+	if err := h.db.First(&joined, raceID).Error; err != nil {
+		http.Error(w, "Race not found", http.StatusNotFound)
+		return
+	}
+	if joined.HostID == userID {
+		http.Error(w, "You can't join your own race", http.StatusConflict)
+		return
+	}
+	if joined.GuestID != nil && *joined.GuestID != userID {
+		http.Error(w, "Race is reserved for another player", http.StatusConflict)
+		return
+	}
+
+	// Only one player can take the seat, which a rematch keeps for the opponent
+	now := time.Now()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Race{}).
+			Where("id = ? AND status = ? AND (guest_id IS NULL OR guest_id = ?)", raceID, models.RaceWaiting, userID).
+			Updates(map[string]interface{}{"guest_id": userID, "status": models.RaceActive, "started_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRaceTaken
+		}
+		if joined.SeriesID == nil {
+			return nil
+		}
+		return tx.Model(&models.RaceSeries{}).Where("id = ? AND guest_id IS NULL", *joined.SeriesID).Update("guest_id", userID).Error
+	})
+	if errors.Is(err, errRaceTaken) {
+		http.Error(w, "Race is no longer open", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to join race", http.StatusInternalServerError)
+		return
+	}
+
+	h.db.First(&joined, raceID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(joined)
+}
+
+// Returned by JoinRace's transaction when another player took the seat first
+var errRaceTaken = errors.New("race is no longer open")
+
+// Returned by Rematch's transaction when the other player opened the rematch first
+var errRematchTaken = errors.New("rematch already opened")
+
+// GetRace returns a race with its starting grid and its series, if it belongs to one
+func (h *RaceHandler) GetRace(w http.ResponseWriter, r *http.Request) {
+	raceID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid race id", http.StatusBadRequest)
+		return
+	}
+
+	var found models.Race
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&found, raceID).Error; err != nil {
+		http.Error(w, "Race not found", http.StatusNotFound)
+		return
+	}
+	var series *models.RaceSeries
+	if found.SeriesID != nil {
+		series = &models.RaceSeries{}
+		if err := h.db.First(series, *found.SeriesID).Error; err != nil {
+			http.Error(w, "Failed to fetch series", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"race":          found,
+		"series":        series,
+		"difficulty":    found.Puzzle.Difficulty,
+		"starting_grid": found.Puzzle.StartingGrid,
+	})
+}
+
+// GetSeries returns a best-of-N series with the result of each of its races
+func (h *RaceHandler) GetSeries(w http.ResponseWriter, r *http.Request) {
+	seriesID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid series id", http.StatusBadRequest)
+		return
+	}
+
+	var series models.RaceSeries
+	if err := h.db.First(&series, seriesID).Error; err != nil {
+		http.Error(w, "Series not found", http.StatusNotFound)
+		return
+	}
+	races := []models.Race{}
+	if err := h.db.Where("series_id = ?", series.ID).Order("id ASC").Find(&races).Error; err != nil {
+		http.Error(w, "Failed to fetch races", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"series": series,
+		"races":  races,
+	})
+}
+
+// Rematch opens a new race between the players of a finished race, on a new puzzle of the same
+// difficulty, with the seat kept for the opponent. It continues the race's series until the
+// series is decided; the rematch of a decided series starts a new one of the same length. A
+// race has one rematch: asking again returns it.
+func (h *RaceHandler) Rematch(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	raceID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid race id", http.StatusBadRequest)
+		return
+	}
+
+	var finished models.Race
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&finished, raceID).Error; err != nil {
+		http.Error(w, "Race not found", http.StatusNotFound)
+		return
+	}
+	if finished.HostID != userID && (finished.GuestID == nil || *finished.GuestID != userID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if finished.Status != models.RaceFinished {
+		http.Error(w, "Race is not finished", http.StatusConflict)
+		return
+	}
+	if finished.RematchID != nil {
+		h.writeRematch(w, *finished.RematchID)
+		return
+	}
+
+	opponentID := finished.HostID
+	if opponentID == userID {
+		opponentID = *finished.GuestID
+	}
+	var series *models.RaceSeries
+	if finished.SeriesID != nil {
+		series = &models.RaceSeries{}
+		if err := h.db.First(series, *finished.SeriesID).Error; err != nil {
+			http.Error(w, "Failed to fetch series", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	puzzle, err := h.racePuzzle(finished.Puzzle.Difficulty, userID)
+	if err != nil {
+		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	rematch := models.Race{PuzzleID: puzzle.ID, HostID: userID, GuestID: &opponentID, Status: models.RaceWaiting}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		switch {
+		case series == nil:
+		case series.WinnerID == nil:
+			rematch.SeriesID = &series.ID
+		default:
+			next := models.RaceSeries{BestOf: series.BestOf, HostID: userID, GuestID: &opponentID}
+			if err := tx.Create(&next).Error; err != nil {
+				return err
+			}
+			rematch.SeriesID = &next.ID
+		}
+		if err := tx.Create(&rematch).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Race{}).Where("id = ? AND rematch_id IS NULL", finished.ID).Update("rematch_id", rematch.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRematchTaken
+		}
+		return nil
+	})
+	if errors.Is(err, errRematchTaken) {
+		h.db.First(&finished, raceID)
+		h.writeRematch(w, *finished.RematchID)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to create rematch", http.StatusInternalServerError)
+		return
+	}
+
+	// Players still on the finished race's socket get the invitation right away
+	h.hub.Broadcast(finished.ID, race.Rematch{Type: "rematch", RaceID: finished.ID, RematchID: rematch.ID, UserID: userID})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rematch)
+}
+
+// Reply with a race opened as a rematch
+func (h *RaceHandler) writeRematch(w http.ResponseWriter, rematchID uint) {
+	var rematch models.Race
+	if err := h.db.First(&rematch, rematchID).Error; err != nil {
+		http.Error(w, "Rematch not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rematch)
+}
+
+// RaceSocket upgrades to a WebSocket carrying the race's moves, power-ups and events
+func (h *RaceHandler) RaceSocket(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	raceID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid race id", http.StatusBadRequest)
+		return
+	}
+
+	var found models.Race
+	if err := h.db.First(&found, raceID).Error; err != nil {
+		http.Error(w, "Race not found", http.StatusNotFound)
+		return
+	}
+	if found.HostID != userID && (found.GuestID == nil || *found.GuestID != userID) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if found.Status != models.RaceActive {
+		http.Error(w, "Race is not running", http.StatusConflict)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // The upgrader has already replied
+	}
+	if err := h.hub.Serve(raceID, userID, conn); err != nil {
+		log.Printf("Race %d socket for user %d failed: %v", raceID, userID, err)
+	}
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"sudoku/internal/models"
+)
+
+// RegionHeader names the region that served the request. The edge router of each region sets it,
+// so it is the region with the lowest latency to the player.
+const RegionHeader = "X-Region"
+
+// The region that served the request, empty when the header is missing or unknown
+func requestRegion(r *http.Request) models.Region {
+	region := models.Region(strings.ToLower(strings.TrimSpace(r.Header.Get(RegionHeader))))
+	if !models.ValidRegion(region) {
+		return ""
+	}
+	return region
+}
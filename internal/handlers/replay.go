@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/blob"
+	"sudoku/internal/models"
+	"sudoku/internal/replay"
+)
+
+// Largest replay upload accepted, in bytes
+const maxReplaySize = 1 << 20
+
+type ReplayHandler struct {
+	db            *gorm.DB
+	blobService   *blob.Service
+	replayService *replay.Service
+}
+
+type ReplayRequest struct {
+	Events []models.ReplayEvent `json:"events"`
+}
+
+func NewReplayHandler(db *gorm.DB, blobService *blob.Service, replayService *replay.Service) *ReplayHandler {
+	return &ReplayHandler{db: db, blobService: blobService, replayService: replayService}
+}
+
+// Load a game result owned by the user, writing the error response if it isn't found or isn't theirs
+func (h *ReplayHandler) ownedGame(w http.ResponseWriter, r *http.Request) (*models.GameResult, bool) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return nil, false
+	}
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return &gameResult, true
+}
+
+// Check that events are in time order and stay on the board
+func validReplay(events []models.ReplayEvent) bool {
+	last := 0
+	for _, event := range events {
+		if event.AtMillis < last || event.Row < 0 || event.Row > 8 || event.Col < 0 || event.Col > 8 || event.Value < 0 || event.Value > 9 {
+			return false
+		}
+		last = event.AtMillis
+	}
+	return true
+}
+
+// SaveReplay stores the move-by-move replay of a game, replacing an earlier upload.
+// The replay of a submitted game is checked right away and can't be replaced afterwards.
+func (h *ReplayHandler) SaveReplay(w http.ResponseWriter, r *http.Request) {
+	gameResult, ok := h.ownedGame(w, r)
+	if !ok {
+		return
+	}
+	if gameResult.CheckedAt != nil {
+		http.Error(w, "Replay has already been checked", http.StatusConflict)
+		return
+	}
+
+	var req ReplayRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReplaySize)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Events) == 0 || !validReplay(req.Events) {
+		http.Error(w, "Replay events must be in time order with rows, columns 0-8 and values 0-9", http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(req.Events)
+	if err != nil {
+		http.Error(w, "Failed to save replay", http.StatusInternalServerError)
+		return
+	}
+	stored, err := h.blobService.Put(models.ReplayBlob, replay.Key(gameResult.ID), "application/json", data, &gameResult.ID)
+	if err != nil {
+		http.Error(w, "Failed to save replay", http.StatusInternalServerError)
+		return
+	}
+
+	// Games submitted without a replay reach the leaderboards once it is checked
+	checked := false
+	if replay.NeedsCheck(gameResult) {
+		if _, err := h.replayService.Verify(gameResult); err != nil {
+			// The verification worker will retry
+			log.Printf("Failed to verify replay of game %d: %v", gameResult.ID, err)
+		} else {
+			checked = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"events":         len(req.Events),
+		"size":           stored.Size,
+		"expires_at":     stored.ExpiresAt,
+		"checked":        checked,
+	})
+}
+
+// GetReplay returns the stored replay events of a game
+func (h *ReplayHandler) GetReplay(w http.ResponseWriter, r *http.Request) {
+	gameResult, ok := h.ownedGame(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := h.replayService.Load(gameResult.ID)
+	if errors.Is(err, blob.ErrNotFound) {
+		http.Error(w, "Replay not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load replay", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game_result_id": gameResult.ID,
+		"events":         events,
+	})
+}
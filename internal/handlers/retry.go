@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+)
+
+// Returned inside the retry transaction when the attempt is no longer the game's latest
+var errAlreadyRetried = errors.New("game has already been retried")
+
+// RetryGame restarts the puzzle of a game graded incorrect as a fresh practice attempt.
+// The failed attempt stays in the history; the retry has its own timer and never
+// reaches the leaderboards or the user's scored totals.
+func (h *GameHandler) RetryGame(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return
+	}
+
+	var gameResult models.GameResult
+	if err := h.db.Preload("Puzzle", models.WithDeleted).First(&gameResult, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify ownership
+	if gameResult.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if gameResult.CompletedAt == nil || gameResult.Completed || gameResult.Skipped || gameResult.Voided {
+		http.Error(w, "Only games graded incorrect can be retried", http.StatusConflict)
+		return
+	}
+
+	var retry models.GameResult
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var game models.Game
+		if err := tx.First(&game, gameResult.GameID).Error; err != nil {
+			return err
+		}
+		// Only the latest attempt can be retried so a game never forks
+		if game.Attempts != gameResult.Attempt {
+			return errAlreadyRetried
+		}
+
+		game.Attempts++
+		if err := tx.Model(&game).Update("attempts", game.Attempts).Error; err != nil {
+			return err
+		}
+
+		retry = models.GameResult{
+			GameID:    game.ID,
+			Attempt:   game.Attempts,
+			UserID:    userID,
+			PuzzleID:  gameResult.PuzzleID,
+			Mode:      gameResult.Mode,
+			Practice:  true,
+			StartedAt: time.Now(),
+			FinalGrid: gameResult.Puzzle.StartingGrid,
+		}
+		return tx.Create(&retry).Error
+	})
+	if errors.Is(err, errAlreadyRetried) {
+		http.Error(w, "Game has already been retried", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to retry game", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"game_result_id": retry.ID,
+		"attempt":        retry.Attempt,
+		"puzzle":         gameResult.Puzzle,
+		"started_at":     retry.StartedAt,
+		"practice":       retry.Practice,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
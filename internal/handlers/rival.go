@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/rivals"
+)
+
+type RivalHandler struct {
+	rivalService *rivals.Service
+}
+
+func NewRivalHandler(rivalService *rivals.Service) *RivalHandler {
+	return &RivalHandler{rivalService: rivalService}
+}
+
+// GetRivals lists the users the player often races or places next to on the weekly boards,
+// with their head-to-head record and both players' stats over the same window
+func (h *RivalHandler) GetRivals(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	list, err := h.rivalService.List(userID, time.Now())
+	if err != nil {
+		http.Error(w, "Failed to fetch rivals", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
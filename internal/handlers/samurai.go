@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// SamuraiHandler serves the Samurai mode: five overlapping grids on one 21x21 board. Samurai
+// games keep their own puzzle and progress and never touch game results or leaderboards.
+type SamuraiHandler struct {
+	db            *gorm.DB
+	sudokuService *sudoku.Service
+}
+
+type StartSamuraiRequest struct {
+	Difficulty models.Difficulty `json:"difficulty"`
+	Symmetry   models.Symmetry   `json:"symmetry,omitempty"`
+}
+
+type SamuraiGridRequest struct {
+	Grid        string `json:"grid"`         // 441 characters, see sudoku.ParseSamurai
+	TimeSeconds int    `json:"time_seconds"` // Saved with the grid on save and submit
+}
+
+type SamuraiHintRequest struct {
+	Grid string `json:"grid"`
+	Row  *int   `json:"row,omitempty"` // Cell to reveal, the first empty one when unset
+	Col  *int   `json:"col,omitempty"`
+}
+
+func NewSamuraiHandler(db *gorm.DB, sudokuService *sudoku.Service) *SamuraiHandler {
+	return &SamuraiHandler{db: db, sudokuService: sudokuService}
+}
+
+// StartSamurai generates a Samurai puzzle and opens a game on it
+func (h *SamuraiHandler) StartSamurai(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var req StartSamuraiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	switch req.Difficulty {
+	case models.Easy, models.Medium, models.Hard, models.Expert:
+	default:
+		http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
+		return
+	}
+	switch req.Symmetry {
+	case "", models.NoSymmetry, models.RotationalSymmetry, models.MirrorSymmetry:
+	default:
+		http.Error(w, "Invalid symmetry", http.StatusBadRequest)
+		return
+	}
+
+	puzzle, solution, err := h.sudokuService.GenerateSamurai(r.Context(), req.Difficulty, sudoku.GenerateOptions{Symmetry: req.Symmetry})
+	if err != nil && r.Context().Err() != nil {
+		writeSolverError(w, err)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+		return
+	}
+
+	game := models.SamuraiGame{
+		UserID:       userID,
+		Difficulty:   req.Difficulty,
+		StartingGrid: sudoku.SamuraiToString(puzzle),
+		Solution:     sudoku.SamuraiToString(solution),
+		Grid:         sudoku.SamuraiToString(puzzle),
+	}
+	if err := h.db.Create(&game).Error; err != nil {
+		http.Error(w, "Failed to create game", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(game)
+}
+
+// ownedSamurai loads the Samurai game named in the URL if it belongs to the user
+func (h *SamuraiHandler) ownedSamurai(w http.ResponseWriter, r *http.Request) (*models.SamuraiGame, bool) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	gameID, ok := urlParamID(r, "id")
+	if !ok {
+		http.Error(w, "Invalid game id", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var game models.SamuraiGame
+	if err := h.db.First(&game, gameID).Error; err != nil {
+		http.Error(w, "Game not found", http.StatusNotFound)
+		return nil, false
+	}
+	if game.UserID != userID {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	return &game, true
+}
+
+// Parse a board sent for a game, answering 400 when it is malformed or changes a given digit
+func samuraiBoard(w http.ResponseWriter, game *models.SamuraiGame, grid string) (sudoku.SamuraiBoard, bool) {
+	board, err := sudoku.ParseSamurai(grid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return board, false
+	}
+	start, _ := sudoku.ParseSamurai(game.StartingGrid)
+	if !sudoku.KeepsSamuraiGivens(start, board) {
+		http.Error(w, "Grid changes given digits", http.StatusBadRequest)
+		return board, false
+	}
+	return board, true
+}
+
+// GetSamurai returns a Samurai game, with its solution once it is completed
+func (h *SamuraiHandler) GetSamurai(w http.ResponseWriter, r *http.Request) {
+	game, ok := h.ownedSamurai(w, r)
+	if !ok {
+		return
+	}
+
+	response := map[string]interface{}{"game": game}
+	if game.Completed {
+		response["solution"] = game.Solution
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SaveSamurai stores the player's board so the game can be resumed
+func (h *SamuraiHandler) SaveSamurai(w http.ResponseWriter, r *http.Request) {
+	game, ok := h.ownedSamurai(w, r)
+	if !ok {
+		return
+	}
+	if game.Completed {
+		http.Error(w, "Game already completed", http.StatusConflict)
+		return
+	}
+
+	var req SamuraiGridRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	board, ok := samuraiBoard(w, game, req.Grid)
+	if !ok {
+		return
+	}
+
+	game.Grid = sudoku.SamuraiToString(board)
+	game.TimeSeconds = req.TimeSeconds
+	if err := h.db.Model(game).Updates(map[string]interface{}{"grid": game.Grid, "time_seconds": game.TimeSeconds}).Error; err != nil {
+		http.Error(w, "Failed to save game", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(game)
+}
+
+// CheckSamurai reports the cells whose digit repeats in a row, column or box of one of their
+// grids, and the filled cells that differ from the solution
+func (h *SamuraiHandler) CheckSamurai(w http.ResponseWriter, r *http.Request) {
+	game, ok := h.ownedSamurai(w, r)
+	if !ok {
+		return
+	}
+
+	var req SamuraiGridRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	board, ok := samuraiBoard(w, game, req.Grid)
+	if !ok {
+		return
+	}
+	solution, _ := sudoku.ParseSamurai(game.Solution)
+
+	wrong := []sudoku.Cell{}
+	for i := 0; i < sudoku.SamuraiSize; i++ {
+		for j := 0; j < sudoku.SamuraiSize; j++ {
+			if board[i][j] != 0 && board[i][j] != solution[i][j] {
+				wrong = append(wrong, sudoku.Cell{Row: i, Col: j})
+			}
+		}
+	}
+
+	response := map[string]interface{}{
+		"duplicates": sudoku.SamuraiDuplicates(board),
+		"wrong":      wrong,
+		"solved":     board == solution,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSamuraiHint reveals the value of a cell from the solution: the chosen one, or else the
+// first empty cell
+func (h *SamuraiHandler) GetSamuraiHint(w http.ResponseWriter, r *http.Request) {
+	game, ok := h.ownedSamurai(w, r)
+	if !ok {
+		return
+	}
+	if game.Completed {
+		http.Error(w, "Game already completed", http.StatusConflict)
+		return
+	}
+
+	var req SamuraiHintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	board, ok := samuraiBoard(w, game, req.Grid)
+	if !ok {
+		return
+	}
+	solution, _ := sudoku.ParseSamurai(game.Solution)
+
+	var move *sudoku.Move
+	if req.Row != nil && req.Col != nil {
+		row, col := *req.Row, *req.Col
+		if !sudoku.InSamurai(row, col) {
+			http.Error(w, "Cell is outside the grids", http.StatusBadRequest)
+			return
+		}
+		if board[row][col] != 0 {
+			http.Error(w, "Cell is already filled", http.StatusBadRequest)
+			return
+		}
+		move = &sudoku.Move{Row: row, Col: col, Value: solution[row][col], Reason: "Hint"}
+	} else {
+		cell, ok := sudoku.FirstEmptySamurai(board)
+		if !ok {
+			http.Error(w, "No empty cells", http.StatusBadRequest)
+			return
+		}
+		move = &sudoku.Move{Row: cell.Row, Col: cell.Col, Value: solution[cell.Row][cell.Col], Reason: "Hint"}
+	}
+
+	if err := h.db.Model(game).Update("hints", gorm.Expr("hints + 1")).Error; err != nil {
+		http.Error(w, "Failed to record hint", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(move)
+}
+
+// SubmitSamurai completes the game when the board matches the solution
+func (h *SamuraiHandler) SubmitSamurai(w http.ResponseWriter, r *http.Request) {
+	game, ok := h.ownedSamurai(w, r)
+	if !ok {
+		return
+	}
+	if game.Completed {
+		http.Error(w, "Game already completed", http.StatusConflict)
+		return
+	}
+
+	var req SamuraiGridRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	board, ok := samuraiBoard(w, game, req.Grid)
+	if !ok {
+		return
+	}
+
+	correct := sudoku.SamuraiToString(board) == game.Solution
+	if correct {
+		now := time.Now()
+		game.Grid = game.Solution
+		game.TimeSeconds = req.TimeSeconds
+		game.Completed = true
+		game.CompletedAt = &now
+		err := h.db.Model(game).Updates(map[string]interface{}{
+			"grid":         game.Grid,
+			"time_seconds": game.TimeSeconds,
+			"completed":    true,
+			"completed_at": now,
+		}).Error
+		if err != nil {
+			http.Error(w, "Failed to save game", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := map[string]interface{}{
+		"correct": correct,
+		"game":    game,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
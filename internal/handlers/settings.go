@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/settings"
+)
+
+type SettingsHandler struct {
+	settingsService *settings.Service
+}
+
+func NewSettingsHandler(settingsService *settings.Service) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService}
+}
+
+// GetSettings returns the runtime settings in effect on this server
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.settingsService.Current())
+}
+
+// UpdateSettings changes the runtime settings in the request. They apply on this server at once
+// and on the others within a reload interval.
+func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	var patch settings.Patch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := settings.Validate(patch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.settingsService.Update(patch, userID)
+	if err != nil {
+		http.Error(w, "Failed to save settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(current)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/breaker"
+	"sudoku/internal/jobs"
+	"sudoku/internal/pool"
+)
+
+const (
+	statusOK       = "ok"
+	statusDegraded = "degraded"
+	statusDown     = "down"
+
+	// How long the database gets to answer the status ping
+	statusPingTimeout = 2 * time.Second
+)
+
+// StatusHandler reports the health of the server's components for status pages and uptime monitors
+type StatusHandler struct {
+	db          *gorm.DB
+	dbBreaker   *breaker.Breaker
+	poolService *pool.Service
+	version     string
+	started     time.Time
+}
+
+type ComponentStatus struct {
+	Status string      `json:"status"`
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+func NewStatusHandler(db *gorm.DB, dbBreaker *breaker.Breaker, poolService *pool.Service, version string) *StatusHandler {
+	return &StatusHandler{db: db, dbBreaker: dbBreaker, poolService: poolService, version: version, started: time.Now()}
+}
+
+// GetStatus answers 200 while the database is reachable, with degraded components listed,
+// and 503 when it is down
+func (h *StatusHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	components := map[string]ComponentStatus{
+		"database": h.databaseStatus(r.Context()),
+		"jobs":     jobsStatus(),
+	}
+	if components["database"].Status == statusOK {
+		components["puzzle_pool"] = h.poolStatus()
+	}
+
+	overall := statusOK
+	for _, component := range components {
+		if component.Status == statusDown {
+			overall = statusDown
+			break
+		}
+		if component.Status == statusDegraded {
+			overall = statusDegraded
+		}
+	}
+
+	response := map[string]interface{}{
+		"status":         overall,
+		"components":     components,
+		"version":        h.version,
+		"commit":         buildCommit(),
+		"started_at":     h.started,
+		"uptime_seconds": int(time.Since(h.started).Seconds()),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if overall == statusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *StatusHandler) databaseStatus(ctx context.Context) ComponentStatus {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return ComponentStatus{Status: statusDown}
+	}
+	ctx, cancel := context.WithTimeout(ctx, statusPingTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return ComponentStatus{Status: statusDown}
+	}
+
+	// Reachable, but recent queries kept failing
+	if wait := h.dbBreaker.RetryAfter(); wait > 0 {
+		return ComponentStatus{Status: statusDegraded, Detail: map[string]interface{}{"circuit_breaker_open_seconds": int(wait.Seconds()) + 1}}
+	}
+	return ComponentStatus{Status: statusOK}
+}
+
+func jobsStatus() ComponentStatus {
+	statuses := jobs.Statuses()
+	status := statusOK
+	for _, job := range statuses {
+		if !job.Healthy {
+			status = statusDegraded
+		}
+	}
+	return ComponentStatus{Status: status, Detail: statuses}
+}
+
+// The pool is degraded once a difficulty runs dry and games have to wait for the generator
+func (h *StatusHandler) poolStatus() ComponentStatus {
+	levels, err := h.poolService.Levels()
+	if err != nil {
+		return ComponentStatus{Status: statusDegraded}
+	}
+	status := statusOK
+	if h.poolService.Size() > 0 {
+		for _, count := range levels {
+			if count == 0 {
+				status = statusDegraded
+			}
+		}
+	}
+	return ComponentStatus{Status: status, Detail: map[string]interface{}{"size": h.poolService.Size(), "levels": levels}}
+}
+
+// The VCS revision the binary was built from, empty when it wasn't built from a checkout
+func buildCommit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
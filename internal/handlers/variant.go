@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Generate a Killer, X-Sudoku, Jigsaw, Even/Odd or chess-constrained classic puzzle and open a
+// game session on it. Only the chess constraints of opts are used. Variant puzzles aren't pooled:
+// they are generated on demand, so there is nothing to check against the puzzles the user skipped.
+func (h *GameHandler) createVariantGame(userID uint, difficulty models.Difficulty, mode models.GameMode, variant models.Variant, opts sudoku.GenerateOptions) (*models.Puzzle, *models.GameResult, error) {
+	var puzzleBoard, solutionBoard sudoku.Board
+	var cages []sudoku.Cage
+	var regions *sudoku.Regions
+	var parities *sudoku.Parities
+	var err error
+	switch variant {
+	case models.Killer:
+		puzzleBoard, solutionBoard, cages, err = h.sudokuService.GenerateKiller(difficulty, opts)
+	case models.Jigsaw:
+		var layout sudoku.Regions
+		puzzleBoard, solutionBoard, layout, err = h.sudokuService.GenerateJigsaw(difficulty, opts)
+		regions = &layout
+	case models.EvenOdd:
+		var markers sudoku.Parities
+		puzzleBoard, solutionBoard, markers, err = h.sudokuService.GenerateEvenOdd(difficulty, opts)
+		parities = &markers
+	case models.Diagonal:
+		puzzleBoard, solutionBoard, err = h.sudokuService.GenerateDiagonal(difficulty, opts)
+	default:
+		puzzleBoard, solutionBoard, err = h.sudokuService.GenerateChess(difficulty, opts)
+	}
+	if err != nil {
+		return nil, nil, errors.New("Failed to generate puzzle")
+	}
+
+	puzzle := &models.Puzzle{
+		Difficulty:   difficulty,
+		Variant:      variant,
+		StartingGrid: sudoku.BoardToString(puzzleBoard),
+		Solution:     sudoku.BoardToString(solutionBoard),
+		Rating:       difficulty,
+		AntiKnight:   opts.AntiKnight,
+		AntiKing:     opts.AntiKing,
+	}
+	if cages != nil {
+		puzzle.Cages = sudoku.FormatCages(cages)
+	}
+	if regions != nil {
+		puzzle.Regions = sudoku.FormatRegions(*regions)
+	}
+	if parities != nil {
+		puzzle.Parities = sudoku.FormatParities(*parities)
+	}
+	if err := h.db.Create(puzzle).Error; err != nil {
+		return nil, nil, errors.New("Failed to save generated puzzle")
+	}
+
+	gameResult, err := h.openGame(userID, puzzle, mode, gameOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return puzzle, gameResult, nil
+}
+
+// Whether the puzzle is played under rules beyond the classic ones
+func isVariant(puzzle *models.Puzzle) bool {
+	return (puzzle.Variant != "" && puzzle.Variant != models.Classic) || puzzle.AntiKnight || puzzle.AntiKing
+}
+
+// Whether puzzles of the variant can be generated with the chess constraints. Random jigsaw
+// regions rarely have a solution under them, and X-Sudoku only takes one at a time.
+func chessFits(variant models.Variant, opts sudoku.GenerateOptions) bool {
+	switch variant {
+	case models.Jigsaw:
+		return !opts.AntiKnight && !opts.AntiKing
+	case models.Diagonal:
+		return !opts.AntiKnight || !opts.AntiKing
+	}
+	return true
+}
+
+// The rules of a variant puzzle
+func puzzleRules(puzzle *models.Puzzle) (sudoku.Rules, error) {
+	rules, err := variantRules(puzzle)
+	rules.AntiKnight = puzzle.AntiKnight
+	rules.AntiKing = puzzle.AntiKing
+	return rules, err
+}
+
+// The rules of a puzzle's variant, without its chess constraints
+func variantRules(puzzle *models.Puzzle) (sudoku.Rules, error) {
+	switch puzzle.Variant {
+	case models.Killer:
+		cages, err := sudoku.ParseCages(puzzle.Cages)
+		if err != nil {
+			return sudoku.Rules{}, err
+		}
+		return sudoku.Rules{Cages: cages}, nil
+	case models.Diagonal:
+		return sudoku.Rules{Diagonals: true}, nil
+	case models.Jigsaw:
+		regions, err := sudoku.ParseRegions(puzzle.Regions)
+		if err != nil {
+			return sudoku.Rules{}, err
+		}
+		return sudoku.Rules{Regions: &regions}, nil
+	case models.EvenOdd:
+		parities, err := sudoku.ParseParities(puzzle.Parities)
+		if err != nil {
+			return sudoku.Rules{}, err
+		}
+		return sudoku.Rules{Parities: &parities}, nil
+	}
+	return sudoku.Rules{}, nil
+}
+
+// Solve the board of a variant puzzle under its rules
+func (h *GameHandler) solveVariant(ctx context.Context, puzzle *models.Puzzle, board sudoku.Board) (sudoku.Board, error) {
+	rules, err := puzzleRules(puzzle)
+	if err != nil {
+		return board, err
+	}
+	return h.sudokuService.SolveVariant(ctx, board, rules)
+}
+
+// A hint for a variant board: the value of the chosen cell, or else of the first empty one
+func (h *GameHandler) variantHint(ctx context.Context, puzzle *models.Puzzle, board sudoku.Board, row, col *int) (*sudoku.Move, error) {
+	if row != nil && col != nil {
+		rules, err := puzzleRules(puzzle)
+		if err != nil {
+			return nil, err
+		}
+		return h.sudokuService.VariantHint(ctx, board, rules, *row, *col)
+	}
+	moves, err := h.variantSteps(ctx, puzzle, board, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, errors.New("could not fill any cell")
+	}
+	return &moves[0], nil
+}
+
+// The next count moves of a variant board, each filled from its solution
+func (h *GameHandler) variantSteps(ctx context.Context, puzzle *models.Puzzle, board sudoku.Board, count int) ([]sudoku.Move, error) {
+	rules, err := puzzleRules(puzzle)
+	if err != nil {
+		return nil, err
+	}
+	return h.sudokuService.VariantSteps(ctx, board, rules, count)
+}
+
+// The whole solve of a variant board, move by move
+func (h *GameHandler) variantPath(ctx context.Context, puzzle *models.Puzzle, board sudoku.Board) (*sudoku.Path, error) {
+	rules, err := puzzleRules(puzzle)
+	if err != nil {
+		return nil, err
+	}
+	return h.sudokuService.VariantPath(ctx, board, rules)
+}
+
+// Conflicts on a variant board, counting repeats its rules forbid
+func (h *GameHandler) variantConflicts(ctx context.Context, puzzle *models.Puzzle, board sudoku.Board) (*sudoku.Conflicts, error) {
+	rules, err := puzzleRules(puzzle)
+	if err != nil {
+		return nil, err
+	}
+	return h.sudokuService.FindVariantConflicts(ctx, board, rules)
+}
+
+// Pencil marks of a variant board under its rules
+func (h *GameHandler) variantCandidates(puzzle *models.Puzzle, board sudoku.Board) (sudoku.CandidateGrid, error) {
+	rules, err := puzzleRules(puzzle)
+	if err != nil {
+		return sudoku.CandidateGrid{}, err
+	}
+	return h.sudokuService.VariantCandidates(board, rules), nil
+}
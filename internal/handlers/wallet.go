@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"sudoku/internal/auth"
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+	"sudoku/internal/wallet"
+)
+
+type WalletHandler struct {
+	walletService *wallet.Service
+}
+
+func NewWalletHandler(walletService *wallet.Service) *WalletHandler {
+	return &WalletHandler{walletService: walletService}
+}
+
+// techniquePrice is what a hint revealing a technique costs at each level
+type techniquePrice struct {
+	Technique   string `json:"technique"`
+	Levels      [3]int `json:"levels"` // Technique, cell and value level
+	Elimination int    `json:"elimination"`
+}
+
+// GetWallet returns the user's learn token balance, their latest transactions and what every
+// technique's hints cost
+func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserIDKey).(uint)
+
+	balance, err := h.walletService.Balance(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch wallet", http.StatusInternalServerError)
+		return
+	}
+	transactions, err := h.walletService.History(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch wallet", http.StatusInternalServerError)
+		return
+	}
+
+	prices := []techniquePrice{}
+	for _, technique := range sudoku.TechniqueNames() {
+		price := techniquePrice{Technique: technique, Elimination: wallet.EliminationPrice(technique)}
+		for level := sudoku.TechniqueLevel; level <= sudoku.ValueLevel; level++ {
+			price.Levels[level-1] = wallet.HintPrice(technique, level)
+		}
+		prices = append(prices, price)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"balance":      balance,
+		"transactions": transactions,
+		"prices":       prices,
+	})
+}
+
+// Charge a Learn mode hint to the player's wallet. On failure the response has been written.
+func (h *GameHandler) chargeHint(w http.ResponseWriter, gameResult *models.GameResult, spend models.TokenTransaction, price int) (*models.TokenTransaction, bool) {
+	spend.UserID = gameResult.UserID
+	spend.GameResultID = &gameResult.ID
+	charged, err := h.walletService.Spend(spend, price)
+	var insufficient *wallet.InsufficientError
+	if errors.As(err, &insufficient) {
+		http.Error(w, "Not enough learn tokens: "+insufficient.Error(), http.StatusPaymentRequired)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed to charge hint to user %d: %v", gameResult.UserID, err)
+		http.Error(w, "Failed to charge hint", http.StatusInternalServerError)
+		return nil, false
+	}
+	return charged, true
+}
+
+// Charge Learn mode solve steps to the player's wallet, each move at the price of a value hint for
+// its technique. On failure the response has been written.
+func (h *GameHandler) chargeSteps(w http.ResponseWriter, gameResult *models.GameResult, moves []sudoku.Move) (*models.TokenTransaction, bool) {
+	spend := models.TokenTransaction{Reason: models.StepPurchase, Level: sudoku.ValueLevel}
+	price := 0
+	for i := range moves {
+		technique := sudoku.MoveTechnique(&moves[i])
+		price += wallet.HintPrice(technique, sudoku.ValueLevel)
+		if len(moves) == 1 {
+			spend.Technique = technique
+		}
+	}
+	return h.chargeHint(w, gameResult, spend, price)
+}
+
+// Credit the player for solving a Learn mode puzzle, adding the reward to the submit response
+func (h *GameHandler) rewardLearnGame(gameResult *models.GameResult, response map[string]interface{}) {
+	reward, err := h.walletService.Reward(gameResult.UserID, gameResult.ID, gameResult.Puzzle.Difficulty)
+	if err != nil {
+		log.Printf("Failed to reward user %d for game %d: %v", gameResult.UserID, gameResult.ID, err)
+		return
+	}
+	response["tokens_earned"] = reward.Amount
+	response["token_balance"] = reward.Balance
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+	"sudoku/internal/worksheet"
+)
+
+type WorksheetHandler struct {
+	sudokuService *sudoku.Service
+}
+
+type WorksheetRequest struct {
+	Title   string `json:"title"`
+	Puzzles []struct {
+		Difficulty models.Difficulty `json:"difficulty"`
+		Count      int               `json:"count"`
+	} `json:"puzzles"`
+	Seed *int64 `json:"seed"` // Reprints the same worksheet when sent again
+}
+
+func NewWorksheetHandler(sudokuService *sudoku.Service) *WorksheetHandler {
+	return &WorksheetHandler{sudokuService: sudokuService}
+}
+
+// CreateWorksheet generates a printable PDF of new puzzles with an answer key
+func (h *WorksheetHandler) CreateWorksheet(w http.ResponseWriter, r *http.Request) {
+	var req WorksheetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	total := 0
+	for _, group := range req.Puzzles {
+		switch group.Difficulty {
+		case models.Easy, models.Medium, models.Hard, models.Expert:
+		default:
+			http.Error(w, "Invalid difficulty level", http.StatusBadRequest)
+			return
+		}
+		if group.Count < 1 {
+			http.Error(w, "Puzzle counts must be positive", http.StatusBadRequest)
+			return
+		}
+		total += group.Count
+	}
+	if total == 0 || total > worksheet.MaxPuzzles {
+		http.Error(w, fmt.Sprintf("A worksheet holds 1 to %d puzzles", worksheet.MaxPuzzles), http.StatusBadRequest)
+		return
+	}
+	if req.Title == "" {
+		req.Title = "Sudoku Worksheet"
+	}
+
+	sheet := worksheet.Worksheet{Title: req.Title}
+	for _, group := range req.Puzzles {
+		for i := 0; i < group.Count; i++ {
+			var opts sudoku.GenerateOptions
+			if req.Seed != nil {
+				seed := *req.Seed + int64(len(sheet.Puzzles)) // Distinct seed per puzzle
+				opts.Seed = &seed
+			}
+			puzzle, solution, err := h.sudokuService.GeneratePuzzle(group.Difficulty, opts)
+			if err != nil {
+				http.Error(w, "Failed to generate puzzle", http.StatusInternalServerError)
+				return
+			}
+			sheet.Puzzles = append(sheet.Puzzles, worksheet.Puzzle{Difficulty: group.Difficulty, Board: puzzle, Solution: solution})
+		}
+	}
+
+	// Render fully before writing so a failure can still be reported
+	var out bytes.Buffer
+	if err := worksheet.Render(&out, sheet); err != nil {
+		http.Error(w, "Failed to render worksheet", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="worksheet.pdf"`)
+	w.Write(out.Bytes())
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the last known state of a scheduled job
+type Status struct {
+	Name      string     `json:"name"`
+	Interval  string     `json:"interval"`
+	LastRun   *time.Time `json:"last_run"`
+	LastError string     `json:"last_error,omitempty"`
+	Healthy   bool       `json:"healthy"` // Ran within two intervals and the last run succeeded
+
+	interval   time.Duration
+	registered time.Time
+}
+
+var (
+	mu       sync.Mutex
+	statuses = map[string]*Status{}
+)
+
+// Every runs fn once immediately and then at every interval until ctx is cancelled.
+// Errors are logged and do not stop the schedule.
+func Every(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	record(name, interval, nil, nil)
+	for {
+		err := fn()
+		if err != nil {
+			log.Printf("Job %s failed: %v", name, err)
+		}
+		now := time.Now()
+		record(name, interval, &now, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func record(name string, interval time.Duration, ran *time.Time, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	status, ok := statuses[name]
+	if !ok {
+		status = &Status{Name: name, Interval: interval.String(), interval: interval, registered: time.Now()}
+		statuses[name] = status
+	}
+	if ran != nil {
+		status.LastRun = ran
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
+		}
+	}
+}
+
+// Statuses reports every scheduled job, sorted by name
+func Statuses() []Status {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	list := make([]Status, 0, len(statuses))
+	for _, status := range statuses {
+		s := *status
+		// A job still on its first run counts from when it was scheduled
+		since := s.registered
+		if s.LastRun != nil {
+			since = *s.LastRun
+		}
+		s.Healthy = s.LastError == "" && now.Sub(since) <= 2*s.interval
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
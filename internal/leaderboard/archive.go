@@ -0,0 +1,137 @@
+package leaderboard
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+// Boards archived for every period
+var (
+	snapshotDifficulties = []string{"", string(models.Easy), string(models.Medium), string(models.Hard), string(models.Expert)}
+	snapshotSorts        = []string{"score", "time"}
+)
+
+// Start of the period containing t
+func PeriodStart(period models.SnapshotPeriod, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == models.WeeklySnapshot {
+		// Weeks start on Monday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// Length of a period starting at start
+func periodEnd(period models.SnapshotPeriod, start time.Time) time.Time {
+	if period == models.WeeklySnapshot {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// SnapshotDue archives the most recently closed day and week. It is safe to run repeatedly:
+// periods that already have a snapshot are skipped.
+func (s *Service) SnapshotDue() error {
+	now := time.Now()
+	for _, period := range []models.SnapshotPeriod{models.DailySnapshot, models.WeeklySnapshot} {
+		current := PeriodStart(period, now)
+		previous := PeriodStart(period, current.Add(-time.Second))
+		if err := s.Snapshot(period, previous); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot archives every leaderboard for the period starting at start
+func (s *Service) Snapshot(period models.SnapshotPeriod, start time.Time) error {
+	var existing int64
+	s.db.Model(&models.LeaderboardSnapshot{}).Where("period = ? AND period_start = ?", period, start).Count(&existing)
+	if existing > 0 {
+		return nil
+	}
+
+	end := periodEnd(period, start)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, difficulty := range snapshotDifficulties {
+			for _, sortBy := range snapshotSorts {
+				entries, err := s.Top(Filter{Difficulty: difficulty, From: start, To: end}, sortBy)
+				if err != nil {
+					return err
+				}
+				for i, entry := range entries {
+					row := models.LeaderboardSnapshot{
+						Period:      period,
+						PeriodStart: start,
+						Difficulty:  difficulty,
+						SortBy:      sortBy,
+						Rank:        i + 1,
+						UserID:      entry.UserID,
+						Username:    entry.Username,
+						Score:       entry.Score,
+						TimeSeconds: entry.TimeSeconds,
+						CompletedAt: entry.CompletedAt,
+					}
+					if err := tx.Create(&row).Error; err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Rebuild replaces the archived boards of a period with freshly computed ones, after results in it
+// changed. Periods that were never archived are left for SnapshotDue. Returns whether it rebuilt.
+func (s *Service) Rebuild(period models.SnapshotPeriod, start time.Time) (bool, error) {
+	deleted := s.db.Where("period = ? AND period_start = ?", period, start).Delete(&models.LeaderboardSnapshot{})
+	if deleted.Error != nil || deleted.RowsAffected == 0 {
+		return false, deleted.Error
+	}
+	return true, s.Snapshot(period, start)
+}
+
+// Archived returns the archived standings of one board
+func (s *Service) Archived(period models.SnapshotPeriod, start time.Time, difficulty, sortBy string) ([]models.LeaderboardSnapshot, error) {
+	if period != models.DailySnapshot && period != models.WeeklySnapshot {
+		return nil, errors.New("invalid period")
+	}
+
+	rows := []models.LeaderboardSnapshot{}
+	err := s.db.Where("period = ? AND period_start = ? AND difficulty = ? AND sort_by = ?", period, PeriodStart(period, start), difficulty, sortBy).
+		Order("rank ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// ArchivedPeriods lists the start of every archived period, newest first
+func (s *Service) ArchivedPeriods(period models.SnapshotPeriod) ([]time.Time, error) {
+	starts := []time.Time{}
+	err := s.db.Model(&models.LeaderboardSnapshot{}).
+		Where("period = ?", period).
+		Distinct("period_start").
+		Order("period_start DESC").
+		Pluck("period_start", &starts).Error
+	return starts, err
+}
+
+// Rank returns the user's best rank on the archived all-difficulties score board of the
+// period starting at start. ok is false if the user didn't place.
+func (s *Service) Rank(period models.SnapshotPeriod, start time.Time, userID uint) (rank int, ok bool, err error) {
+	var ranks []int
+	err = s.db.Model(&models.LeaderboardSnapshot{}).
+		Where("period = ? AND period_start = ? AND difficulty = ? AND sort_by = ? AND user_id = ?", period, PeriodStart(period, start), "", "score", userID).
+		Order("rank ASC").
+		Limit(1).
+		Pluck("rank", &ranks).Error
+	if err != nil || len(ranks) == 0 {
+		return 0, false, err
+	}
+	return ranks[0], true, nil
+}
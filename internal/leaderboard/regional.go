@@ -0,0 +1,92 @@
+package leaderboard
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"sudoku/internal/models"
+)
+
+// How long a regional board is served from the cache before it is read again
+const regionalCacheTTL = 30 * time.Second
+
+// boardCache keeps recently read regional boards, keyed by region, difficulty and sort
+type boardCache struct {
+	mu     sync.Mutex
+	boards map[string]cachedBoard
+}
+
+type cachedBoard struct {
+	entries []Entry
+	expires time.Time
+}
+
+func newBoardCache() *boardCache {
+	return &boardCache{boards: make(map[string]cachedBoard)}
+}
+
+func regionalCacheKey(region models.Region, difficulty, sortBy string) string {
+	return string(region) + "|" + difficulty + "|" + sortBy
+}
+
+func (c *boardCache) get(key string, now time.Time) ([]Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	board, ok := c.boards[key]
+	if !ok || now.After(board.expires) {
+		return nil, false
+	}
+	return board.entries, true
+}
+
+func (c *boardCache) put(key string, entries []Entry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.boards[key] = cachedBoard{entries: entries, expires: now.Add(regionalCacheTTL)}
+}
+
+// Regional returns the board of the players of one region, empty for players without one.
+// Boards are cached for a short while per region, difficulty and sort.
+func (s *Service) Regional(region models.Region, difficulty, sortBy string) ([]Entry, error) {
+	if sortBy != "time" {
+		sortBy = "score"
+	}
+	key := regionalCacheKey(region, difficulty, sortBy)
+	now := time.Now()
+	if entries, ok := s.cache.get(key, now); ok {
+		return entries, nil
+	}
+
+	entries, err := s.Top(Filter{Difficulty: difficulty, Region: &region}, sortBy)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(key, entries, now)
+	return entries, nil
+}
+
+// Merged builds the global board from the regional ones. Every result of the global top is in the
+// top of its own region, so merging the regional boards, players without a region included,
+// gives the same standings while only reading cached shards.
+func (s *Service) Merged(difficulty, sortBy string) ([]Entry, error) {
+	merged := []Entry{}
+	for _, region := range append([]models.Region{""}, models.AllRegions...) {
+		entries, err := s.Regional(region, difficulty, sortBy)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, entries...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if sortBy == "time" {
+			return merged[i].TimeSeconds < merged[j].TimeSeconds
+		}
+		return merged[i].Score > merged[j].Score
+	})
+	if len(merged) > Size {
+		merged = merged[:Size]
+	}
+	return merged, nil
+}
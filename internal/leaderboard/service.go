@@ -0,0 +1,115 @@
+package leaderboard
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+// Number of entries kept on a leaderboard
+const Size = 10
+
+type Service struct {
+	db    *gorm.DB
+	cache *boardCache // Regional boards
+}
+
+// Entry is one row of a leaderboard
+type Entry struct {
+	UserID      uint       `json:"-"`
+	Username    string     `json:"username"`
+	Region      string     `json:"region,omitempty"`
+	Score       int        `json:"score"`
+	TimeSeconds int        `json:"time_seconds"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Difficulty  string     `json:"difficulty"`
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, cache: newBoardCache()}
+}
+
+// WithContext returns a service whose queries carry ctx, tying them to the request in logs
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{db: s.db.WithContext(ctx), cache: s.cache}
+}
+
+// Filter narrows a leaderboard. Zero values mean no restriction.
+type Filter struct {
+	Difficulty string
+	PuzzleID   uint
+	UserID     uint
+	EventID    uint
+	From       time.Time      // Completed at or after
+	To         time.Time      // Completed before
+	Region     *models.Region // Players of the region, or without one when it is empty
+}
+
+// Top returns the best eligible play-mode results matching the filter, sorted by "score" or "time".
+// Results submitted since replays became required are only eligible once their replay has been
+// checked.
+func (s *Service) Top(filter Filter, sortBy string) ([]Entry, error) {
+	query := s.db.Table("game_results").
+		Select("users.id AS user_id, users.username, users.region, game_results.score, game_results.time_seconds, game_results.completed_at, puzzles.difficulty").
+		Joins("JOIN users ON game_results.user_id = users.id").
+		Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").
+		Scopes(models.Active("game_results", "users", "puzzles")).
+		Where("game_results.mode = ? AND game_results.completed = ? AND game_results.disqualified = ?", models.PlayMode, true, false).
+		Where("game_results.voided = ? AND game_results.under_review = ? AND game_results.practice = ?", false, false, false).
+		Where("game_results.checked_at IS NOT NULL OR game_results.needs_replay = ?", false) // Only results backed by a replay
+
+	if filter.Difficulty != "" {
+		query = query.Where("puzzles.difficulty = ?", filter.Difficulty)
+	}
+	if filter.PuzzleID != 0 {
+		query = query.Where("game_results.puzzle_id = ?", filter.PuzzleID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("game_results.user_id = ?", filter.UserID)
+	}
+	if filter.EventID != 0 {
+		query = query.Where("game_results.event_id = ?", filter.EventID)
+	}
+	if filter.Region != nil {
+		query = query.Where("users.region = ?", *filter.Region)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("game_results.completed_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("game_results.completed_at < ?", filter.To)
+	}
+
+	if sortBy == "time" {
+		query = query.Order("game_results.time_seconds ASC")
+	} else {
+		query = query.Order("game_results.score DESC")
+	}
+
+	entries := []Entry{}
+	err := query.Limit(Size).Scan(&entries).Error
+	return entries, err
+}
+
+// GlobalEntry is one row of the global ranking
+type GlobalEntry struct {
+	Username    string `json:"username"`
+	Points      int    `json:"points"` // Ranking points, decayed by game age when score decay is on
+	TotalPoints int    `json:"total_points"`
+	GamesPlayed int    `json:"games_played"`
+}
+
+// Global returns the players with the most ranking points
+func (s *Service) Global() ([]GlobalEntry, error) {
+	entries := []GlobalEntry{}
+	err := s.db.Model(&models.User{}).
+		Select("username, ranking_points AS points, total_points, games_played").
+		Where("ranking_points > 0").
+		Order("ranking_points DESC, id ASC").
+		Limit(Size).
+		Scan(&entries).Error
+	return entries, err
+}
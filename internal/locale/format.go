@@ -0,0 +1,113 @@
+package locale
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Date layouts by region. Tags without a region use the language's most likely one, so "de"
+// is formatted like "de-DE".
+var dateLayouts = map[string]string{
+	"US": "01/02/2006",
+	"PH": "01/02/2006",
+	"CA": "2006-01-02",
+	"DE": "02.01.2006",
+	"AT": "02.01.2006",
+	"CH": "02.01.2006",
+	"RU": "02.01.2006",
+	"PL": "02.01.2006",
+	"SE": "2006-01-02",
+	"CN": "2006/01/02",
+	"JP": "2006/01/02",
+	"KR": "2006. 01. 02.",
+}
+
+// Used for regions without an entry in dateLayouts
+const defaultDateLayout = "02/01/2006"
+
+// Formatter renders numbers, durations and dates for one locale and timezone. It is used for
+// anything shown outside the JSON API, such as emails, exports and share cards.
+type Formatter struct {
+	tag      language.Tag
+	location *time.Location
+	printer  *message.Printer
+}
+
+// NewFormatter returns a formatter for a BCP 47 tag and an IANA timezone. Empty or invalid
+// values fall back to DefaultLocale and DefaultTimezone, so a user's stored preferences can
+// be passed as is.
+func NewFormatter(tag, timezone string) *Formatter {
+	parsed, err := language.Parse(tag)
+	if tag == "" || err != nil {
+		parsed = language.MustParse(DefaultLocale)
+	}
+	location, err := time.LoadLocation(timezone)
+	if timezone == "" || err != nil {
+		location = time.UTC
+	}
+	return &Formatter{tag: parsed, location: location, printer: message.NewPrinter(parsed)}
+}
+
+// FromContext returns a formatter for the locale and timezone resolved by Middleware
+func FromContext(ctx context.Context) *Formatter {
+	f := NewFormatter(Locale(ctx), "")
+	f.location = Location(ctx)
+	return f
+}
+
+// Number formats an integer with the locale's thousands separator, e.g. "12,345" or "12.345"
+func (f *Formatter) Number(n int) string {
+	return f.printer.Sprintf("%d", n)
+}
+
+// Duration formats a solve time as "m:ss", or "h:mm:ss" from one hour on, using the locale's
+// digits for the hours
+func (f *Formatter) Duration(d time.Duration) string {
+	seconds := int(d.Round(time.Second) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+	hours, minutes, seconds := seconds/3600, seconds/60%60, seconds%60
+	if hours > 0 {
+		return fmt.Sprintf("%s:%02d:%02d", f.Number(hours), minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
+
+// Date formats the day of t in the formatter's timezone with the locale's numeric layout
+func (f *Formatter) Date(t time.Time) string {
+	return t.In(f.location).Format(f.dateLayout())
+}
+
+// Day formats a calendar day, such as the start of a leaderboard period, without converting
+// it to the formatter's timezone
+func (f *Formatter) Day(t time.Time) string {
+	return t.Format(f.dateLayout())
+}
+
+// DateTime formats t as a date followed by a 24-hour clock time, in the formatter's timezone.
+// US English keeps its 12-hour clock.
+func (f *Formatter) DateTime(t time.Time) string {
+	clock := "15:04"
+	if region, _ := f.tag.Region(); region.String() == "US" {
+		clock = "3:04 PM"
+	}
+	return t.In(f.location).Format(f.dateLayout() + " " + clock)
+}
+
+// Location returns the timezone dates are rendered in
+func (f *Formatter) Location() *time.Location {
+	return f.location
+}
+
+func (f *Formatter) dateLayout() string {
+	region, _ := f.tag.Region()
+	if layout, ok := dateLayouts[region.String()]; ok {
+		return layout
+	}
+	return defaultDateLayout
+}
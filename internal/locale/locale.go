@@ -0,0 +1,95 @@
+package locale
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"sudoku/internal/auth"
+)
+
+// Defaults used when neither the profile nor the request specify a preference
+const (
+	DefaultTimezone = "UTC"
+	DefaultLocale   = "en-US"
+)
+
+type contextKey string
+
+const (
+	locationKey contextKey = "location"
+	localeKey   contextKey = "locale"
+)
+
+// ValidTimezone reports whether name is an IANA timezone such as "Europe/Paris"
+func ValidTimezone(name string) bool {
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// ValidLocale reports whether tag is a well-formed BCP 47 language tag such as "fr-FR"
+func ValidLocale(tag string) bool {
+	_, err := language.Parse(tag)
+	return err == nil
+}
+
+// Middleware resolves the timezone and locale of the request. The X-Timezone header and
+// Accept-Language override the user's profile preferences, which override the defaults.
+// It must run after auth.AuthMiddleware.
+func Middleware(authService *auth.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timezone, tag := DefaultTimezone, DefaultLocale
+			if userID, ok := r.Context().Value(auth.UserIDKey).(uint); ok {
+				if user, err := authService.GetUserByID(userID); err == nil {
+					if user.Timezone != "" {
+						timezone = user.Timezone
+					}
+					if user.Locale != "" {
+						tag = user.Locale
+					}
+				}
+			}
+
+			if header := r.Header.Get("X-Timezone"); header != "" && ValidTimezone(header) {
+				timezone = header
+			}
+			if tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil && len(tags) > 0 {
+				tag = tags[0].String()
+			}
+
+			location, err := time.LoadLocation(timezone)
+			if err != nil {
+				location = time.UTC
+			}
+
+			ctx := context.WithValue(r.Context(), locationKey, location)
+			ctx = context.WithValue(ctx, localeKey, tag)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Location returns the timezone of the request, UTC if none was resolved
+func Location(ctx context.Context) *time.Location {
+	if location, ok := ctx.Value(locationKey).(*time.Location); ok {
+		return location
+	}
+	return time.UTC
+}
+
+// Locale returns the language tag of the request
+func Locale(ctx context.Context) string {
+	if tag, ok := ctx.Value(localeKey).(string); ok {
+		return tag
+	}
+	return DefaultLocale
+}
+
+// StartOfDay returns midnight of t's day in the request's timezone
+func StartOfDay(ctx context.Context, t time.Time) time.Time {
+	local := t.In(Location(ctx))
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+}
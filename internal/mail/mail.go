@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// Sender delivers a plain text email
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+// LogSender only logs emails. It is used until SMTP is configured.
+type LogSender struct{}
+
+func (LogSender) Send(to, subject, body string) error {
+	log.Printf("Email to %s: %s", to, subject)
+	return nil
+}
+
+// SMTPSender sends email through an SMTP relay
+type SMTPSender struct {
+	Addr     string // host:port
+	From     string
+	Username string // Optional, enables PLAIN auth
+	Password string
+}
+
+func (s SMTPSender) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		s.From, to, subject, strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(s.Addr, auth, s.From, []string{to}, []byte(msg))
+}
@@ -0,0 +1,290 @@
+package merge
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/models"
+	"sudoku/internal/stats"
+	"sudoku/internal/wallet"
+)
+
+// How long both account holders have to confirm a merge
+const ConfirmWindow = 7 * 24 * time.Hour
+
+var (
+	ErrSameAccount   = errors.New("cannot merge an account into itself")
+	ErrNotPending    = errors.New("merge is no longer pending")
+	ErrNotInvolved   = errors.New("user is not part of the merge")
+	ErrAlreadyActive = errors.New("one of the accounts already has a pending merge")
+)
+
+// Summary counts what a merge moved to the target account
+type Summary struct {
+	Games   int `json:"games_moved"`
+	Results int `json:"results_moved"`
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Propose records an admin's merge proposal. Accounts can only be part of one pending merge at a time.
+func (s *Service) Propose(sourceID, targetID, adminID uint) (*models.AccountMerge, error) {
+	if sourceID == targetID {
+		return nil, ErrSameAccount
+	}
+	for _, id := range []uint{sourceID, targetID} {
+		if err := s.db.First(&models.User{}, id).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var active int64
+	err := s.db.Model(&models.AccountMerge{}).
+		Where("status = ? AND expires_at > ?", models.MergePending, time.Now()).
+		Where("source_user_id IN ? OR target_user_id IN ?", []uint{sourceID, targetID}, []uint{sourceID, targetID}).
+		Count(&active).Error
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, ErrAlreadyActive
+	}
+
+	merge := models.AccountMerge{
+		SourceUserID:  sourceID,
+		TargetUserID:  targetID,
+		RequestedByID: adminID,
+		Status:        models.MergePending,
+		ExpiresAt:     time.Now().Add(ConfirmWindow),
+	}
+	if err := s.db.Create(&merge).Error; err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// Pending lists the merges waiting on the user's confirmation
+func (s *Service) Pending(userID uint) ([]models.AccountMerge, error) {
+	var merges []models.AccountMerge
+	err := s.db.Where("status = ? AND expires_at > ?", models.MergePending, time.Now()).
+		Where("source_user_id = ? OR target_user_id = ?", userID, userID).
+		Order("created_at DESC").
+		Find(&merges).Error
+	return merges, err
+}
+
+// Confirm records the user's consent. The merge runs as soon as both accounts have confirmed.
+func (s *Service) Confirm(mergeID, userID uint) (*models.AccountMerge, error) {
+	var merge models.AccountMerge
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.loadPending(tx, mergeID, userID, &merge); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if merge.SourceUserID == userID && merge.SourceConfirmedAt == nil {
+			merge.SourceConfirmedAt = &now
+		}
+		if merge.TargetUserID == userID && merge.TargetConfirmedAt == nil {
+			merge.TargetConfirmedAt = &now
+		}
+
+		if merge.SourceConfirmedAt != nil && merge.TargetConfirmedAt != nil {
+			summary, err := mergeAccounts(tx, merge.SourceUserID, merge.TargetUserID)
+			if err != nil {
+				return err
+			}
+			merge.Status = models.MergeCompleted
+			merge.CompletedAt = &now
+			merge.GamesMoved = summary.Games
+			merge.ResultsMoved = summary.Results
+		}
+		return tx.Save(&merge).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// Decline lets either account holder refuse the merge
+func (s *Service) Decline(mergeID, userID uint) (*models.AccountMerge, error) {
+	var merge models.AccountMerge
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.loadPending(tx, mergeID, userID, &merge); err != nil {
+			return err
+		}
+		merge.Status = models.MergeDeclined
+		return tx.Save(&merge).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// Load a pending, unexpired merge involving the user, locking it against a concurrent confirmation
+func (s *Service) loadPending(tx *gorm.DB, mergeID, userID uint, merge *models.AccountMerge) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(merge, mergeID).Error; err != nil {
+		return err
+	}
+	if merge.SourceUserID != userID && merge.TargetUserID != userID {
+		return ErrNotInvolved
+	}
+	if merge.Status != models.MergePending || time.Now().After(merge.ExpiresAt) {
+		return ErrNotPending
+	}
+	return nil
+}
+
+// Fold the source account into the target within the transaction. Conflict rules:
+//   - games, attempts, quizzes, disputes, devices, API keys and races move to the target
+//   - rows unique per user (puzzle skips, dismissals, coach grants) keep the target's copy
+//   - coach grants between the two accounts are dropped
+//   - profile settings keep the target's value, falling back to the source's where unset
+//   - totals are recomputed from the merged game results
+//   - archived leaderboard rows are credited to the target
+//
+// The source account is soft deleted afterwards.
+func mergeAccounts(tx *gorm.DB, sourceID, targetID uint) (Summary, error) {
+	var source, target models.User
+	if err := tx.First(&source, sourceID).Error; err != nil {
+		return Summary{}, err
+	}
+	if err := tx.First(&target, targetID).Error; err != nil {
+		return Summary{}, err
+	}
+
+	var summary Summary
+	// Soft deleted games move too, so restoring them later puts them on the target
+	games := tx.Unscoped().Model(&models.Game{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if games.Error != nil {
+		return Summary{}, games.Error
+	}
+	summary.Games = int(games.RowsAffected)
+	results := tx.Unscoped().Model(&models.GameResult{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	if results.Error != nil {
+		return Summary{}, results.Error
+	}
+	summary.Results = int(results.RowsAffected)
+
+	// Rows unique per user: drop the source's copy where the target already has one
+	err := tx.Where("user_id = ? AND puzzle_id IN (?)", sourceID,
+		tx.Model(&models.PuzzleSkip{}).Select("puzzle_id").Where("user_id = ?", targetID)).
+		Delete(&models.PuzzleSkip{}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	err = tx.Where("user_id = ? AND announcement_id IN (?)", sourceID,
+		tx.Model(&models.AnnouncementDismissal{}).Select("announcement_id").Where("user_id = ?", targetID)).
+		Delete(&models.AnnouncementDismissal{}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	err = tx.Where("user_id = ? AND event_id IN (?)", sourceID,
+		tx.Model(&models.EventBadge{}).Select("event_id").Where("user_id = ?", targetID)).
+		Delete(&models.EventBadge{}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	err = tx.Where("(player_id = ? AND coach_id = ?) OR (player_id = ? AND coach_id = ?)", sourceID, targetID, targetID, sourceID).
+		Delete(&models.CoachGrant{}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	err = tx.Where("player_id = ? AND coach_id IN (?)", sourceID,
+		tx.Model(&models.CoachGrant{}).Select("coach_id").Where("player_id = ?", targetID)).
+		Delete(&models.CoachGrant{}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	err = tx.Where("coach_id = ? AND player_id IN (?)", sourceID,
+		tx.Model(&models.CoachGrant{}).Select("player_id").Where("coach_id = ?", targetID)).
+		Delete(&models.CoachGrant{}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+
+	reassign := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.PuzzleSkip{}, "user_id"},
+		{&models.AnnouncementDismissal{}, "user_id"},
+		{&models.EventBadge{}, "user_id"},
+		{&models.CoachGrant{}, "player_id"},
+		{&models.CoachGrant{}, "coach_id"},
+		{&models.GameAnnotation{}, "coach_id"},
+		{&models.TechniqueRecommendation{}, "player_id"},
+		{&models.TechniqueRecommendation{}, "coach_id"},
+		{&models.OnboardingQuiz{}, "user_id"},
+		{&models.AssistantSession{}, "user_id"},
+		{&models.GameDispute{}, "user_id"},
+		{&models.APIKey{}, "user_id"},
+		{&models.PushDevice{}, "user_id"},
+		{&models.PushNotification{}, "user_id"},
+		{&models.Goal{}, "user_id"},
+		{&models.GoalCompletion{}, "user_id"},
+		{&models.Race{}, "host_id"},
+		{&models.Race{}, "guest_id"},
+		{&models.RivalNudge{}, "user_id"},
+		{&models.RivalNudge{}, "rival_id"},
+		{&models.TokenTransaction{}, "user_id"},
+		{&models.SamuraiGame{}, "user_id"},
+		{&models.Race{}, "winner_id"},
+		{&models.RaceSeries{}, "host_id"},
+		{&models.RaceSeries{}, "guest_id"},
+		{&models.RaceSeries{}, "winner_id"},
+	}
+	for _, r := range reassign {
+		if err := tx.Model(r.model).Where(r.column+" = ?", sourceID).Update(r.column, targetID).Error; err != nil {
+			return Summary{}, err
+		}
+	}
+	err = tx.Model(&models.LeaderboardSnapshot{}).Where("user_id = ?", sourceID).
+		Updates(map[string]interface{}{"user_id": targetID, "username": target.Username}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+
+	// Settings the target never set are taken from the source
+	updates := map[string]interface{}{}
+	if target.Timezone == "" && source.Timezone != "" {
+		updates["timezone"] = source.Timezone
+	}
+	if target.Locale == "" && source.Locale != "" {
+		updates["locale"] = source.Locale
+	}
+	if target.StartingDifficulty == "" && source.StartingDifficulty != "" {
+		updates["starting_difficulty"] = source.StartingDifficulty
+	}
+	if target.RecommendedLesson == "" && source.RecommendedLesson != "" {
+		updates["recommended_lesson"] = source.RecommendedLesson
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(&target).Updates(updates).Error; err != nil {
+			return Summary{}, err
+		}
+	}
+
+	if err := stats.RecomputeUser(tx, targetID); err != nil {
+		return Summary{}, err
+	}
+	if err := wallet.Transfer(tx, sourceID, targetID); err != nil {
+		return Summary{}, err
+	}
+	err = tx.Model(&source).Updates(map[string]interface{}{"total_points": 0, "ranking_points": 0, "games_played": 0, "practice_games": 0}).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	return summary, tx.Delete(&source).Error
+}
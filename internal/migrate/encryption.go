@@ -0,0 +1,128 @@
+package migrate
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/vault"
+)
+
+// EncryptColumns encrypts user emails and push device tokens that are still in plain text or were
+// encrypted with an older key, and fills in their blind indexes. Without configured keys only
+// missing blind indexes are filled. With all set every row is rewritten, which is needed after
+// changing the index key. Running it repeatedly is safe.
+func EncryptColumns(db *gorm.DB, all bool) (int, error) {
+	users, err := EncryptUsers(db, all)
+	if err != nil {
+		return users, err
+	}
+	devices, err := EncryptPushDevices(db, all)
+	return users + devices, err
+}
+
+// ErrStaleIndexes is returned by CheckIndexes when stored blind indexes were made with another key
+var ErrStaleIndexes = errors.New("stored blind indexes were made with another ENCRYPTION_INDEX_KEY, run the migration script with -reindex")
+
+// CheckIndexes compares the blind indexes of the oldest and newest user and push device with the
+// ones the configured index key gives. Lookups by email or device token miss without an error
+// when they differ, so servers refuse to start until the indexes are rewritten.
+func CheckIndexes(db *gorm.DB) error {
+	for _, order := range []string{"id ASC", "id DESC"} {
+		var user models.User
+		err := db.Unscoped().Where("email_hash IS NOT NULL").Order(order).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && *vault.Index(user.Email) != *user.EmailHash {
+			return ErrStaleIndexes
+		}
+
+		var device models.PushDevice
+		err = db.Where("token_hash IS NOT NULL").Order(order).First(&device).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && *vault.Index(device.Token) != *device.TokenHash {
+			return ErrStaleIndexes
+		}
+	}
+	return nil
+}
+
+// ErrMissingIndexes is returned by CheckComplete when users or push devices have no blind index yet
+var ErrMissingIndexes = errors.New("some users or push devices have no blind index, run the migration script with -reindex")
+
+// CheckComplete makes sure every user and push device has a blind index. Rows from before
+// indexes were required have none, and lookups by email or device token miss them without an
+// error, which CheckIndexes can't tell when no row has an index at all.
+func CheckComplete(db *gorm.DB) error {
+	var users, devices int64
+	if err := db.Unscoped().Model(&models.User{}).Where("email_hash IS NULL").Count(&users).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.PushDevice{}).Where("token_hash IS NULL").Count(&devices).Error; err != nil {
+		return err
+	}
+	if users+devices > 0 {
+		return ErrMissingIndexes
+	}
+	return nil
+}
+
+// EncryptUsers is EncryptColumns for user emails
+func EncryptUsers(db *gorm.DB, all bool) (int, error) {
+	query := pending(db.Unscoped(), "email", "email_hash", all)
+
+	encrypted := 0
+	var users []models.User
+	err := query.FindInBatches(&users, batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, user := range users {
+				user.EmailHash = vault.Index(user.Email)
+				if err := tx.Unscoped().Model(&user).Select("email", "email_hash").UpdateColumns(&user).Error; err != nil {
+					return err
+				}
+			}
+			encrypted += len(users)
+			return nil
+		})
+	}).Error
+	return encrypted, err
+}
+
+// EncryptPushDevices is EncryptColumns for push device tokens
+func EncryptPushDevices(db *gorm.DB, all bool) (int, error) {
+	query := pending(db, "token", "token_hash", all)
+
+	encrypted := 0
+	var devices []models.PushDevice
+	err := query.FindInBatches(&devices, batchSize, func(_ *gorm.DB, _ int) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			for _, device := range devices {
+				device.TokenHash = vault.Index(device.Token)
+				if err := tx.Model(&device).Select("token", "token_hash").UpdateColumns(&device).Error; err != nil {
+					return err
+				}
+			}
+			encrypted += len(devices)
+			return nil
+		})
+	}).Error
+	return encrypted, err
+}
+
+// Narrow a query to rows whose column isn't encrypted with the current key or whose blind index is
+// missing; without configured keys only the missing indexes matter
+func pending(query *gorm.DB, column, index string, all bool) *gorm.DB {
+	prefix := vault.Prefix()
+	switch {
+	case all:
+		return query
+	case prefix != "":
+		return query.Where(column+" NOT LIKE ? OR "+index+" IS NULL", prefix+"%")
+	default:
+		return query.Where(index + " IS NULL")
+	}
+}
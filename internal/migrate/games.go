@@ -0,0 +1,44 @@
+package migrate
+
+import (
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+// Number of rows migrated per batch
+const batchSize = 500
+
+// BackfillGames creates a game for every game result recorded before games and attempts were split,
+// making each of those results the first attempt of its own game.
+// Results that already belong to a game are left alone, so running it repeatedly is safe.
+func BackfillGames(db *gorm.DB) (int, error) {
+	linked := 0
+	var results []models.GameResult
+	err := db.Unscoped().Where("game_id IS NULL OR game_id = 0").
+		FindInBatches(&results, batchSize, func(batch *gorm.DB, _ int) error {
+			return db.Transaction(func(tx *gorm.DB) error {
+				for _, result := range results {
+					game := models.Game{
+						UserID:    result.UserID,
+						PuzzleID:  result.PuzzleID,
+						Mode:      result.Mode,
+						Attempts:  1,
+						CreatedAt: result.StartedAt,
+						DeletedAt: result.DeletedAt,
+					}
+					if err := tx.Create(&game).Error; err != nil {
+						return err
+					}
+					err := tx.Unscoped().Model(&models.GameResult{}).Where("id = ?", result.ID).
+						UpdateColumns(map[string]interface{}{"game_id": game.ID, "attempt": 1}).Error
+					if err != nil {
+						return err
+					}
+				}
+				linked += len(results)
+				return nil
+			})
+		}).Error
+	return linked, err
+}
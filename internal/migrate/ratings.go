@@ -0,0 +1,29 @@
+package migrate
+
+import (
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// RatePuzzles stores the technique-based rating of every puzzle created before puzzles were rated.
+// Puzzles that already have a rating are left alone, so running it repeatedly is safe.
+func RatePuzzles(db *gorm.DB, sudokuService *sudoku.Service) (int, error) {
+	rated := 0
+	var puzzles []models.Puzzle
+	err := db.Unscoped().Where("rating IS NULL OR rating = ''").
+		FindInBatches(&puzzles, batchSize, func(batch *gorm.DB, _ int) error {
+			for _, puzzle := range puzzles {
+				rating := sudokuService.RatePuzzle(sudoku.StringToBoard(puzzle.StartingGrid))
+				err := db.Unscoped().Model(&models.Puzzle{}).Where("id = ?", puzzle.ID).
+					UpdateColumns(map[string]interface{}{"rating": rating.Difficulty, "hardest_technique": rating.HardestTechnique}).Error
+				if err != nil {
+					return err
+				}
+			}
+			rated += len(puzzles)
+			return nil
+		}).Error
+	return rated, err
+}
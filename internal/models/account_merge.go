@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+type MergeStatus string
+
+const (
+	MergePending   MergeStatus = "pending"   // Waiting for both accounts to confirm
+	MergeCompleted MergeStatus = "completed" // Source account folded into the target and deleted
+	MergeDeclined  MergeStatus = "declined"  // One of the accounts refused
+)
+
+// AccountMerge is an admin's proposal to fold a duplicate account (the source) into another
+// (the target). It only runs once the holders of both accounts have confirmed it.
+type AccountMerge struct {
+	ID                uint        `json:"id" gorm:"primaryKey"`
+	SourceUserID      uint        `json:"source_user_id" gorm:"not null;index"`
+	TargetUserID      uint        `json:"target_user_id" gorm:"not null;index"`
+	RequestedByID     uint        `json:"requested_by_id" gorm:"not null"`
+	Status            MergeStatus `json:"status" gorm:"not null;default:pending;index"`
+	SourceConfirmedAt *time.Time  `json:"source_confirmed_at"`
+	TargetConfirmedAt *time.Time  `json:"target_confirmed_at"`
+	ExpiresAt         time.Time   `json:"expires_at" gorm:"not null"`
+	CompletedAt       *time.Time  `json:"completed_at"`
+	GamesMoved        int         `json:"games_moved"`
+	ResultsMoved      int         `json:"results_moved"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
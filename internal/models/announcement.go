@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type AnnouncementKind string
+
+const (
+	MaintenanceAnnouncement AnnouncementKind = "maintenance"
+	FeatureAnnouncement     AnnouncementKind = "feature"
+	TournamentAnnouncement  AnnouncementKind = "tournament"
+)
+
+// Announcement is an admin broadcast shown to every user while it is active
+type Announcement struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	Kind      AnnouncementKind `json:"kind" gorm:"not null"`
+	Title     string           `json:"title" gorm:"not null"`
+	Body      string           `json:"body"`
+	StartsAt  time.Time        `json:"starts_at" gorm:"not null;index"`
+	EndsAt    *time.Time       `json:"ends_at"` // Nil means until deleted
+	CreatedBy uint             `json:"created_by" gorm:"not null"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// AnnouncementDismissal records a user hiding an announcement
+type AnnouncementDismissal struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	AnnouncementID uint      `json:"announcement_id" gorm:"not null;uniqueIndex:idx_announcement_dismissal"`
+	UserID         uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_announcement_dismissal"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+type QuotaCategory string
+
+const (
+	SolveQuota   QuotaCategory = "solve"
+	AnalyzeQuota QuotaCategory = "analyze"
+)
+
+// APIKey lets a client call the public API under a daily quota
+type APIKey struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	UserID            uint       `json:"user_id" gorm:"not null;index"`
+	Name              string     `json:"name" gorm:"not null"`
+	Prefix            string     `json:"prefix" gorm:"not null"`        // First characters of the key, shown to identify it
+	KeyHash           string     `json:"-" gorm:"uniqueIndex;not null"` // SHA-256 of the key, the key itself is never stored
+	DailySolveLimit   int        `json:"daily_solve_limit" gorm:"not null"`
+	DailyAnalyzeLimit int        `json:"daily_analyze_limit" gorm:"not null"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// APIUsage counts the calls made with a key in one category on one UTC day
+type APIUsage struct {
+	ID       uint          `json:"id" gorm:"primaryKey"`
+	APIKeyID uint          `json:"api_key_id" gorm:"not null;uniqueIndex:idx_api_usage"`
+	Day      time.Time     `json:"day" gorm:"type:date;not null;uniqueIndex:idx_api_usage"`
+	Category QuotaCategory `json:"category" gorm:"not null;uniqueIndex:idx_api_usage"`
+	Count    int           `json:"count" gorm:"not null;default:0"`
+}
+
+// AnonymousUsage counts the calls made without a key from one client address in one category on
+// one UTC day
+type AnonymousUsage struct {
+	ID       uint          `json:"id" gorm:"primaryKey"`
+	Client   string        `json:"client" gorm:"not null;uniqueIndex:idx_anonymous_usage"`
+	Day      time.Time     `json:"day" gorm:"type:date;not null;uniqueIndex:idx_anonymous_usage;index"`
+	Category QuotaCategory `json:"category" gorm:"not null;uniqueIndex:idx_anonymous_usage"`
+	Count    int           `json:"count" gorm:"not null;default:0"`
+}
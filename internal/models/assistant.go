@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// AssistantSession tracks the solve-with-me assistant working towards one move of a game.
+// A new session starts whenever the grid changes.
+type AssistantSession struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	GameResultID uint              `json:"game_result_id" gorm:"not null;index"`
+	UserID       uint              `json:"user_id" gorm:"not null"`
+	Grid         string            `json:"grid" gorm:"not null"` // Board the session's move was found on
+	TargetRow    int               `json:"target_row"`
+	TargetCol    int               `json:"target_col"`
+	TargetValue  int               `json:"target_value"`
+	Technique    string            `json:"technique"`
+	Stage        int               `json:"stage" gorm:"default:0"` // Deepest stage revealed so far
+	Prompts      []AssistantPrompt `json:"prompts" gorm:"foreignKey:SessionID"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// AssistantPrompt is one answer given during an assistant session
+type AssistantPrompt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SessionID uint      `json:"session_id" gorm:"not null;index"`
+	Stage     int       `json:"stage" gorm:"not null"`
+	Text      string    `json:"text" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+type BlobKind string
+
+const (
+	ReplayBlob     BlobKind = "replay"      // Move-by-move event stream of a game result
+	ShareImageBlob BlobKind = "share_image" // Rendered image of a finished board for sharing
+	DatasetBlob    BlobKind = "dataset"     // Anonymized solve dataset published for research
+)
+
+// Blob records an object kept in blob storage so it can be looked up and expired.
+// The content itself lives in the configured store, not in this table.
+type Blob struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Key          string     `json:"key" gorm:"uniqueIndex;not null"`
+	Kind         BlobKind   `json:"kind" gorm:"not null;index"`
+	GameResultID *uint      `json:"game_result_id" gorm:"index"` // Game result the blob belongs to, if any
+	ContentType  string     `json:"content_type"`
+	Size         int        `json:"size"`
+	ExpiresAt    *time.Time `json:"expires_at" gorm:"index"` // Removed by the cleanup job after this time, never if nil
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// LargeObject maps a blob key to the Postgres large object holding its content
+type LargeObject struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Key       string    `json:"key" gorm:"uniqueIndex;not null"`
+	OID       uint32    `json:"oid" gorm:"column:oid;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// CoachGrant gives a coach read access to a player's games
+type CoachGrant struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PlayerID  uint      `json:"player_id" gorm:"not null;uniqueIndex:idx_coach_grant"`
+	Player    User      `json:"player" gorm:"foreignKey:PlayerID"`
+	CoachID   uint      `json:"coach_id" gorm:"not null;uniqueIndex:idx_coach_grant"`
+	Coach     User      `json:"coach" gorm:"foreignKey:CoachID"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GameAnnotation is a coach's note on a game, optionally tied to a cell
+type GameAnnotation struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	GameResultID uint      `json:"game_result_id" gorm:"not null;index"`
+	CoachID      uint      `json:"coach_id" gorm:"not null"`
+	Coach        User      `json:"coach" gorm:"foreignKey:CoachID"`
+	Row          *int      `json:"row"`
+	Col          *int      `json:"col"`
+	Note         string    `json:"note" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TechniqueRecommendation is a technique a coach suggests a player should study
+type TechniqueRecommendation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PlayerID  uint      `json:"player_id" gorm:"not null;index"`
+	CoachID   uint      `json:"coach_id" gorm:"not null"`
+	Coach     User      `json:"coach" gorm:"foreignKey:CoachID"`
+	Technique string    `json:"technique" gorm:"not null"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type ExportStatus string
+
+const (
+	ExportPending   ExportStatus = "pending"   // Waiting for the export worker
+	ExportCompleted ExportStatus = "completed" // Dataset written and downloadable
+	ExportFailed    ExportStatus = "failed"
+)
+
+// DatasetExport is an admin request for an anonymized dataset of solves, built by a background job
+type DatasetExport struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	Status        ExportStatus `json:"status" gorm:"not null;default:pending;index"`
+	Fields        string       `json:"fields" gorm:"not null"`         // Comma separated columns of the dataset
+	MinGroupSize  int          `json:"min_group_size" gorm:"not null"` // k: rows are only published in groups of at least k players
+	TimeBucket    int          `json:"time_bucket" gorm:"not null"`    // Solve times are rounded down to multiples of this many seconds
+	Rows          int          `json:"rows" gorm:"default:0"`          // Rows published
+	Suppressed    int          `json:"suppressed" gorm:"default:0"`    // Rows dropped for belonging to a group smaller than k
+	BlobKey       string       `json:"-"`
+	Error         string       `json:"error,omitempty"`
+	RequestedByID uint         `json:"requested_by_id"`
+	CompletedAt   *time.Time   `json:"completed_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+)
+
+// Event is a limited-time puzzle event scheduled by an admin, such as a holiday special.
+// Results on its puzzles completed inside the window earn boosted points and count towards its badge.
+type Event struct {
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	Name            string        `json:"name" gorm:"not null"`
+	Description     string        `json:"description"`
+	StartsAt        time.Time     `json:"starts_at" gorm:"not null;index"`
+	EndsAt          time.Time     `json:"ends_at" gorm:"not null;index"`
+	BonusMultiplier float64       `json:"bonus_multiplier" gorm:"not null;default:1"` // Applied to the score of event games
+	BadgeName       string        `json:"badge_name"`                                 // Empty for events without a badge
+	BadgeThreshold  int           `json:"badge_threshold" gorm:"default:0"`           // Event puzzles to solve for the badge
+	Puzzles         []EventPuzzle `json:"puzzles,omitempty" gorm:"foreignKey:EventID"`
+	CreatedByID     uint          `json:"created_by_id"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// Active reports whether the event is running at t
+func (e *Event) Active(t time.Time) bool {
+	return !t.Before(e.StartsAt) && t.Before(e.EndsAt)
+}
+
+// EventPuzzle puts a puzzle in an event's set
+type EventPuzzle struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	EventID  uint   `json:"event_id" gorm:"not null;uniqueIndex:idx_event_puzzle"`
+	PuzzleID uint   `json:"puzzle_id" gorm:"not null;uniqueIndex:idx_event_puzzle"`
+	Puzzle   Puzzle `json:"puzzle" gorm:"foreignKey:PuzzleID"`
+	Position int    `json:"position"` // Order of the puzzle in the set
+}
+
+// EventBadge is the cosmetic badge a user earned in an event
+type EventBadge struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_event_badge"`
+	EventID   uint      `json:"event_id" gorm:"not null;uniqueIndex:idx_event_badge"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"awarded_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// FeaturedPuzzle puts a puzzle in the featured slot for a time window, with a spotlight on its author
+type FeaturedPuzzle struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PuzzleID    uint      `json:"puzzle_id" gorm:"not null"`
+	Puzzle      Puzzle    `json:"puzzle" gorm:"foreignKey:PuzzleID"`
+	Title       string    `json:"title" gorm:"not null"`
+	AuthorName  string    `json:"author_name"`
+	AuthorBlurb string    `json:"author_blurb"`
+	StartsAt    time.Time `json:"starts_at" gorm:"not null;index"`
+	EndsAt      time.Time `json:"ends_at" gorm:"not null;index"`
+	CreatedByID uint      `json:"created_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
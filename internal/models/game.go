@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Game assigns a puzzle to a user. Every try at it is recorded as a GameResult attempt.
+type Game struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	UserID    uint           `json:"user_id" gorm:"not null;index"`
+	PuzzleID  uint           `json:"puzzle_id" gorm:"not null"`
+	Mode      GameMode       `json:"mode" gorm:"not null"`
+	Attempts  int            `json:"attempts" gorm:"default:1"` // Number of attempts started on the game
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type DisputeOutcome string
+
+const (
+	DisputeRegraded DisputeOutcome = "regraded" // The stored grid was correct, the game now counts as solved
+	DisputeReopened DisputeOutcome = "reopened" // The stored grid was garbled, the game can be resubmitted
+	DisputeRejected DisputeOutcome = "rejected" // The stored grid really was wrong
+)
+
+// GameDispute is the audit trail of a player disputing a game graded incorrect
+type GameDispute struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	GameResultID  uint           `json:"game_result_id" gorm:"not null;index"`
+	UserID        uint           `json:"user_id" gorm:"not null;index"`
+	Reason        string         `json:"reason"`
+	Outcome       DisputeOutcome `json:"outcome" gorm:"not null"`
+	Detail        string         `json:"detail"`
+	PreviousGrid  string         `json:"previous_grid"`
+	PreviousScore int            `json:"previous_score"`
+	NewScore      int            `json:"new_score"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
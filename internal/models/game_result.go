@@ -13,8 +13,15 @@ const (
 	LearnMode GameMode = "learn"
 )
 
+// PencilMarks are the candidates a player noted in each cell, indexed by row*9+col.
+// An empty list means no notes in that cell. Served by the notes endpoints rather than with the game.
+type PencilMarks [][]int
+
+// GameResult is one attempt at a Game
 type GameResult struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
+	GameID        uint           `json:"game_id" gorm:"index"`
+	Attempt       int            `json:"attempt" gorm:"default:1"` // 1 for the first attempt at the game
 	UserID        uint           `json:"user_id" gorm:"not null"`
 	User          User           `json:"user" gorm:"foreignKey:UserID"`
 	PuzzleID      uint           `json:"puzzle_id" gorm:"not null"`
@@ -26,10 +33,35 @@ type GameResult struct {
 	UsedHints     bool           `json:"used_hints" gorm:"default:false"`
 	UsedAutoSolve bool           `json:"used_auto_solve" gorm:"default:false"`
 	Disqualified  bool           `json:"disqualified" gorm:"default:false"`
-	FinalGrid     string         `json:"final_grid" gorm:"not null"` // 81 characters representing the final board state
+	Skipped       bool           `json:"skipped" gorm:"default:false"`      // Abandoned through the skip flow
+	UnderReview   bool           `json:"under_review" gorm:"default:false"` // Flagged by anti-cheat, hidden from leaderboards
+	Voided        bool           `json:"voided" gorm:"default:false"`       // Voided by a moderator, never counted
+	Practice      bool           `json:"practice" gorm:"default:false"`     // Retry of a failed attempt, a custom game or a paper solve, kept off leaderboards and user totals
+	Paper         bool           `json:"paper" gorm:"default:false"`        // Solved on a printout and graded from a photo of it
+	Expired       bool           `json:"expired" gorm:"default:false"`      // Abandoned by the expiry job after a long time without play
+	HintLevels    int            `json:"hint_levels" gorm:"default:0"`      // Tiered hint levels consumed, each costing points in Play mode
+	HintedCell    int            `json:"-" gorm:"default:0"`                // Cell of the last tiered hint as row*9+col+1, 0 before the first
+	HintedLevel   int            `json:"-" gorm:"default:0"`                // Deepest level revealed for HintedCell
+	EventID       *uint          `json:"event_id" gorm:"index"`             // Event the game was started in
+	FinalGrid     string         `json:"final_grid" gorm:"not null"`        // 81 characters representing the final board state
+	Notes         PencilMarks    `json:"-" gorm:"serializer:json;type:jsonb"`
 	StartedAt     time.Time      `json:"started_at"`
+	LastSeenAt    *time.Time     `json:"last_seen_at"`                    // Last heartbeat from the client
+	ActiveSeconds int            `json:"active_seconds" gorm:"default:0"` // Solve time measured from heartbeats, excluding pauses
 	CompletedAt   *time.Time     `json:"completed_at"`
+	CheckedAt     *time.Time     `json:"checked_at"`             // When the replay was checked against the result, whether or not it passed
+	NeedsReplay   bool           `json:"-" gorm:"default:false"` // Submitted since leaderboards require a checked replay; older results are listed without one
+	WarnedAt      *time.Time     `json:"-"`                      // When the player was last warned the game is about to expire
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 }
+
+// PuzzleSkip records a puzzle a user skipped so it is never served to them again
+type PuzzleSkip struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_puzzle_skip"`
+	PuzzleID     uint      `json:"puzzle_id" gorm:"not null;uniqueIndex:idx_user_puzzle_skip"`
+	GameResultID uint      `json:"game_result_id" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
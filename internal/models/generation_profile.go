@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type Symmetry string
+
+const (
+	NoSymmetry         Symmetry = "none"
+	RotationalSymmetry Symmetry = "rotational"
+	// Rotational symmetry that is also mirrored across the middle column
+	MirrorSymmetry Symmetry = "mirror"
+)
+
+// GenerationProfile holds the generator parameters for one difficulty level
+type GenerationProfile struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Difficulty   Difficulty `json:"difficulty" gorm:"uniqueIndex;not null"`
+	MinClues     int        `json:"min_clues" gorm:"not null"`
+	MaxClues     int        `json:"max_clues" gorm:"not null"`
+	Symmetry     Symmetry   `json:"symmetry" gorm:"default:none"`
+	MaxTechnique string     `json:"max_technique"` // Hardest technique allowed in the logical solve, empty for no limit
+	MinTechnique string     `json:"min_technique"` // The logical solve must need this technique or a harder one, empty for no minimum
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GoalKind string
+
+const (
+	TimeGoal  GoalKind = "time"  // Solve a puzzle of the difficulty within Target seconds
+	CountGoal GoalKind = "count" // Solve Target puzzles every period
+)
+
+// GoalPeriod is how often a count goal starts over
+type GoalPeriod string
+
+const (
+	DailyGoal  GoalPeriod = "daily"
+	WeeklyGoal GoalPeriod = "weekly" // Weeks start on Monday
+)
+
+// Goal is a personal target a user set themselves. Only scored games completed after it was set count.
+type Goal struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	Kind       GoalKind       `json:"kind" gorm:"not null"`
+	Difficulty Difficulty     `json:"difficulty"`             // Empty counts puzzles of every difficulty
+	Target     int            `json:"target" gorm:"not null"` // Seconds for a time goal, puzzles for a count goal
+	Period     GoalPeriod     `json:"period,omitempty"`       // Empty for time goals
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"` // Removed goals keep their completions for the user's stats
+}
+
+// GoalCompletion records a goal being met, once per period for count goals and once for time goals
+type GoalCompletion struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	GoalID      uint      `json:"goal_id" gorm:"not null;uniqueIndex:idx_goal_period"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	PeriodStart time.Time `json:"period_start" gorm:"not null;uniqueIndex:idx_goal_period"` // When the goal was set, for time goals
+	CreatedAt   time.Time `json:"completed_at" gorm:"index"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+type SnapshotPeriod string
+
+const (
+	DailySnapshot  SnapshotPeriod = "daily"
+	WeeklySnapshot SnapshotPeriod = "weekly"
+)
+
+// LeaderboardSnapshot is one archived leaderboard row for a closed day or week
+type LeaderboardSnapshot struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Period      SnapshotPeriod `json:"period" gorm:"not null;uniqueIndex:idx_snapshot_rank"`
+	PeriodStart time.Time      `json:"period_start" gorm:"not null;uniqueIndex:idx_snapshot_rank"`
+	Difficulty  string         `json:"difficulty" gorm:"not null;uniqueIndex:idx_snapshot_rank"` // Empty for the all-difficulties board
+	SortBy      string         `json:"sort_by" gorm:"not null;uniqueIndex:idx_snapshot_rank"`
+	Rank        int            `json:"rank" gorm:"not null;uniqueIndex:idx_snapshot_rank"`
+	UserID      uint           `json:"user_id" gorm:"not null"`
+	Username    string         `json:"username" gorm:"not null"`
+	Score       int            `json:"score"`
+	TimeSeconds int            `json:"time_seconds"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// ModerationTerm is an admin-managed blocklist entry. Allowed terms override
+// blocked terms and the profanity checker, e.g. to let "scunthorpe" through.
+type ModerationTerm struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Term      string    `json:"term" gorm:"uniqueIndex;not null"`
+	Allowed   bool      `json:"allowed" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+}
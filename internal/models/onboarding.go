@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// OnboardingQuiz is a placement quiz of timed mini-boards handed out one at a time
+type OnboardingQuiz struct {
+	ID                uint              `json:"id" gorm:"primaryKey"`
+	UserID            uint              `json:"user_id" gorm:"not null;index"`
+	Boards            []OnboardingBoard `json:"boards" gorm:"foreignKey:QuizID"`
+	PlacedDifficulty  Difficulty        `json:"placed_difficulty"`
+	RecommendedLesson string            `json:"recommended_lesson"`
+	CompletedAt       *time.Time        `json:"completed_at"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+// OnboardingBoard is one mini-board of a placement quiz
+type OnboardingBoard struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	QuizID           uint       `json:"quiz_id" gorm:"not null;index"`
+	Position         int        `json:"position" gorm:"not null"`
+	Difficulty       Difficulty `json:"difficulty" gorm:"not null"`
+	StartingGrid     string     `json:"starting_grid" gorm:"not null"`
+	Solution         string     `json:"-" gorm:"not null"`
+	TimeLimitSeconds int        `json:"time_limit_seconds" gorm:"not null"`
+	IssuedAt         *time.Time `json:"issued_at"` // The timer starts when the board is handed out
+	SubmittedGrid    string     `json:"submitted_grid"`
+	TimeSeconds      int        `json:"time_seconds"`
+	Passed           bool       `json:"passed" gorm:"default:false"`
+	SubmittedAt      *time.Time `json:"submitted_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// PooledPuzzle is a pre-generated puzzle waiting to be served by StartGame.
+// It becomes a Puzzle when drawn from the pool.
+type PooledPuzzle struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	Difficulty         Difficulty `json:"difficulty" gorm:"not null;index"`
+	StartingGrid       string     `json:"starting_grid" gorm:"not null"`
+	Solution           string     `json:"solution" gorm:"not null"`
+	RequiresUniqueness bool       `json:"requires_uniqueness" gorm:"default:false"`
+	Rating             Difficulty `json:"rating"`
+	HardestTechnique   string     `json:"hardest_technique"`
+	CanonicalHash      string     `json:"-"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+type PushPlatform string
+
+const (
+	FCMPlatform     PushPlatform = "fcm"
+	APNSPlatform    PushPlatform = "apns"
+	WebPushPlatform PushPlatform = "webpush"
+)
+
+// PushDevice is a device token a user registered for push notifications
+type PushDevice struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	UserID    uint         `json:"user_id" gorm:"not null;index"`
+	Platform  PushPlatform `json:"platform" gorm:"not null"`
+	Token     string       `json:"token" gorm:"serializer:encrypted;not null"`
+	TokenHash *string      `json:"-" gorm:"uniqueIndex"` // Blind index of Token
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// PushNotification is a queued notification waiting for the delivery worker
+type PushNotification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Title     string     `json:"title" gorm:"not null"`
+	Body      string     `json:"body"`
+	Attempts  int        `json:"attempts" gorm:"default:0"`
+	LastError string     `json:"last_error"`
+	SentAt    *time.Time `json:"sent_at" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -12,14 +12,37 @@ const (
 	Easy   Difficulty = "easy"
 	Medium Difficulty = "medium"
 	Hard   Difficulty = "hard"
+	Expert Difficulty = "expert" // Needs at least one fish, wing or other advanced technique
+)
+
+// Variant is the rule set a puzzle is played under
+type Variant string
+
+const (
+	Classic  Variant = "classic"
+	Killer   Variant = "killer"   // Cages of cells add up to given sums, few or no digits are given
+	Diagonal Variant = "diagonal" // X-Sudoku: both long diagonals also hold every digit once
+	Jigsaw   Variant = "jigsaw"   // Irregular regions of nine cells take the place of the 3x3 boxes
+	EvenOdd  Variant = "evenodd"  // Marked cells must hold an even or an odd digit
 )
 
 type Puzzle struct {
-	ID           uint           `json:"id" gorm:"primaryKey"`
-	Difficulty   Difficulty     `json:"difficulty" gorm:"not null"`
-	StartingGrid string         `json:"starting_grid" gorm:"not null"` // 81 characters representing the initial board
-	Solution     string         `json:"solution" gorm:"not null"`      // 81 characters representing the complete solution
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	Difficulty         Difficulty     `json:"difficulty" gorm:"not null"`
+	StartingGrid       string         `json:"starting_grid" gorm:"not null"`                    // 81 characters representing the initial board
+	Solution           string         `json:"solution" gorm:"not null"`                         // 81 characters representing the complete solution
+	RequiresUniqueness bool           `json:"requires_uniqueness" gorm:"default:false"`         // Logical solve relies on uniqueness techniques
+	Rating             Difficulty     `json:"rating" gorm:"index"`                              // Difficulty by the hardest technique of the logical solve
+	HardestTechnique   string         `json:"hardest_technique"`                                // Empty when the puzzle can't be solved logically
+	CanonicalHash      string         `json:"-" gorm:"uniqueIndex:,where:canonical_hash <> ''"` // Same for every disguise of the grid; empty on older puzzles and custom repeats of saved ones
+	UserSubmitted      bool           `json:"user_submitted" gorm:"default:false"`              // Entered by a player through a custom game, never listed
+	Variant            Variant        `json:"variant" gorm:"not null;default:classic"`          // Classic on every puzzle saved before variants
+	Cages              string         `json:"cages,omitempty"`                                  // Killer cage layout, "sum:cell,cell;..." with cells as grid positions 0-80
+	Regions            string         `json:"regions,omitempty"`                                // Jigsaw region layout, 81 digits giving the region (1-9) of every cell
+	Parities           string         `json:"parities,omitempty"`                               // Even/Odd markers, 81 characters: 'e' even, 'o' odd, '.' unmarked
+	AntiKnight         bool           `json:"anti_knight,omitempty" gorm:"default:false"`       // Cells a knight's move apart never hold the same digit
+	AntiKing           bool           `json:"anti_king,omitempty" gorm:"default:false"`         // Cells a king's move apart never hold the same digit
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
 }
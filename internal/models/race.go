@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+type RaceStatus string
+
+const (
+	RaceWaiting  RaceStatus = "waiting" // Created, waiting for an opponent to join
+	RaceActive   RaceStatus = "active"
+	RaceFinished RaceStatus = "finished"
+)
+
+// Race is an arcade versus game: two players race through the same puzzle and earn
+// power-ups from streaks of correct moves. Races are never ranked.
+type Race struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	PuzzleID   uint       `json:"puzzle_id" gorm:"not null"`
+	Puzzle     Puzzle     `json:"-" gorm:"foreignKey:PuzzleID"`
+	HostID     uint       `json:"host_id" gorm:"not null;index"`
+	GuestID    *uint      `json:"guest_id" gorm:"index"`
+	Status     RaceStatus `json:"status" gorm:"not null;default:waiting"`
+	WinnerID   *uint      `json:"winner_id"`
+	HostGrid   string     `json:"host_grid"`  // Host's board when the race finished
+	GuestGrid  string     `json:"guest_grid"` // Guest's board when the race finished
+	SeriesID   *uint      `json:"series_id" gorm:"index"`
+	RematchID  *uint      `json:"rematch_id"` // The race opened as this one's rematch
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// RaceSeries is a best-of-N match between two players: its races are played one after another
+// through rematches until a player has won more than half of BestOf.
+type RaceSeries struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	BestOf     int        `json:"best_of" gorm:"not null"`
+	HostID     uint       `json:"host_id" gorm:"not null;index"`
+	GuestID    *uint      `json:"guest_id" gorm:"index"`
+	HostWins   int        `json:"host_wins" gorm:"not null;default:0"`
+	GuestWins  int        `json:"guest_wins" gorm:"not null;default:0"`
+	WinnerID   *uint      `json:"winner_id"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
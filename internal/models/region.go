@@ -0,0 +1,26 @@
+package models
+
+// Region is the latency region a player is served from. Leaderboards are sharded by it.
+type Region string
+
+const (
+	NorthAmerica Region = "na"
+	SouthAmerica Region = "sa"
+	Europe       Region = "eu"
+	Africa       Region = "af"
+	Asia         Region = "as"
+	Oceania      Region = "oc"
+)
+
+// AllRegions lists every region, in the order regional boards are shown
+var AllRegions = []Region{NorthAmerica, SouthAmerica, Europe, Africa, Asia, Oceania}
+
+// ValidRegion reports whether region is one of AllRegions
+func ValidRegion(region Region) bool {
+	for _, r := range AllRegions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
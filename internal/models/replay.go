@@ -0,0 +1,9 @@
+package models
+
+// ReplayEvent is one move in a game's replay. Replays are kept in blob storage, not in the database.
+type ReplayEvent struct {
+	AtMillis int `json:"t"` // Milliseconds since the game started
+	Row      int `json:"row"`
+	Col      int `json:"col"`
+	Value    int `json:"value"` // 0 clears the cell
+}
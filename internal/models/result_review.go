@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending ReviewStatus = "pending"
+	ReviewCleared ReviewStatus = "cleared"
+	ReviewVoided  ReviewStatus = "voided"
+)
+
+// ResultReview is a moderation queue entry for a game result flagged by anti-cheat
+type ResultReview struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	GameResultID uint         `json:"game_result_id" gorm:"not null;uniqueIndex"`
+	GameResult   GameResult   `json:"game_result" gorm:"foreignKey:GameResultID"`
+	Reasons      string       `json:"reasons" gorm:"not null"` // "; " separated anti-cheat findings
+	Status       ReviewStatus `json:"status" gorm:"not null;default:pending;index"`
+	ReviewerID   *uint        `json:"reviewer_id"`
+	ReviewNote   string       `json:"review_note"`
+	ReviewedAt   *time.Time   `json:"reviewed_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// RivalNudge records a user challenging one of their rivals to a race, to space out nudges
+type RivalNudge struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_nudge_pair"`
+	RivalID   uint      `json:"rival_id" gorm:"not null;index:idx_nudge_pair"`
+	RaceID    uint      `json:"race_id" gorm:"not null"` // Race opened for the rival to join
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// SamuraiGame is a game of Samurai Sudoku: five 9x9 grids overlapping at their corner boxes on one
+// 21x21 board. Each game gets a puzzle of its own, stored with it. Samurai games are played through
+// their own endpoints and never ranked.
+type SamuraiGame struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	Difficulty   Difficulty `json:"difficulty" gorm:"not null"`
+	StartingGrid string     `json:"starting_grid" gorm:"not null"` // 441 characters, the 21x21 board row by row with '.' outside the grids
+	Solution     string     `json:"-" gorm:"not null"`             // 441 characters like StartingGrid, only shown once the game is over
+	Grid         string     `json:"grid" gorm:"not null"`          // The player's board as last saved, 441 characters like StartingGrid
+	Hints        int        `json:"hints" gorm:"default:0"`
+	TimeSeconds  int        `json:"time_seconds" gorm:"default:0"`
+	Completed    bool       `json:"completed" gorm:"default:false"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Active excludes soft-deleted rows of the given tables. GORM only does this on its own for
+// queries built from a model, so raw Table() queries and joins must apply it explicitly.
+func Active(tables ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, table := range tables {
+			db = db.Where(table + ".deleted_at IS NULL")
+		}
+		return db
+	}
+}
+
+// WithDeleted includes soft-deleted rows. Preloads of the puzzle behind an existing game or race
+// use it, since deleting a puzzle only keeps it out of new games.
+func WithDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
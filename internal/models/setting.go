@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// Setting stores one runtime setting an admin has changed, as JSON. Settings without a row keep
+// their defaults.
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	UpdatedBy uint      `json:"updated_by" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
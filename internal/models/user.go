@@ -4,16 +4,30 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	_ "sudoku/internal/vault" // Registers the "encrypted" serializer of sensitive columns
 )
 
 type User struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Username    string         `json:"username" gorm:"uniqueIndex;not null"`
-	Email       string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password    string         `json:"-" gorm:"not null"`
-	TotalPoints int            `json:"total_points" gorm:"default:0"`
-	GamesPlayed int            `json:"games_played" gorm:"default:0"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	Username           string         `json:"username" gorm:"uniqueIndex;not null"`
+	Email              string         `json:"email" gorm:"serializer:encrypted;not null"`
+	EmailHash          *string        `json:"-" gorm:"uniqueIndex"` // Blind index of Email, for lookups and uniqueness
+	Password           string         `json:"-" gorm:"not null"`
+	TotalPoints        int            `json:"total_points" gorm:"default:0"`
+	RankingPoints      int            `json:"ranking_points" gorm:"default:0;index"` // TotalPoints weighted by game age when score decay is on, for the global ranking
+	GamesPlayed        int            `json:"games_played" gorm:"default:0"`
+	PracticeGames      int            `json:"practice_games" gorm:"default:0"` // Retries solved correctly, not part of the scored totals
+	LearnTokens        int            `json:"learn_tokens" gorm:"default:100"` // Learn mode hint currency, see TokenTransaction
+	IsAdmin            bool           `json:"is_admin" gorm:"default:false"`
+	Timezone           string         `json:"timezone"`                                // IANA name, e.g. "Europe/Paris"; empty means UTC
+	Locale             string         `json:"locale"`                                  // BCP 47 tag, e.g. "fr-FR"; empty means en-US
+	Region             Region         `json:"region" gorm:"not null;default:'';index"` // Latency region, set at registration or first login; empty when unknown
+	StartingDifficulty Difficulty     `json:"starting_difficulty"`                     // Set by the onboarding quiz
+	RecommendedLesson  string         `json:"recommended_lesson"`                      // Technique to study next, set by the onboarding quiz
+	DigestOptIn        bool           `json:"digest_opt_in" gorm:"default:false"`      // Receive the weekly stats email
+	LastDigestAt       *time.Time     `json:"-"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+type TokenReason string
+
+const (
+	HintPurchase        TokenReason = "hint"        // A tiered Learn mode hint
+	EliminationPurchase TokenReason = "elimination" // A Learn mode elimination hint
+	StepPurchase        TokenReason = "step"        // Learn mode solve steps
+	LearnReward         TokenReason = "reward"      // A Learn mode puzzle solved
+	MergeTransfer       TokenReason = "merge"       // Balance of an account merged into this one
+)
+
+// TokenTransaction is one entry of a user's learn token log. Tokens are a Learn mode currency
+// with no money value: hints cost them and solved Learn puzzles earn them back.
+type TokenTransaction struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	UserID       uint        `json:"user_id" gorm:"not null;index"`
+	GameResultID *uint       `json:"game_result_id,omitempty" gorm:"index"`
+	Amount       int         `json:"amount" gorm:"not null"` // Negative when tokens were spent
+	Reason       TokenReason `json:"reason" gorm:"not null"`
+	Technique    string      `json:"technique,omitempty"` // Technique a bought hint revealed
+	Level        int         `json:"level,omitempty"`     // Level of a bought tiered hint
+	Balance      int         `json:"balance"`             // Balance after the transaction
+	CreatedAt    time.Time   `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,163 @@
+package moderation
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 20
+	MaxTextLength     = 1000
+)
+
+var (
+	ErrInvalidUsername = errors.New("username must be 3-20 letters, digits, '_' or '-'")
+	ErrTextTooLong     = errors.New("text is too long")
+	ErrBlockedContent  = errors.New("content contains blocked language")
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Common character substitutions used to dodge filters
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+// Checker is a pluggable profanity check, run on normalized text
+type Checker interface {
+	IsProfane(text string) bool
+}
+
+// WordListChecker flags text containing any of its words
+type WordListChecker struct {
+	words []string
+}
+
+func NewWordListChecker(words []string) *WordListChecker {
+	checker := &WordListChecker{}
+	for _, word := range words {
+		if word = normalize(word); word != "" {
+			checker.words = append(checker.words, word)
+		}
+	}
+	return checker
+}
+
+// LoadWordList reads a checker from a file with one word per line; '#' starts a comment
+func LoadWordList(path string) (*WordListChecker, error) {
+	words, err := ReadWords(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewWordListChecker(words), nil
+}
+
+// ReadWords reads a file with one word per line, skipping blank lines and '#' comments
+func ReadWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+func (c *WordListChecker) IsProfane(text string) bool {
+	for _, word := range c.words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+type Service struct {
+	db              *gorm.DB
+	checker         Checker
+	adjectives      []string // Words for suggested usernames, the built-in list when empty
+	nouns           []string
+	suggestionCount int
+}
+
+// NewService creates the moderation service; checker may be nil to rely on the blocklist only
+func NewService(db *gorm.DB, checker Checker) *Service {
+	return &Service{db: db, checker: checker}
+}
+
+// ValidateUsername checks the format of a username and that it contains no blocked language
+func (s *Service) ValidateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength || !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	return s.ValidateText(username)
+}
+
+// ValidateText checks user-submitted text such as notes and messages
+func (s *Service) ValidateText(text string) error {
+	if len(text) > MaxTextLength {
+		return ErrTextTooLong
+	}
+
+	var terms []models.ModerationTerm
+	if err := s.db.Find(&terms).Error; err != nil {
+		return err
+	}
+
+	normalized := normalize(text)
+
+	// Allowed terms are cut out first so they can't trigger a match
+	for _, term := range terms {
+		if t := normalize(term.Term); term.Allowed && t != "" {
+			normalized = strings.ReplaceAll(normalized, t, "")
+		}
+	}
+
+	for _, term := range terms {
+		if t := normalize(term.Term); !term.Allowed && t != "" && strings.Contains(normalized, t) {
+			return ErrBlockedContent
+		}
+	}
+	if s.checker != nil && s.checker.IsProfane(normalized) {
+		return ErrBlockedContent
+	}
+	return nil
+}
+
+// NormalizeTerm returns the form a blocklist term is matched in, empty if it has no letters
+func NormalizeTerm(term string) string {
+	return normalize(term)
+}
+
+// Lowercase, undo common substitutions and drop everything but letters so
+// "B.a-D_w0rd" matches "badword"
+func normalize(text string) string {
+	text = leetReplacer.Replace(strings.ToLower(text))
+	var b strings.Builder
+	for _, r := range text {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package moderation
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"sudoku/internal/models"
+)
+
+// DefaultSuggestionCount is how many alternatives are offered for a rejected username
+const DefaultSuggestionCount = 3
+
+// Built-in words for suggested usernames, replaceable with SetSuggestionWords
+var (
+	defaultAdjectives = []string{
+		"Agile", "Bold", "Brave", "Bright", "Calm", "Clever", "Cosmic", "Daring", "Eager", "Fuzzy",
+		"Gentle", "Golden", "Happy", "Jolly", "Keen", "Lucky", "Mellow", "Nimble", "Quick", "Quiet",
+		"Rapid", "Silver", "Sly", "Sunny", "Swift", "Tidy", "Witty", "Zesty",
+	}
+	defaultNouns = []string{
+		"Badger", "Comet", "Falcon", "Fox", "Gecko", "Heron", "Koala", "Lynx", "Maple", "Meteor",
+		"Otter", "Owl", "Panda", "Pebble", "Penguin", "Puffin", "Raven", "Robin", "Sparrow", "Tiger",
+		"Walrus", "Willow", "Wombat", "Zebra",
+	}
+)
+
+// SetSuggestionWords replaces the words suggested usernames are built from.
+// Empty lists keep the built-in words.
+func (s *Service) SetSuggestionWords(adjectives, nouns []string) {
+	if len(adjectives) > 0 {
+		s.adjectives = adjectives
+	}
+	if len(nouns) > 0 {
+		s.nouns = nouns
+	}
+}
+
+// SetSuggestionCount changes how many usernames SuggestUsernames returns
+func (s *Service) SetSuggestionCount(count int) {
+	s.suggestionCount = count
+}
+
+// SuggestUsernames returns available usernames in an adjective+noun+number style, such as
+// "SwiftOtter42". Every suggestion passes ValidateUsername, so it is free of blocked language.
+func (s *Service) SuggestUsernames() ([]string, error) {
+	adjectives, nouns := s.adjectives, s.nouns
+	if len(adjectives) == 0 {
+		adjectives = defaultAdjectives
+	}
+	if len(nouns) == 0 {
+		nouns = defaultNouns
+	}
+	count := s.suggestionCount
+	if count <= 0 {
+		count = DefaultSuggestionCount
+	}
+
+	// Draw a few more than needed, since some will be too long, blocked or taken
+	seen := make(map[string]bool)
+	var candidates []string
+	for i := 0; i < count*4; i++ {
+		name := fmt.Sprintf("%s%s%d", adjectives[rand.Intn(len(adjectives))], nouns[rand.Intn(len(nouns))], rand.Intn(100))
+		if seen[strings.ToLower(name)] || s.ValidateUsername(name) != nil {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		candidates = append(candidates, name)
+	}
+
+	// Soft-deleted accounts keep their username, so they count as taken too
+	var taken []string
+	if err := s.db.Unscoped().Model(&models.User{}).Where("username IN ?", candidates).Pluck("username", &taken).Error; err != nil {
+		return nil, err
+	}
+	takenSet := make(map[string]bool)
+	for _, name := range taken {
+		takenSet[name] = true
+	}
+
+	suggestions := []string{}
+	for _, name := range candidates {
+		if !takenSet[name] && len(suggestions) < count {
+			suggestions = append(suggestions, name)
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,71 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"sudoku/internal/sudoku"
+)
+
+// Reader reads the digits of a photographed grid
+type Reader interface {
+	// ReadGrid returns the grid in the photo as 81 digits row by row, 0 for cells it can't read
+	ReadGrid(ctx context.Context, image []byte, contentType string) (string, error)
+}
+
+// ErrNoGrid is returned when no grid was found in the photo
+var ErrNoGrid = errors.New("no grid found in the photo")
+
+// HTTPReader sends photos to a grid recognition service. The service answers a POST of the
+// image with {"grid": "..."}, an empty grid when there is none in the photo.
+type HTTPReader struct {
+	URL    string
+	Token  string // Optional bearer token
+	Client *http.Client
+}
+
+func NewHTTPReader(url, token string) HTTPReader {
+	return HTTPReader{URL: url, Token: token, Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (r HTTPReader) ReadGrid(ctx context.Context, image []byte, contentType string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(image))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("grid recognition returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	var result struct {
+		Grid string `json:"grid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.Grid == "" {
+		return "", ErrNoGrid
+	}
+	grid, ok := sudoku.NormalizeGrid(result.Grid)
+	if !ok {
+		return "", fmt.Errorf("grid recognition returned an invalid grid %q", result.Grid)
+	}
+	return grid, nil
+}
@@ -0,0 +1,184 @@
+package pool
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// DefaultSize is the number of puzzles kept ready per difficulty
+const DefaultSize = 10
+
+// ErrEmpty is returned by Take when no pooled puzzle can be served to the user
+var ErrEmpty = errors.New("puzzle pool is empty")
+
+// Difficulties kept in the pool
+var difficulties = []models.Difficulty{models.Easy, models.Medium, models.Hard, models.Expert}
+
+// Service keeps a pool of pre-generated puzzles per difficulty so starting a game
+// doesn't wait for the generator
+type Service struct {
+	db            *gorm.DB
+	sudokuService *sudoku.Service
+	size          int
+
+	mu        sync.Mutex
+	refilling map[models.Difficulty]bool
+}
+
+func NewService(db *gorm.DB, sudokuService *sudoku.Service, size int) *Service {
+	return &Service{
+		db:            db,
+		sudokuService: sudokuService,
+		size:          size,
+		refilling:     map[models.Difficulty]bool{},
+	}
+}
+
+// Take removes a pooled puzzle of the difficulty and saves it as a Puzzle. Puzzles the user has
+// skipped before are passed over. The difficulty is topped up again in the background.
+func (s *Service) Take(difficulty models.Difficulty, userID uint) (*models.Puzzle, error) {
+	var puzzle *models.Puzzle
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		skipped := tx.Model(&models.PuzzleSkip{}).
+			Joins("JOIN puzzles ON puzzle_skips.puzzle_id = puzzles.id").
+			Scopes(models.Active("puzzles")).
+			Where("puzzle_skips.user_id = ?", userID).
+			Select("puzzles.starting_grid")
+
+		for {
+			// Concurrent games draw different puzzles instead of waiting on each other's locks
+			var pooled models.PooledPuzzle
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("difficulty = ? AND starting_grid NOT IN (?)", difficulty, skipped).
+				Order("id").
+				First(&pooled).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEmpty
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Delete(&pooled).Error; err != nil {
+				return err
+			}
+
+			puzzle = &models.Puzzle{
+				Difficulty:         pooled.Difficulty,
+				Variant:            models.Classic,
+				StartingGrid:       pooled.StartingGrid,
+				Solution:           pooled.Solution,
+				RequiresUniqueness: pooled.RequiresUniqueness,
+				Rating:             pooled.Rating,
+				HardestTechnique:   pooled.HardestTechnique,
+				CanonicalHash:      pooled.CanonicalHash,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(puzzle)
+			if result.Error != nil || result.RowsAffected > 0 {
+				return result.Error
+			}
+			// The same puzzle was saved since it was pooled, so drop it and draw the next one
+		}
+	})
+
+	go s.refillInBackground(difficulty)
+	if err != nil {
+		return nil, err
+	}
+	return puzzle, nil
+}
+
+// Refill tops up every difficulty to the pool size. It is run periodically by the pool worker.
+func (s *Service) Refill() error {
+	for _, difficulty := range difficulties {
+		if err := s.refillGuarded(difficulty); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) refillInBackground(difficulty models.Difficulty) {
+	if err := s.refillGuarded(difficulty); err != nil {
+		log.Printf("Failed to refill %s puzzle pool: %v", difficulty, err)
+	}
+}
+
+// Refill one difficulty unless another goroutine is already doing it
+func (s *Service) refillGuarded(difficulty models.Difficulty) error {
+	s.mu.Lock()
+	if s.refilling[difficulty] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.refilling[difficulty] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.refilling, difficulty)
+		s.mu.Unlock()
+	}()
+	return s.refill(difficulty)
+}
+
+func (s *Service) refill(difficulty models.Difficulty) error {
+	var count int64
+	if err := s.db.Model(&models.PooledPuzzle{}).Where("difficulty = ?", difficulty).Count(&count).Error; err != nil {
+		return err
+	}
+
+	for ; count < int64(s.size); count++ {
+		puzzleBoard, solutionBoard, err := s.sudokuService.GeneratePuzzle(difficulty, sudoku.GenerateOptions{})
+		if err != nil {
+			return err
+		}
+
+		rating := s.sudokuService.RatePuzzle(puzzleBoard)
+		pooled := models.PooledPuzzle{
+			Difficulty:         difficulty,
+			StartingGrid:       sudoku.BoardToString(puzzleBoard),
+			Solution:           sudoku.BoardToString(solutionBoard),
+			RequiresUniqueness: s.sudokuService.UsesUniqueness(puzzleBoard),
+			Rating:             rating.Difficulty,
+			HardestTechnique:   rating.HardestTechnique,
+			CanonicalHash:      sudoku.CanonicalHash(puzzleBoard),
+		}
+		if err := s.db.Create(&pooled).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Levels returns the number of pooled puzzles of every difficulty
+func (s *Service) Levels() (map[models.Difficulty]int, error) {
+	var rows []struct {
+		Difficulty models.Difficulty
+		Count      int
+	}
+	err := s.db.Model(&models.PooledPuzzle{}).Select("difficulty, COUNT(*) AS count").Group("difficulty").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	levels := map[models.Difficulty]int{}
+	for _, difficulty := range difficulties {
+		levels[difficulty] = 0
+	}
+	for _, row := range rows {
+		levels[row.Difficulty] = row.Count
+	}
+	return levels, nil
+}
+
+// Size is the number of puzzles kept ready per difficulty
+func (s *Service) Size() int {
+	return s.size
+}
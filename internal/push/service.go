@@ -0,0 +1,134 @@
+package push
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/vault"
+)
+
+const (
+	// Notifications delivered per worker run
+	batchSize = 100
+	// Notifications are dropped after this many failed runs
+	maxAttempts = 5
+)
+
+// ErrInvalidToken is returned by a Sender when the provider no longer accepts a device token
+var ErrInvalidToken = errors.New("device token is no longer valid")
+
+// Sender delivers a notification to one device through its platform's provider
+type Sender interface {
+	Send(device models.PushDevice, notification models.PushNotification) error
+}
+
+// LogSender only logs notifications. It is used until provider credentials are configured.
+type LogSender struct{}
+
+func (LogSender) Send(device models.PushDevice, notification models.PushNotification) error {
+	log.Printf("Push to user %d via %s: %s", device.UserID, device.Platform, notification.Title)
+	return nil
+}
+
+type Service struct {
+	db      *gorm.DB
+	senders map[models.PushPlatform]Sender
+}
+
+// NewService creates the push service; platforms without a sender fall back to LogSender
+func NewService(db *gorm.DB, senders map[models.PushPlatform]Sender) *Service {
+	if senders == nil {
+		senders = map[models.PushPlatform]Sender{}
+	}
+	return &Service{db: db, senders: senders}
+}
+
+// ValidPlatform reports whether the platform is supported
+func ValidPlatform(platform models.PushPlatform) bool {
+	switch platform {
+	case models.FCMPlatform, models.APNSPlatform, models.WebPushPlatform:
+		return true
+	}
+	return false
+}
+
+// Register adds the device token to the user, moving it over if another account had it
+func (s *Service) Register(userID uint, platform models.PushPlatform, token string) (*models.PushDevice, error) {
+	var device models.PushDevice
+	err := s.db.Where("token_hash = ?", vault.Index(token)).First(&device).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	device.UserID = userID
+	device.Platform = platform
+	device.Token = token
+	device.TokenHash = vault.Index(token)
+	if err := s.db.Save(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// Unregister removes one of the user's device tokens
+func (s *Service) Unregister(userID uint, token string) error {
+	return s.db.Where("user_id = ? AND token_hash = ?", userID, vault.Index(token)).Delete(&models.PushDevice{}).Error
+}
+
+// Enqueue queues a notification for all of the user's devices
+func (s *Service) Enqueue(userID uint, title, body string) error {
+	return s.db.Create(&models.PushNotification{UserID: userID, Title: title, Body: body}).Error
+}
+
+// Deliver sends pending notifications. It is run periodically by the delivery worker.
+func (s *Service) Deliver() error {
+	var pending []models.PushNotification
+	err := s.db.Where("sent_at IS NULL AND attempts < ?", maxAttempts).
+		Order("created_at ASC").
+		Limit(batchSize).
+		Find(&pending).Error
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range pending {
+		var devices []models.PushDevice
+		if err := s.db.Where("user_id = ?", notification.UserID).Find(&devices).Error; err != nil {
+			return err
+		}
+
+		var lastErr error
+		for _, device := range devices {
+			err := s.sender(device.Platform).Send(device, notification)
+			if errors.Is(err, ErrInvalidToken) {
+				s.db.Delete(&device)
+				continue
+			}
+			if err != nil {
+				lastErr = err
+			}
+		}
+
+		updates := map[string]interface{}{"attempts": notification.Attempts + 1}
+		if lastErr != nil {
+			updates["last_error"] = lastErr.Error()
+		} else {
+			updates["sent_at"] = time.Now()
+		}
+		if err := s.db.Model(&notification).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) sender(platform models.PushPlatform) Sender {
+	if sender, ok := s.senders[platform]; ok {
+		return sender
+	}
+	return LogSender{}
+}
@@ -0,0 +1,64 @@
+package quota
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sudoku/internal/models"
+)
+
+// Header carrying the API key
+const KeyHeader = "X-API-Key"
+
+// Middleware enforces the daily quota of the category for requests carrying an API key. Requests
+// without a key are metered by client address against anonymousLimit, or not at all when it is 0.
+func Middleware(quotaService *Service, category models.QuotaCategory, anonymousLimit int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rawKey := r.Header.Get(KeyHeader)
+			if rawKey == "" && anonymousLimit == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var usage Usage
+			var err error
+			if rawKey != "" {
+				usage, err = quotaService.Consume(rawKey, category)
+			} else {
+				client, _, splitErr := net.SplitHostPort(r.RemoteAddr)
+				if splitErr != nil {
+					client = r.RemoteAddr
+				}
+				usage, err = quotaService.ConsumeAnonymous(client, category, anonymousLimit)
+			}
+			if errors.Is(err, ErrInvalidKey) {
+				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(usage.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(usage.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(usage.Reset.Unix(), 10))
+
+			if errors.Is(err, ErrQuotaExceeded) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(usage.Reset).Seconds())+1))
+				if rawKey == "" {
+					http.Error(w, "Daily quota exceeded, send an API key for a higher limit", http.StatusTooManyRequests)
+					return
+				}
+				http.Error(w, "Daily API quota exceeded", http.StatusTooManyRequests)
+				return
+			}
+			if err != nil {
+				http.Error(w, "Failed to record API usage", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package quota
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/models"
+)
+
+// Default daily limits of a new key
+const (
+	DefaultDailySolveLimit   = 200
+	DefaultDailyAnalyzeLimit = 500
+)
+
+// Daily analyze calls each client address may make without a key
+const AnonymousDailyAnalyzeLimit = 50
+
+var (
+	ErrInvalidKey    = errors.New("invalid API key")
+	ErrQuotaExceeded = errors.New("daily API quota exceeded")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+// Usage describes where a key stands against its quota for the current day
+type Usage struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time // Start of the next UTC day
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateKey issues a new key for the user. The raw key is only returned here.
+func (s *Service) CreateKey(userID uint, name string) (*models.APIKey, string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	raw := "sk_" + hex.EncodeToString(buf)
+
+	key := &models.APIKey{
+		UserID:            userID,
+		Name:              name,
+		Prefix:            raw[:10],
+		KeyHash:           hashKey(raw),
+		DailySolveLimit:   DefaultDailySolveLimit,
+		DailyAnalyzeLimit: DefaultDailyAnalyzeLimit,
+	}
+	if err := s.db.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+// Consume records one call in the category for the key, failing with ErrQuotaExceeded
+// once the daily limit is used up.
+func (s *Service) Consume(rawKey string, category models.QuotaCategory) (Usage, error) {
+	var key models.APIKey
+	if err := s.db.Where("key_hash = ? AND revoked_at IS NULL", hashKey(rawKey)).First(&key).Error; err != nil {
+		return Usage{}, ErrInvalidKey
+	}
+
+	day := today()
+	usage := Usage{Limit: key.DailyAnalyzeLimit, Reset: day.AddDate(0, 0, 1)}
+	if category == models.SolveQuota {
+		usage.Limit = key.DailySolveLimit
+	}
+
+	record := models.APIUsage{APIKeyID: key.ID, Day: day, Category: category, Count: 1}
+	if err := s.increment(&record, "api_usages", []string{"api_key_id", "day", "category"}, usage.Limit); err != nil {
+		return usage, err
+	}
+
+	usage.Remaining = usage.Limit - record.Count
+	return usage, nil
+}
+
+// ConsumeAnonymous records one call in the category from a client without a key, failing with
+// ErrQuotaExceeded once the client has made limit calls today.
+func (s *Service) ConsumeAnonymous(client string, category models.QuotaCategory, limit int) (Usage, error) {
+	day := today()
+	usage := Usage{Limit: limit, Reset: day.AddDate(0, 0, 1)}
+
+	record := models.AnonymousUsage{Client: client, Day: day, Category: category, Count: 1}
+	if err := s.increment(&record, "anonymous_usages", []string{"client", "day", "category"}, limit); err != nil {
+		return usage, err
+	}
+
+	usage.Remaining = usage.Limit - record.Count
+	return usage, nil
+}
+
+// Count one call on the usage row of record unless it has reached limit, leaving the new count in
+// record. Checking and counting in one statement keeps concurrent calls from going over the limit.
+func (s *Service) increment(record interface{}, table string, columns []string, limit int) error {
+	if limit <= 0 {
+		return ErrQuotaExceeded
+	}
+
+	conflict := clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr(table + ".count + 1")}),
+		Where:     clause.Where{Exprs: []clause.Expression{gorm.Expr(table+".count < ?", limit)}},
+	}
+	for _, column := range columns {
+		conflict.Columns = append(conflict.Columns, clause.Column{Name: column})
+	}
+
+	result := s.db.Clauses(conflict, clause.Returning{Columns: []clause.Column{{Name: "count"}}}).Create(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// PruneAnonymous deletes the anonymous usage counts of past days
+func (s *Service) PruneAnonymous() error {
+	return s.db.Where("day < ?", today()).Delete(&models.AnonymousUsage{}).Error
+}
+
+// Start of the current UTC day, which quotas are counted by
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// UsageToday returns the number of calls per category made with the key today
+func (s *Service) UsageToday(keyID uint) (map[models.QuotaCategory]int, error) {
+	day := today()
+
+	var rows []models.APIUsage
+	if err := s.db.Where("api_key_id = ? AND day = ?", keyID, day).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	usage := map[models.QuotaCategory]int{models.SolveQuota: 0, models.AnalyzeQuota: 0}
+	for _, row := range rows {
+		usage[row.Category] = row.Count
+	}
+	return usage, nil
+}
@@ -0,0 +1,266 @@
+package race
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Largest message accepted from a player, in bytes
+const maxMessageSize = 1024
+
+// Message is sent by a player over the race socket
+type Message struct {
+	Type    string  `json:"type"` // "move" or "power_up"
+	Row     int     `json:"row"`
+	Col     int     `json:"col"`
+	Value   int     `json:"value"`
+	PowerUp PowerUp `json:"power_up"`
+}
+
+// Snapshot is sent to a player when they connect
+type Snapshot struct {
+	Type           string `json:"type"`
+	RaceID         uint   `json:"race_id"`
+	Grid           string `json:"grid"` // The player's own board
+	You            Player `json:"you"`
+	Opponent       Player `json:"opponent"`
+	OpponentFilled int    `json:"opponent_filled"`
+	WinnerID       uint   `json:"winner_id,omitempty"`
+}
+
+// Rematch is sent to the players of a finished race when one of them opens its rematch
+type Rematch struct {
+	Type      string `json:"type"`
+	RaceID    uint   `json:"race_id"`
+	RematchID uint   `json:"rematch_id"`
+	UserID    uint   `json:"user_id"` // The player who asked for it
+}
+
+// SeriesScore is sent to both players when a race of a best-of-N series finishes
+type SeriesScore struct {
+	Type   string            `json:"type"`
+	Series models.RaceSeries `json:"series"`
+}
+
+// Hub runs the races that have connected players. Race state lives in memory while a race
+// is played; only the outcome is saved. Progress is lost if both players leave.
+type Hub struct {
+	db    *gorm.DB
+	mu    sync.Mutex
+	rooms map[uint]*room
+}
+
+type room struct {
+	mu    sync.Mutex
+	race  models.Race
+	state *State
+	conns map[uint]*websocket.Conn
+}
+
+func NewHub(db *gorm.DB) *Hub {
+	return &Hub{db: db, rooms: map[uint]*room{}}
+}
+
+// Serve plays the race for the user over the connection until it closes
+func (h *Hub) Serve(raceID, userID uint, conn *websocket.Conn) error {
+	defer conn.Close()
+
+	rm, err := h.join(raceID, userID, conn)
+	if err != nil {
+		conn.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return err
+	}
+	defer h.leave(raceID, rm, userID, conn)
+
+	conn.SetReadLimit(maxMessageSize)
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return nil // Closed by the client
+		}
+		rm.handle(h.db, userID, msg)
+	}
+}
+
+// Register the connection in the race's room, creating the room on the first connection
+func (h *Hub) join(raceID, userID uint, conn *websocket.Conn) (*room, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rm, ok := h.rooms[raceID]
+	if !ok {
+		var race models.Race
+		if err := h.db.Preload("Puzzle", models.WithDeleted).First(&race, raceID).Error; err != nil {
+			return nil, err
+		}
+		if race.Status == models.RaceFinished {
+			return nil, ErrRaceOver
+		}
+		if race.Status != models.RaceActive || race.GuestID == nil {
+			return nil, ErrRaceNotStarted
+		}
+		rm = &room{
+			race: race,
+			state: NewState(sudoku.StringToBoard(race.Puzzle.StartingGrid), sudoku.StringToBoard(race.Puzzle.Solution),
+				race.HostID, *race.GuestID),
+			conns: map[uint]*websocket.Conn{},
+		}
+		h.rooms[raceID] = rm
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	player, opponent, err := rm.state.players(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A newer connection of the same player replaces the old one
+	if old, ok := rm.conns[userID]; ok {
+		old.Close()
+	}
+	rm.conns[userID] = conn
+
+	snapshot := Snapshot{
+		Type:           "state",
+		RaceID:         raceID,
+		Grid:           sudoku.BoardToString(player.Board),
+		You:            *player,
+		Opponent:       *opponent,
+		OpponentFilled: opponent.Filled(),
+		WinnerID:       rm.state.WinnerID,
+	}
+	if err := conn.WriteJSON(snapshot); err != nil {
+		delete(rm.conns, userID)
+		return nil, err
+	}
+	return rm, nil
+}
+
+// Broadcast sends a message to the players connected to a race, if any
+func (h *Hub) Broadcast(raceID uint, msg interface{}) {
+	h.mu.Lock()
+	rm, ok := h.rooms[raceID]
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	for _, conn := range rm.conns {
+		conn.WriteJSON(msg)
+	}
+}
+
+// Unregister the connection, dropping the room once nobody is connected
+func (h *Hub) leave(raceID uint, rm *room, userID uint, conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.conns[userID] == conn {
+		delete(rm.conns, userID)
+	}
+	if len(rm.conns) == 0 && h.rooms[raceID] == rm {
+		delete(h.rooms, raceID)
+	}
+}
+
+// Apply a player's message and send the resulting events to both players
+func (rm *room) handle(db *gorm.DB, userID uint, msg Message) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	var events []Event
+	var err error
+	now := time.Now()
+	switch msg.Type {
+	case "move":
+		events, err = rm.state.Move(userID, msg.Row, msg.Col, msg.Value, now)
+	case "power_up":
+		events, err = rm.state.UsePowerUp(userID, msg.PowerUp, now)
+	default:
+		err = errors.New("unknown message type")
+	}
+	if err != nil {
+		if conn, ok := rm.conns[userID]; ok {
+			conn.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		}
+		return
+	}
+
+	for _, event := range events {
+		for id, conn := range rm.conns {
+			if id == event.UserID {
+				conn.WriteJSON(event)
+			} else {
+				conn.WriteJSON(event.ForOpponent())
+			}
+		}
+		if event.Type != "finished" {
+			continue
+		}
+		series, err := rm.save(db, now)
+		if err != nil {
+			log.Printf("Failed to save race %d: %v", rm.race.ID, err)
+			continue
+		}
+		if series != nil {
+			for _, conn := range rm.conns {
+				conn.WriteJSON(SeriesScore{Type: "series", Series: *series})
+			}
+		}
+	}
+}
+
+// Save the outcome of a finished race, scoring it in its series. Returns the series, nil for
+// a race played on its own.
+func (rm *room) save(db *gorm.DB, now time.Time) (*models.RaceSeries, error) {
+	var series *models.RaceSeries
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&rm.race).Updates(map[string]interface{}{
+			"status":      models.RaceFinished,
+			"winner_id":   rm.state.WinnerID,
+			"host_grid":   sudoku.BoardToString(rm.state.Players[0].Board),
+			"guest_grid":  sudoku.BoardToString(rm.state.Players[1].Board),
+			"finished_at": now,
+		}).Error
+		if err != nil || rm.race.SeriesID == nil {
+			return err
+		}
+
+		series = &models.RaceSeries{}
+		if err := tx.First(series, *rm.race.SeriesID).Error; err != nil {
+			return err
+		}
+		if series.WinnerID != nil {
+			return nil // Decided by an earlier race
+		}
+		if rm.state.WinnerID == series.HostID {
+			series.HostWins++
+		} else {
+			series.GuestWins++
+		}
+		updates := map[string]interface{}{"host_wins": series.HostWins, "guest_wins": series.GuestWins}
+		if max(series.HostWins, series.GuestWins) > series.BestOf/2 {
+			winnerID := rm.state.WinnerID
+			series.WinnerID, series.FinishedAt = &winnerID, &now
+			updates["winner_id"], updates["finished_at"] = winnerID, now
+		}
+		return tx.Model(series).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
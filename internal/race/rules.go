@@ -0,0 +1,232 @@
+package race
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"sudoku/internal/sudoku"
+)
+
+const (
+	// Correct moves in a row that earn a power-up
+	StreakForPowerUp = 3
+	// Power-ups a player can hold at once; streaks earn nothing while the hand is full
+	MaxPowerUps = 2
+	// How long a fogged player can't see or change their board
+	FogDuration = 5 * time.Second
+)
+
+type PowerUp string
+
+const (
+	RevealPowerUp PowerUp = "reveal" // Fills one of the player's empty cells
+	FogPowerUp    PowerUp = "fog"    // Blocks the opponent's board for FogDuration
+)
+
+// Power-ups are handed out in this order, repeating
+var powerUpOrder = []PowerUp{RevealPowerUp, FogPowerUp}
+
+var (
+	ErrRaceOver       = errors.New("race is over")
+	ErrInvalidMove    = errors.New("invalid move")
+	ErrCellFilled     = errors.New("cell is already filled")
+	ErrFogged         = errors.New("board is fogged")
+	ErrNoPowerUp      = errors.New("power-up not held")
+	ErrOpponentFogged = errors.New("opponent is already fogged")
+	ErrUnknownPowerUp = errors.New("unknown power-up")
+	ErrNotParticipant = errors.New("not a participant of the race")
+	ErrRaceNotStarted = errors.New("race has not started")
+)
+
+// Player is one side of a race
+type Player struct {
+	UserID      uint         `json:"user_id"`
+	Board       sudoku.Board `json:"-"`
+	Streak      int          `json:"streak"`
+	PowerUps    []PowerUp    `json:"power_ups"`
+	Earned      int          `json:"-"` // Power-ups earned so far, picks the next one from powerUpOrder
+	FoggedUntil time.Time    `json:"fogged_until"`
+}
+
+// Number of filled cells on the player's board
+func (p *Player) Filled() int {
+	filled := 0
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if p.Board[i][j] != 0 {
+				filled++
+			}
+		}
+	}
+	return filled
+}
+
+func (p *Player) fogged(now time.Time) bool {
+	return now.Before(p.FoggedUntil)
+}
+
+// Take a held power-up out of the player's hand
+func (p *Player) spend(powerUp PowerUp) bool {
+	for i, held := range p.PowerUps {
+		if held == powerUp {
+			p.PowerUps = append(p.PowerUps[:i], p.PowerUps[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Event is sent to both players whenever the race changes
+type Event struct {
+	Type        string     `json:"type"`
+	UserID      uint       `json:"user_id"`
+	Row         *int       `json:"row,omitempty"`
+	Col         *int       `json:"col,omitempty"`
+	Value       int        `json:"value,omitempty"` // Only sent to the player who made the move
+	PowerUp     PowerUp    `json:"power_up,omitempty"`
+	Filled      int        `json:"filled"` // Filled cells of the player, so the opponent sees progress without values
+	Streak      int        `json:"streak"`
+	FoggedUntil *time.Time `json:"fogged_until,omitempty"`
+	WinnerID    uint       `json:"winner_id,omitempty"`
+}
+
+// ForOpponent strips the cell value, which the opponent must not see
+func (e Event) ForOpponent() Event {
+	e.Value = 0
+	return e
+}
+
+// State holds the server-side rules of a running race. It is not safe for concurrent use.
+type State struct {
+	Solution sudoku.Board
+	Players  [2]*Player
+	WinnerID uint
+	rng      *rand.Rand
+}
+
+// NewState starts both players on the puzzle
+func NewState(puzzle, solution sudoku.Board, hostID, guestID uint) *State {
+	return &State{
+		Solution: solution,
+		Players: [2]*Player{
+			{UserID: hostID, Board: puzzle},
+			{UserID: guestID, Board: puzzle},
+		},
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Finished reports whether a player has completed their board
+func (s *State) Finished() bool {
+	return s.WinnerID != 0
+}
+
+func (s *State) players(userID uint) (*Player, *Player, error) {
+	switch userID {
+	case s.Players[0].UserID:
+		return s.Players[0], s.Players[1], nil
+	case s.Players[1].UserID:
+		return s.Players[1], s.Players[0], nil
+	}
+	return nil, nil, ErrNotParticipant
+}
+
+// Move places a value for the player. Only correct values are placed; a wrong one breaks the streak.
+func (s *State) Move(userID uint, row, col, value int, now time.Time) ([]Event, error) {
+	player, _, err := s.players(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Finished() {
+		return nil, ErrRaceOver
+	}
+	if row < 0 || row > 8 || col < 0 || col > 8 || value < 1 || value > 9 {
+		return nil, ErrInvalidMove
+	}
+	if player.fogged(now) {
+		return nil, ErrFogged
+	}
+	if player.Board[row][col] != 0 {
+		return nil, ErrCellFilled
+	}
+
+	if s.Solution[row][col] != value {
+		player.Streak = 0
+		return []Event{{Type: "wrong", UserID: userID, Row: &row, Col: &col, Filled: player.Filled()}}, nil
+	}
+
+	player.Board[row][col] = value
+	player.Streak++
+	events := []Event{{Type: "correct", UserID: userID, Row: &row, Col: &col, Value: value, Filled: player.Filled(), Streak: player.Streak}}
+
+	if player.Streak%StreakForPowerUp == 0 && len(player.PowerUps) < MaxPowerUps {
+		powerUp := powerUpOrder[player.Earned%len(powerUpOrder)]
+		player.Earned++
+		player.PowerUps = append(player.PowerUps, powerUp)
+		events = append(events, Event{Type: "power_up_earned", UserID: userID, PowerUp: powerUp, Filled: player.Filled(), Streak: player.Streak})
+	}
+	return append(events, s.checkFinished(player)...), nil
+}
+
+// UsePowerUp spends one of the player's power-ups
+func (s *State) UsePowerUp(userID uint, powerUp PowerUp, now time.Time) ([]Event, error) {
+	player, opponent, err := s.players(userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.Finished() {
+		return nil, ErrRaceOver
+	}
+
+	switch powerUp {
+	case RevealPowerUp:
+		if player.fogged(now) {
+			return nil, ErrFogged
+		}
+		if !player.spend(powerUp) {
+			return nil, ErrNoPowerUp
+		}
+		row, col := s.randomEmptyCell(player.Board)
+		value := s.Solution[row][col]
+		player.Board[row][col] = value
+		events := []Event{{Type: "power_up_used", UserID: userID, PowerUp: powerUp, Row: &row, Col: &col, Value: value, Filled: player.Filled(), Streak: player.Streak}}
+		return append(events, s.checkFinished(player)...), nil
+
+	case FogPowerUp:
+		if opponent.fogged(now) {
+			return nil, ErrOpponentFogged
+		}
+		if !player.spend(powerUp) {
+			return nil, ErrNoPowerUp
+		}
+		opponent.FoggedUntil = now.Add(FogDuration)
+		foggedUntil := opponent.FoggedUntil
+		return []Event{
+			{Type: "power_up_used", UserID: userID, PowerUp: powerUp, Filled: player.Filled(), Streak: player.Streak},
+			{Type: "fogged", UserID: opponent.UserID, Filled: opponent.Filled(), Streak: opponent.Streak, FoggedUntil: &foggedUntil},
+		}, nil
+	}
+	return nil, ErrUnknownPowerUp
+}
+
+// An empty cell of a board that isn't full
+func (s *State) randomEmptyCell(board sudoku.Board) (int, int) {
+	var empty []int
+	for pos := 0; pos < 81; pos++ {
+		if board[pos/9][pos%9] == 0 {
+			empty = append(empty, pos)
+		}
+	}
+	pos := empty[s.rng.Intn(len(empty))]
+	return pos / 9, pos % 9
+}
+
+// Declare the player the winner once their board is full
+func (s *State) checkFinished(player *Player) []Event {
+	if player.Filled() < 81 {
+		return nil
+	}
+	s.WinnerID = player.UserID
+	return []Event{{Type: "finished", UserID: player.UserID, Filled: 81, Streak: player.Streak, WinnerID: player.UserID}}
+}
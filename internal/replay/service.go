@@ -0,0 +1,152 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/anticheat"
+	"sudoku/internal/blob"
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/models"
+)
+
+// Game results verified per worker run
+const batchSize = 100
+
+// Service verifies that the replays of scored games back up their results. Leaderboards only
+// list results whose replay was checked; results whose replay fails are sent for review.
+type Service struct {
+	db          *gorm.DB
+	blobService *blob.Service
+}
+
+func NewService(db *gorm.DB, blobService *blob.Service) *Service {
+	return &Service{db: db, blobService: blobService}
+}
+
+// Key returns the blob key of a game result's replay
+func Key(gameResultID uint) string {
+	return fmt.Sprintf("replays/%d.json", gameResultID)
+}
+
+// Load returns the stored replay events of a game result. blob.ErrNotFound is returned if it has none.
+func (s *Service) Load(gameResultID uint) ([]models.ReplayEvent, error) {
+	_, data, err := s.blobService.Get(Key(gameResultID))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []models.ReplayEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// NeedsCheck reports whether the game result is a scored one whose replay hasn't been checked yet
+func NeedsCheck(gameResult *models.GameResult) bool {
+	return gameResult.CheckedAt == nil && gameResult.Mode == models.PlayMode && gameResult.Completed &&
+		!gameResult.Disqualified && !gameResult.Voided && !gameResult.Practice
+}
+
+// Verify checks the stored replay of a completed game result against its final grid and time.
+// A failing replay demotes the result: it is put under review with the reasons, which hides it
+// from the leaderboards until a moderator clears it. It returns the reasons found.
+func (s *Service) Verify(gameResult *models.GameResult) ([]string, error) {
+	events, err := s.Load(gameResult.ID)
+	if err != nil {
+		return nil, err
+	}
+	if gameResult.Puzzle.ID == 0 {
+		if err := s.db.First(&gameResult.Puzzle, gameResult.PuzzleID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	reasons := anticheat.CheckReplay(gameResult, events)
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"checked_at": now}
+		if len(reasons) > 0 {
+			updates["under_review"] = true
+		}
+		if err := tx.Model(gameResult).Updates(updates).Error; err != nil {
+			return err
+		}
+		// A late check lists a result that waited for its replay, or hides one listed without it,
+		// so periods archived in the meantime are rebuilt
+		if gameResult.NeedsReplay == (len(reasons) == 0) && gameResult.CompletedAt != nil {
+			if err := rebuildPeriods(tx, *gameResult.CompletedAt); err != nil {
+				return err
+			}
+		}
+		if len(reasons) == 0 {
+			return nil
+		}
+
+		// A result has one review, which anti-cheat may have opened already
+		var review models.ResultReview
+		err := tx.Where("game_result_id = ?", gameResult.ID).First(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(&models.ResultReview{
+				GameResultID: gameResult.ID,
+				Reasons:      strings.Join(reasons, "; "),
+				Status:       models.ReviewPending,
+			}).Error
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&review).Updates(map[string]interface{}{
+			"reasons": review.Reasons + "; " + strings.Join(reasons, "; "),
+			"status":  models.ReviewPending,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reasons, nil
+}
+
+// Rebuild the archived daily and weekly boards of the periods containing completed
+func rebuildPeriods(tx *gorm.DB, completed time.Time) error {
+	archive := leaderboard.NewService(tx)
+	// Periods are archived in server time, see SnapshotDue
+	completed = completed.In(time.Local)
+	for _, period := range []models.SnapshotPeriod{models.DailySnapshot, models.WeeklySnapshot} {
+		if _, err := archive.Rebuild(period, leaderboard.PeriodStart(period, completed)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyPending checks the replays of scored results that were uploaded before the game was
+// submitted. It is run periodically by the verification worker; results without a replay wait.
+func (s *Service) VerifyPending() error {
+	var pending []models.GameResult
+	err := s.db.Preload("Puzzle", models.WithDeleted).
+		Where("checked_at IS NULL AND mode = ? AND completed = ? AND disqualified = ? AND voided = ? AND practice = ?",
+			models.PlayMode, true, false, false, false).
+		Where("EXISTS (SELECT 1 FROM blobs WHERE blobs.game_result_id = game_results.id AND blobs.kind = ? AND (blobs.expires_at IS NULL OR blobs.expires_at > ?))",
+			models.ReplayBlob, time.Now()).
+		Order("id").
+		Limit(batchSize).
+		Find(&pending).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range pending {
+		if _, err := s.Verify(&pending[i]); err != nil {
+			log.Printf("Failed to verify replay of game %d: %v", pending[i].ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package rivals
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/push"
+	"sudoku/internal/stats"
+)
+
+// Rivalries are detected over the games, races and boards of this window
+const Window = 90 * 24 * time.Hour
+
+// Finished races against the same opponent in the window that make them rivals
+const minRaces = 3
+
+// Archived weekly boards in the window two users placed next to each other on that make them rivals
+const minNeighbours = 2
+
+// Most rivals listed
+const MaxRivals = 10
+
+// Time before the same rival can be nudged again
+const NudgeCooldown = 24 * time.Hour
+
+var (
+	ErrNotRival       = errors.New("user is not one of your rivals")
+	ErrNudgedRecently = fmt.Errorf("you can challenge the same rival once every %s", NudgeCooldown)
+)
+
+// Rival is a user the player often meets head to head, with how the two compare over the window
+type Rival struct {
+	UserID     uint          `json:"user_id"`
+	Username   string        `json:"username"`
+	Races      int           `json:"races"`            // Finished races between the two
+	Wins       int           `json:"wins"`             // Races the player won
+	Losses     int           `json:"losses"`           // Races the rival won
+	Neighbours int           `json:"neighbour_boards"` // Archived weekly boards the two placed next to each other on
+	You        stats.Summary `json:"you"`
+	Them       stats.Summary `json:"them"`
+	NudgedAt   *time.Time    `json:"nudged_at,omitempty"` // Last time the player challenged this rival
+}
+
+// Service detects rivalries from races and the leaderboard archive, and lets players challenge them
+type Service struct {
+	db           *gorm.DB
+	statsService *stats.Service
+	pushService  *push.Service
+}
+
+func NewService(db *gorm.DB, statsService *stats.Service, pushService *push.Service) *Service {
+	return &Service{db: db, statsService: statsService, pushService: pushService}
+}
+
+// List returns the user's rivals as of now, those met most often first
+func (s *Service) List(userID uint, now time.Time) ([]Rival, error) {
+	since := now.Add(-Window)
+	found := map[uint]*Rival{}
+	rival := func(id uint) *Rival {
+		if found[id] == nil {
+			found[id] = &Rival{UserID: id}
+		}
+		return found[id]
+	}
+
+	var races []models.Race
+	err := s.db.Where("(host_id = ? OR guest_id = ?) AND status = ? AND finished_at >= ?", userID, userID, models.RaceFinished, since).
+		Find(&races).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, race := range races {
+		if race.GuestID == nil {
+			continue
+		}
+		opponent := race.HostID
+		if opponent == userID {
+			opponent = *race.GuestID
+		}
+		r := rival(opponent)
+		r.Races++
+		switch {
+		case race.WinnerID == nil:
+		case *race.WinnerID == userID:
+			r.Wins++
+		default:
+			r.Losses++
+		}
+	}
+
+	var neighbours []struct {
+		UserID uint
+		Boards int
+	}
+	err = s.db.Table("leaderboard_snapshots AS mine").
+		Select("theirs.user_id, COUNT(DISTINCT theirs.id) AS boards").
+		Joins("JOIN leaderboard_snapshots AS theirs ON theirs.period = mine.period AND theirs.period_start = mine.period_start "+
+			"AND theirs.difficulty = mine.difficulty AND theirs.sort_by = mine.sort_by AND ABS(theirs.rank - mine.rank) = 1").
+		Where("mine.user_id = ? AND theirs.user_id <> ? AND mine.period = ? AND mine.period_start >= ?", userID, userID, models.WeeklySnapshot, since).
+		Group("theirs.user_id").
+		Scan(&neighbours).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range neighbours {
+		rival(n.UserID).Neighbours = n.Boards
+	}
+
+	var ids []uint
+	for id, r := range found {
+		if r.Races >= minRaces || r.Neighbours >= minNeighbours {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []Rival{}, nil
+	}
+
+	// Deleted accounts drop out here
+	var users []models.User
+	if err := s.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	rivals := make([]Rival, 0, len(users))
+	for _, user := range users {
+		r := found[user.ID]
+		r.Username = user.Username
+		rivals = append(rivals, *r)
+	}
+	sort.Slice(rivals, func(i, j int) bool {
+		a, b := rivals[i], rivals[j]
+		if a.Races+a.Neighbours != b.Races+b.Neighbours {
+			return a.Races+a.Neighbours > b.Races+b.Neighbours
+		}
+		return a.UserID < b.UserID
+	})
+	if len(rivals) > MaxRivals {
+		rivals = rivals[:MaxRivals]
+	}
+
+	you, err := s.statsService.Summarize(userID, since, now)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rivals {
+		rivals[i].You = you
+		if rivals[i].Them, err = s.statsService.Summarize(rivals[i].UserID, since, now); err != nil {
+			return nil, err
+		}
+		if rivals[i].NudgedAt, err = s.lastNudge(userID, rivals[i].UserID); err != nil {
+			return nil, err
+		}
+	}
+	return rivals, nil
+}
+
+// CanNudge checks that rivalID is one of the user's rivals and hasn't been challenged by them
+// within the cooldown
+func (s *Service) CanNudge(userID, rivalID uint, now time.Time) (*Rival, error) {
+	rivals, err := s.List(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rivals {
+		if r.UserID != rivalID {
+			continue
+		}
+		if r.NudgedAt != nil && now.Sub(*r.NudgedAt) < NudgeCooldown {
+			return nil, ErrNudgedRecently
+		}
+		return &r, nil
+	}
+	return nil, ErrNotRival
+}
+
+// Nudge records the user challenging a rival to the race and notifies the rival. A failed
+// notification is only logged: the race is open either way.
+func (s *Service) Nudge(userID uint, username string, rivalID, raceID uint) error {
+	nudge := models.RivalNudge{UserID: userID, RivalID: rivalID, RaceID: raceID}
+	if err := s.db.Create(&nudge).Error; err != nil {
+		return err
+	}
+	body := fmt.Sprintf("%s challenges you to a race. Join race %d to play.", username, raceID)
+	if err := s.pushService.Enqueue(rivalID, "Rival challenge", body); err != nil {
+		log.Printf("Failed to notify user %d about race %d: %v", rivalID, raceID, err)
+	}
+	return nil
+}
+
+func (s *Service) lastNudge(userID, rivalID uint) (*time.Time, error) {
+	var nudges []models.RivalNudge
+	err := s.db.Where("user_id = ? AND rival_id = ?", userID, rivalID).Order("created_at DESC").Limit(1).Find(&nudges).Error
+	if err != nil || len(nudges) == 0 {
+		return nil, err
+	}
+	return &nudges[0].CreatedAt, nil
+}
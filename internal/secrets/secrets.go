@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadFiles reads variables from files, as container secrets are mounted: for every NAME_FILE
+// in the environment, NAME is set to the contents of that file without the trailing newline.
+// Setting both NAME and NAME_FILE is an error.
+func LoadFiles() error {
+	for _, entry := range os.Environ() {
+		key, path, _ := strings.Cut(entry, "=")
+		name, ok := strings.CutSuffix(key, "_FILE")
+		if !ok || name == "" || path == "" {
+			continue
+		}
+		if _, set := os.LookupEnv(name); set {
+			return fmt.Errorf("both %s and %s are set", name, key)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		if err := os.Setenv(name, strings.TrimRight(string(data), "\r\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package settings
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Paths still served during maintenance, so admins can sign in and switch it off
+var maintenanceExempt = []string{"/status", "/auth/login", "/admin/"}
+
+// Maintenance answers 503 to everything but the exempt paths while maintenance mode is on
+func Maintenance(s *Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.Current().Maintenance && !exempt(r.URL.Path) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(ReloadInterval.Seconds())))
+				http.Error(w, "Down for maintenance", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func exempt(path string) bool {
+	for _, prefix := range maintenanceExempt {
+		if path == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(path, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireFeature answers 404 while the named feature is switched off
+func RequireFeature(s *Service, name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.Feature(name) {
+				http.Error(w, "This feature is switched off", http.StatusNotFound)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit caps the requests each client address makes per minute at the current rate limit.
+// Counts are kept in memory, so each server enforces the limit on its own.
+func RateLimit(s *Service) func(http.Handler) http.Handler {
+	var (
+		mu     sync.Mutex
+		window time.Time
+		counts = map[string]int{}
+	)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := s.Current().RateLimit
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				client = r.RemoteAddr
+			}
+
+			mu.Lock()
+			now := time.Now().Truncate(time.Minute)
+			if !now.Equal(window) {
+				window = now
+				counts = map[string]int{}
+			}
+			counts[client]++
+			count := counts[client]
+			reset := window.Add(time.Minute)
+			mu.Unlock()
+
+			if count > limit {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
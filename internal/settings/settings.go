@@ -0,0 +1,230 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"sudoku/internal/models"
+)
+
+// How often every server reads the settings table, so changes made through another server apply
+const ReloadInterval = 15 * time.Second
+
+// Features that can be switched off at runtime
+const (
+	RacesFeature      = "races"
+	AssistantFeature  = "assistant"
+	PaperFeature      = "paper"
+	WorksheetsFeature = "worksheets"
+)
+
+var knownFeatures = []string{RacesFeature, AssistantFeature, PaperFeature, WorksheetsFeature}
+
+// Settings are the runtime settings an admin can change without restarting the server
+type Settings struct {
+	CORSOrigins []string        `json:"cors_origins"`
+	RateLimit   int             `json:"rate_limit"` // Requests per minute from one client address, 0 for no limit
+	Features    map[string]bool `json:"features"`   // Every known feature, on unless switched off
+	Maintenance bool            `json:"maintenance"`
+}
+
+// Patch changes the settings it sets. Features only lists the features to switch.
+type Patch struct {
+	CORSOrigins *[]string       `json:"cors_origins"`
+	RateLimit   *int            `json:"rate_limit"`
+	Features    map[string]bool `json:"features"`
+	Maintenance *bool           `json:"maintenance"`
+}
+
+// Service keeps the current settings in memory, reloading them from the settings table
+type Service struct {
+	db       *gorm.DB
+	defaults Settings
+
+	mu      sync.RWMutex
+	current Settings
+}
+
+// NewService starts from the defaults until the first Reload
+func NewService(db *gorm.DB, defaults Settings) *Service {
+	defaults.Features = withAllFeatures(defaults.Features)
+	return &Service{db: db, defaults: defaults, current: defaults}
+}
+
+// Every known feature, keeping the state given for any of them
+func withAllFeatures(features map[string]bool) map[string]bool {
+	all := map[string]bool{}
+	for _, name := range knownFeatures {
+		on, ok := features[name]
+		all[name] = on || !ok
+	}
+	return all
+}
+
+// Current returns the settings in effect
+func (s *Service) Current() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Feature reports whether the named feature is on
+func (s *Service) Feature(name string) bool {
+	return s.Current().Features[name]
+}
+
+// AllowOrigin reports whether browsers on origin may call the API
+func (s *Service) AllowOrigin(origin string) bool {
+	for _, allowed := range s.Current().CORSOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload reads the settings table and applies it over the defaults
+func (s *Service) Reload() error {
+	var rows []models.Setting
+	if err := s.db.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	settings := s.defaults
+	settings.Features = withAllFeatures(s.defaults.Features)
+	for _, row := range rows {
+		if err := decode(&settings, row); err != nil {
+			log.Printf("Ignoring setting %s: %v", row.Key, err)
+		}
+	}
+	settings.Features = withAllFeatures(settings.Features)
+	s.apply(settings)
+	return nil
+}
+
+// Decode a stored row into its field of settings
+func decode(settings *Settings, row models.Setting) error {
+	switch row.Key {
+	case "cors_origins":
+		return json.Unmarshal([]byte(row.Value), &settings.CORSOrigins)
+	case "rate_limit":
+		return json.Unmarshal([]byte(row.Value), &settings.RateLimit)
+	case "features":
+		var features map[string]bool
+		if err := json.Unmarshal([]byte(row.Value), &features); err != nil {
+			return err
+		}
+		for name, on := range features {
+			settings.Features[name] = on
+		}
+		return nil
+	case "maintenance":
+		return json.Unmarshal([]byte(row.Value), &settings.Maintenance)
+	default:
+		return errors.New("unknown setting")
+	}
+}
+
+func (s *Service) apply(settings Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !reflect.DeepEqual(settings, s.current) {
+		log.Printf("Applying runtime settings: origins %v, rate limit %d/min, features %v, maintenance %t",
+			settings.CORSOrigins, settings.RateLimit, settings.Features, settings.Maintenance)
+	}
+	s.current = settings
+}
+
+// Validate a patch before it is stored
+func Validate(patch Patch) error {
+	if patch.CORSOrigins != nil {
+		for _, origin := range *patch.CORSOrigins {
+			parsed, err := url.Parse(origin)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+				parsed.Path != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+				return fmt.Errorf("invalid origin %q, use scheme://host[:port]", origin)
+			}
+		}
+	}
+	if patch.RateLimit != nil && *patch.RateLimit < 0 {
+		return errors.New("rate_limit must not be negative")
+	}
+	for name := range patch.Features {
+		if !slices.Contains(knownFeatures, name) {
+			return fmt.Errorf("unknown feature %q", name)
+		}
+	}
+	return nil
+}
+
+// Update stores the settings the patch sets and applies them at once on this server. Other
+// servers pick them up on their next reload.
+func (s *Service) Update(patch Patch, adminID uint) (Settings, error) {
+	if err := Validate(patch); err != nil {
+		return Settings{}, err
+	}
+
+	values := map[string]interface{}{}
+	if patch.CORSOrigins != nil {
+		values["cors_origins"] = *patch.CORSOrigins
+	}
+	if patch.RateLimit != nil {
+		values["rate_limit"] = *patch.RateLimit
+	}
+	if patch.Features != nil {
+		// Store every switched feature, not only the ones in this patch
+		features := map[string]bool{}
+		var stored models.Setting
+		err := s.db.Where("key = ?", "features").First(&stored).Error
+		if err == nil {
+			if err := json.Unmarshal([]byte(stored.Value), &features); err != nil {
+				return Settings{}, err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return Settings{}, err
+		}
+		for name, on := range patch.Features {
+			features[name] = on
+		}
+		values["features"] = features
+	}
+	if patch.Maintenance != nil {
+		values["maintenance"] = *patch.Maintenance
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for key, value := range values {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			row := models.Setting{Key: key, Value: string(encoded), UpdatedBy: adminID}
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+			}).Create(&row).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Settings{}, err
+	}
+
+	if err := s.Reload(); err != nil {
+		return Settings{}, err
+	}
+	return s.Current(), nil
+}
@@ -0,0 +1,128 @@
+package slowlog
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"gorm.io/gorm"
+)
+
+// Thresholds above which queries and handlers are logged. Zero disables the log.
+type Thresholds struct {
+	Query   time.Duration
+	Handler time.Duration
+}
+
+// DefaultThresholds is used when no thresholds are configured
+var DefaultThresholds = Thresholds{Query: 200 * time.Millisecond, Handler: time.Second}
+
+// Key under which a statement's start time is kept
+const startKey = "slowlog:start"
+
+// Watch logs every query made through db that runs longer than threshold, with its
+// parameters and the request ID of the context the query was issued with, if any
+func Watch(db *gorm.DB, threshold time.Duration) error {
+	if threshold <= 0 {
+		return nil
+	}
+
+	start := func(tx *gorm.DB) { tx.InstanceSet(startKey, time.Now()) }
+	finish := func(tx *gorm.DB) {
+		started, ok := tx.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(started.(time.Time))
+		if elapsed < threshold {
+			return
+		}
+		sql := tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		log.Printf("Slow query [%s] took %s (%d rows): %s", requestID(tx), elapsed, tx.RowsAffected, sql)
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("slowlog:start_create", start); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Register("slowlog:create", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("slowlog:start_query", start); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("slowlog:query", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("slowlog:start_update", start); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("slowlog:update", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("slowlog:start_delete", start); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("slowlog:delete", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("slowlog:start_row", start); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register("slowlog:row", finish); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("gorm:raw").Register("slowlog:start_raw", start); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("slowlog:raw", finish)
+}
+
+// Request ID of a statement, "-" for queries made outside a request
+func requestID(tx *gorm.DB) string {
+	if tx.Statement.Context == nil {
+		return "-"
+	}
+	if id := middleware.GetReqID(tx.Statement.Context); id != "" {
+		return id
+	}
+	return "-"
+}
+
+// Middleware logs requests whose handler runs longer than threshold, with the route
+// pattern, status and request ID. Use it after middleware.RequestID.
+func Middleware(threshold time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if threshold <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// WebSocket connections are meant to stay open
+			if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			started := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			elapsed := time.Since(started)
+			if elapsed < threshold {
+				return
+			}
+			route := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			id := middleware.GetReqID(r.Context())
+			if id == "" {
+				id = "-"
+			}
+			log.Printf("Slow handler [%s] %s %s took %s (status %d, query %q)", id, r.Method, route, elapsed, ww.Status(), r.URL.RawQuery)
+		})
+	}
+}
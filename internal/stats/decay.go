@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+// DecayPolicy weights scored games by age for the global ranking. A game counts fully when it is
+// completed and less the older it gets, until it drops out at the end of the window.
+type DecayPolicy struct {
+	Window time.Duration // Age at which a game stops counting, 0 disables decay
+}
+
+// DefaultDecay ranks players by their undecayed total points
+var DefaultDecay = DecayPolicy{}
+
+// SetDecay changes the policy ApplyDecay ranks players by
+func (s *Service) SetDecay(policy DecayPolicy) {
+	s.decay = policy
+}
+
+// ApplyDecay recomputes every user's RankingPoints under the decay policy. It is run periodically
+// by the decay worker; in between, new scores are added at full weight. Without decay the ranking
+// points are the total points.
+func (s *Service) ApplyDecay() error {
+	if s.decay.Window <= 0 {
+		return s.db.Model(&models.User{}).
+			Where("ranking_points <> total_points").
+			Update("ranking_points", gorm.Expr("total_points")).Error
+	}
+
+	now := time.Now()
+	var rows []struct {
+		UserID uint
+		Points float64
+	}
+	// Linear decay: a game's weight falls from 1 when completed to 0 at the end of the window
+	err := scoredGames(s.db).
+		Select("user_id, SUM(score * (1 - EXTRACT(EPOCH FROM (? - completed_at)) / ?)) AS points", now, s.decay.Window.Seconds()).
+		Where("completed_at > ?", now.Add(-s.decay.Window)).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.UserID
+			err := tx.Model(&models.User{}).
+				Where("id = ? AND ranking_points <> ?", row.UserID, int(row.Points+0.5)).
+				Update("ranking_points", int(row.Points+0.5)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Players without a game in the window fall to zero
+		stale := tx.Model(&models.User{}).Where("ranking_points <> 0")
+		if len(ids) > 0 {
+			stale = stale.Where("id NOT IN ?", ids)
+		}
+		return stale.Update("ranking_points", 0).Error
+	})
+}
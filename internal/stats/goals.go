@@ -0,0 +1,37 @@
+package stats
+
+import (
+	"time"
+
+	"sudoku/internal/models"
+)
+
+// GoalProgress measures a goal against the user's scored games completed since periodStart, or
+// since the goal was set if that is later. For a count goal current is the puzzles solved; for a
+// time goal it is the best time in seconds, 0 before the first solve.
+func (s *Service) GoalProgress(goal models.Goal, periodStart time.Time) (current int, met bool, err error) {
+	since := goal.CreatedAt
+	if periodStart.After(since) {
+		since = periodStart
+	}
+	query := scoredGames(s.db).Where("game_results.user_id = ? AND game_results.completed_at >= ?", goal.UserID, since)
+	if goal.Difficulty != "" {
+		query = query.Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").Where("puzzles.difficulty = ?", goal.Difficulty)
+	}
+
+	switch goal.Kind {
+	case models.CountGoal:
+		var solved int64
+		if err := query.Count(&solved).Error; err != nil {
+			return 0, false, err
+		}
+		return int(solved), int(solved) >= goal.Target, nil
+	case models.TimeGoal:
+		var best *int
+		if err := query.Select("MIN(game_results.time_seconds)").Scan(&best).Error; err != nil || best == nil {
+			return 0, false, err
+		}
+		return *best, *best <= goal.Target, nil
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+type Service struct {
+	db    *gorm.DB
+	decay DecayPolicy
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, decay: DefaultDecay}
+}
+
+// Only correct, non-disqualified and non-voided play mode games count towards user totals.
+// Retries are practice and never count.
+func scoredGames(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.GameResult{}).
+		Where("mode = ? AND completed = ? AND disqualified = ? AND voided = ? AND practice = ?", models.PlayMode, true, false, false, false)
+}
+
+// Correct, non-voided retries count towards the user's practice stats
+func practiceGames(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.GameResult{}).
+		Where("practice = ? AND completed = ? AND voided = ?", true, true, false)
+}
+
+// RecomputeUser rebuilds a user's TotalPoints, GamesPlayed and PracticeGames from their game results.
+// Pass a transaction as db to make it part of a larger change.
+func RecomputeUser(db *gorm.DB, userID uint) error {
+	var totals struct {
+		Points int
+		Games  int
+	}
+	err := scoredGames(db).
+		Select("COALESCE(SUM(score), 0) AS points, COUNT(*) AS games").
+		Where("user_id = ?", userID).
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+
+	var practice int64
+	if err := practiceGames(db).Where("user_id = ?", userID).Count(&practice).Error; err != nil {
+		return err
+	}
+
+	return db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"total_points":   totals.Points,
+		"games_played":   totals.Games,
+		"practice_games": practice,
+	}).Error
+}
+
+// RecomputeAll rebuilds the totals of every user from their game results.
+// It only derives values from game_results, so running it repeatedly is safe.
+func (s *Service) RecomputeAll() (int, error) {
+	var userIDs []uint
+	if err := s.db.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+
+	for _, userID := range userIDs {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return RecomputeUser(tx, userID)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(userIDs), nil
+}
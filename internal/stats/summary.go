@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"time"
+
+	"sudoku/internal/models"
+)
+
+// Summary aggregates a user's scored games over a period
+type Summary struct {
+	GamesPlayed int                       `json:"games_played"`
+	Points      int                       `json:"points"`
+	BestTimes   map[models.Difficulty]int `json:"best_times"` // Fastest solve in seconds per difficulty
+	GoalsMet    int                       `json:"goals_met"`  // Personal goals completed
+}
+
+// Summarize aggregates the user's scored games completed in [from, to)
+func (s *Service) Summarize(userID uint, from, to time.Time) (Summary, error) {
+	var rows []struct {
+		Difficulty models.Difficulty
+		Games      int
+		Points     int
+		BestTime   int
+	}
+	err := scoredGames(s.db).
+		Select("puzzles.difficulty, COUNT(*) AS games, COALESCE(SUM(game_results.score), 0) AS points, MIN(game_results.time_seconds) AS best_time").
+		Joins("JOIN puzzles ON game_results.puzzle_id = puzzles.id").
+		Where("game_results.user_id = ? AND game_results.completed_at >= ? AND game_results.completed_at < ?", userID, from, to).
+		Group("puzzles.difficulty").
+		Scan(&rows).Error
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{BestTimes: map[models.Difficulty]int{}}
+	for _, row := range rows {
+		summary.GamesPlayed += row.Games
+		summary.Points += row.Points
+		summary.BestTimes[row.Difficulty] = row.BestTime
+	}
+
+	var goalsMet int64
+	err = s.db.Model(&models.GoalCompletion{}).
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Count(&goalsMet).Error
+	if err != nil {
+		return Summary{}, err
+	}
+	summary.GoalsMet = int(goalsMet)
+	return summary, nil
+}
+
+// Streak counts the consecutive days, in loc, with at least one scored game. A streak
+// still counts as running if the last game was yesterday.
+func (s *Service) Streak(userID uint, now time.Time, loc *time.Location) (int, error) {
+	var completed []time.Time
+	err := scoredGames(s.db).
+		Where("user_id = ? AND completed_at >= ?", userID, now.AddDate(-1, 0, 0)).
+		Order("completed_at DESC").
+		Pluck("completed_at", &completed).Error
+	if err != nil {
+		return 0, err
+	}
+
+	played := make(map[string]bool)
+	for _, t := range completed {
+		played[t.In(loc).Format("2006-01-02")] = true
+	}
+
+	day := now.In(loc)
+	if !played[day.Format("2006-01-02")] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for played[day.Format("2006-01-02")] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak, nil
+}
@@ -0,0 +1,109 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Stages of the solve-with-me assistant, from a gentle nudge to the answer
+const (
+	StageUnit       = 1 // Which row, column or box to scan
+	StageCandidates = 2 // The candidates of the cells that matter
+	StageTechnique  = 3 // The technique to apply
+	StageCell       = 4 // The cell and its value
+)
+
+// UnitRef names a row, column or box. Index is 0-based; boxes count left to right, top to bottom.
+type UnitRef struct {
+	Kind  string `json:"kind"`
+	Index int    `json:"index"`
+}
+
+// CellCandidates lists the pencil marks of one cell
+type CellCandidates struct {
+	Row    int   `json:"row"`
+	Col    int   `json:"col"`
+	Values []int `json:"values"`
+}
+
+// Prompt is one assistant answer. Fields beyond the stage's level of detail are left empty.
+type Prompt struct {
+	Stage      int              `json:"stage"`
+	Text       string           `json:"text"`
+	Unit       *UnitRef         `json:"unit,omitempty"`
+	Candidates []CellCandidates `json:"candidates,omitempty"`
+	Technique  string           `json:"technique,omitempty"`
+	Move       *Move            `json:"move,omitempty"`
+}
+
+// AssistantPrompt explains move, the next logical move on board, up to the given stage
+func (s *Service) AssistantPrompt(board Board, move *Move, stage int) Prompt {
+	technique := strings.SplitN(move.Reason, " in ", 2)[0]
+	focus := []Cell{{Row: move.Row, Col: move.Col}}
+	if len(move.Deductions) > 0 {
+		technique = move.Deductions[0].Technique
+		focus = move.Deductions[0].Cells
+	}
+
+	prompt := Prompt{Stage: stage}
+	unit, cells := focusUnit(move, focus)
+	if unit != nil {
+		prompt.Unit = unit
+		prompt.Text = fmt.Sprintf("Look at %s %d.", strings.ToLower(unit.Kind), unit.Index+1)
+	} else {
+		prompt.Text = fmt.Sprintf("Look at the cells around row %d, column %d.", focus[0].Row+1, focus[0].Col+1)
+	}
+	if stage == StageUnit {
+		return prompt
+	}
+
+	c := s.ComputeCandidates(board)
+	for _, cell := range cells {
+		if board[cell.Row][cell.Col] == 0 {
+			prompt.Candidates = append(prompt.Candidates, CellCandidates{Row: cell.Row, Col: cell.Col, Values: c.Values(cell.Row, cell.Col)})
+		}
+	}
+	prompt.Text = "Pencil in the candidates of these cells."
+	if stage == StageCandidates {
+		return prompt
+	}
+
+	prompt.Technique = technique
+	prompt.Text = fmt.Sprintf("Look for a %s.", technique)
+	if stage == StageTechnique {
+		return prompt
+	}
+
+	prompt.Move = move
+	prompt.Text = fmt.Sprintf("Row %d, column %d must be %d (%s).", move.Row+1, move.Col+1, move.Value, move.Reason)
+	return prompt
+}
+
+// The unit to scan for a move and the cells whose candidates matter. Hidden singles use the
+// unit they were found in, naked singles their box, other patterns the first unit holding
+// all their cells.
+func focusUnit(move *Move, focus []Cell) (*UnitRef, []Cell) {
+	kind := ""
+	if parts := strings.SplitN(move.Reason, " in ", 2); len(parts) == 2 && len(move.Deductions) == 0 {
+		kind = parts[1]
+	}
+	if move.Reason == "Naked Single" {
+		kind = "Box"
+	}
+
+	for _, u := range allUnits() {
+		if kind != "" && u.kind != kind {
+			continue
+		}
+		if !allIn(focus, u) {
+			continue
+		}
+		ref := unitRef(u)
+		// A naked single is read off its own cell
+		if move.Reason == "Naked Single" {
+			return &ref, focus
+		}
+		return &ref, u.cells
+	}
+	return nil, focus
+}
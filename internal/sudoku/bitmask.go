@@ -0,0 +1,107 @@
+package sudoku
+
+import (
+	"math/bits"
+)
+
+// Bits 1-9 set, one per digit, matching the layout of CandidateGrid
+const allDigits uint16 = 0x3fe
+
+// Index of the 3x3 box holding a cell, row-major
+func boxIndex(row, col int) int {
+	return row/3*3 + col/3
+}
+
+// Digits used by the other cells in the row, column and box of a cell
+func peerDigits(board Board, row, col int) uint16 {
+	var used uint16
+	boxRow, boxCol := row/3*3, col/3*3
+	for k := 0; k < 9; k++ {
+		if k != col {
+			used |= 1 << board[row][k]
+		}
+		if k != row {
+			used |= 1 << board[k][col]
+		}
+		if r, c := boxRow+k/3, boxCol+k%3; r != row || c != col {
+			used |= 1 << board[r][c]
+		}
+	}
+	return used &^ 1 // Empty cells set bit 0
+}
+
+// masks tracks the digits placed in every row, column and box so a cell's
+// candidates are three lookups instead of a scan of its peers
+type masks struct {
+	rows, cols, boxes [9]uint16
+}
+
+func newMasks(board *Board) *masks {
+	m := &masks{}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if value := board[i][j]; value != 0 {
+				m.set(i, j, value)
+			}
+		}
+	}
+	return m
+}
+
+// Mark the digit as used in the cell's row, column and box
+func (m *masks) set(row, col, value int) {
+	bit := uint16(1) << value
+	m.rows[row] |= bit
+	m.cols[col] |= bit
+	m.boxes[boxIndex(row, col)] |= bit
+}
+
+func (m *masks) candidates(row, col int) uint16 {
+	return allDigits &^ (m.rows[row] | m.cols[col] | m.boxes[boxIndex(row, col)])
+}
+
+// Fill a cell with one of its candidates
+func (m *masks) place(board *Board, row, col, value int) {
+	board[row][col] = value
+	m.set(row, col, value)
+}
+
+// Undo place. The digit was a candidate, so no other cell of the houses holds it.
+func (m *masks) unplace(board *Board, row, col, value int) {
+	board[row][col] = 0
+	bit := uint16(1) << value
+	m.rows[row] &^= bit
+	m.cols[col] &^= bit
+	m.boxes[boxIndex(row, col)] &^= bit
+}
+
+// The empty cell with the fewest candidates, which keeps the search tree narrow.
+// ok is false once the board is full; a cell without candidates is returned straight away.
+func (m *masks) nextCell(board *Board) (row, col int, candidates uint16, ok bool) {
+	best := 10
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] != 0 {
+				continue
+			}
+			c := m.candidates(i, j)
+			if n := bits.OnesCount16(c); n < best {
+				row, col, candidates, best = i, j, c, n
+				if n <= 1 {
+					return row, col, candidates, true
+				}
+			}
+		}
+	}
+	return row, col, candidates, best < 10
+}
+
+// The digits of a candidate mask in increasing order
+func digits(candidates uint16) []int {
+	values := make([]int, 0, bits.OnesCount16(candidates))
+	for candidates != 0 {
+		values = append(values, bits.TrailingZeros16(candidates))
+		candidates &= candidates - 1
+	}
+	return values
+}
@@ -0,0 +1,75 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsolvable is returned when a board has no solution
+var ErrUnsolvable = errors.New("puzzle cannot be solved from current state")
+
+// SolveBudget caps the work of one backtracking search over a user-supplied board
+type SolveBudget struct {
+	MaxNodes int           // Placements tried before giving up, 0 means no limit
+	Timeout  time.Duration // Wall-clock limit, 0 means no limit
+}
+
+// DefaultSolveBudget is far above what any published puzzle needs but stops
+// crafted grids from pinning a core
+var DefaultSolveBudget = SolveBudget{MaxNodes: 10_000_000, Timeout: 2 * time.Second}
+
+// BudgetExceededError is returned when a search runs out of nodes or time
+type BudgetExceededError struct {
+	Nodes   int
+	Elapsed time.Duration
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("solver budget exceeded after %d nodes in %s", e.Nodes, e.Elapsed.Round(time.Millisecond))
+}
+
+// search tracks the budget of one backtracking run. A nil search is unlimited,
+// which the generator uses for the boards it builds itself.
+type search struct {
+	ctx     context.Context // Stops the run early when the caller gives up, e.g. the client disconnects
+	budget  SolveBudget
+	started time.Time
+	nodes   int
+	err     error
+}
+
+func newSearch(ctx context.Context, budget SolveBudget) *search {
+	return &search{ctx: ctx, budget: budget, started: time.Now()}
+}
+
+// visit counts one placement and reports whether the search has to stop
+func (sr *search) visit() bool {
+	if sr == nil {
+		return false
+	}
+	if sr.err != nil {
+		return true
+	}
+
+	sr.nodes++
+	exceeded := sr.budget.MaxNodes > 0 && sr.nodes > sr.budget.MaxNodes
+	// Reading the clock or the context costs more than a placement, so only check them now and then
+	if !exceeded && sr.nodes%1024 == 0 {
+		if err := sr.ctx.Err(); err != nil {
+			sr.err = err
+			return true
+		}
+		exceeded = sr.budget.Timeout > 0 && time.Since(sr.started) > sr.budget.Timeout
+	}
+	if exceeded {
+		sr.err = &BudgetExceededError{Nodes: sr.nodes, Elapsed: time.Since(sr.started)}
+	}
+	return exceeded
+}
+
+// SetSolveBudget changes the limits applied to SolvePuzzle and CountSolutions
+func (s *Service) SetSolveBudget(budget SolveBudget) {
+	s.budget = budget
+}
@@ -0,0 +1,117 @@
+package sudoku
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Orderings of the three rows of a band, or columns of a stack, or bands, or stacks
+var perms3 = [6][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+
+// Canonical returns the minlex form of a board: the lexicographically smallest grid, read
+// row by row with empty cells as 0, reachable by relabeling digits, transposing, and
+// reordering bands, stacks, rows within a band and columns within a stack. Boards that are
+// the same puzzle in disguise share one canonical form.
+func Canonical(board Board) Board {
+	c := &canonicalizer{}
+	for i := range c.best {
+		c.best[i] = 10 // Larger than any cell, so the first complete grid replaces it
+	}
+
+	var transposed Board
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			transposed[j][i] = board[i][j]
+		}
+	}
+
+	for _, grid := range []*Board{&board, &transposed} {
+		c.grid = grid
+		for _, stacks := range perms3 {
+			for _, p0 := range perms3 {
+				for _, p1 := range perms3 {
+					for _, p2 := range perms3 {
+						inStack := [3][3]int{p0, p1, p2}
+						for s := 0; s < 3; s++ {
+							for k := 0; k < 3; k++ {
+								c.cols[s*3+k] = stacks[s]*3 + inStack[s][k]
+							}
+						}
+						c.placeRow(0, 0, [10]int{}, 1)
+					}
+				}
+			}
+		}
+	}
+
+	var canonical Board
+	for pos, value := range c.best {
+		canonical[pos/9][pos%9] = value
+	}
+	return canonical
+}
+
+// CanonicalHash identifies the puzzle up to the symmetries Canonical removes
+func CanonicalHash(board Board) string {
+	sum := sha256.Sum256([]byte(BoardToString(Canonical(board))))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalizer searches row orders for one column order, keeping the smallest grid found so far
+type canonicalizer struct {
+	grid *Board
+	cols [9]int
+	cur  [81]int
+	best [81]int
+}
+
+// Fill output row slot with each source row the band structure allows, relabeling digits in
+// order of first appearance, and prune any branch whose grid is already larger than the best
+func (c *canonicalizer) placeRow(slot int, usedRows uint16, labels [10]int, next int) {
+	for row := 0; row < 9; row++ {
+		if usedRows&(1<<row) != 0 {
+			continue
+		}
+		// A new band starts every third slot; otherwise stay in the band being filled
+		if slot%3 != 0 && usedRows&(7<<(row/3*3)) == 0 {
+			continue
+		}
+
+		rowLabels, rowNext := labels, next
+		for k, col := range c.cols {
+			value := c.grid[row][col]
+			if value != 0 {
+				if rowLabels[value] == 0 {
+					rowLabels[value] = rowNext
+					rowNext++
+				}
+				value = rowLabels[value]
+			}
+			c.cur[slot*9+k] = value
+		}
+
+		cmp := compareCells(c.cur[:(slot+1)*9], c.best[:(slot+1)*9])
+		if cmp > 0 {
+			continue
+		}
+		if slot == 8 {
+			if cmp < 0 {
+				c.best = c.cur
+			}
+			continue
+		}
+		c.placeRow(slot+1, usedRows|1<<row, rowLabels, rowNext)
+	}
+}
+
+func compareCells(a, b []int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
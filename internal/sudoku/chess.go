@@ -0,0 +1,50 @@
+package sudoku
+
+import (
+	"context"
+	"math/rand"
+
+	"sudoku/internal/models"
+)
+
+// Offsets of the cells a knight and a king can move to
+var (
+	knightMoves = [8][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	kingMoves   = [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+)
+
+// Digits on the cells a move away from a cell
+func movePeerDigits(board *Board, row, col int, moves *[8][2]int) uint16 {
+	var used uint16
+	for _, move := range moves {
+		i, j := row+move[0], col+move[1]
+		if i >= 0 && i < 9 && j >= 0 && j < 9 {
+			used |= 1 << board[i][j]
+		}
+	}
+	return used &^ 1
+}
+
+// A random solved board under the rules' chess constraints, found the classic way when there are none
+func (s *Service) randomSolution(rules Rules, rng *rand.Rand) (Board, bool) {
+	var solved Board
+	if !rules.AntiKnight && !rules.AntiKing {
+		return solved, s.solveRandom(&solved, rng)
+	}
+	v, _ := newVariantSearch(&solved, rules)
+	return solved, v.solveRandom(&solved, rng, newSearch(context.Background(), SolveBudget{MaxNodes: variantCheckNodes}))
+}
+
+// Chess constraints of the options, added to a variant's rules
+func (o GenerateOptions) chessRules(rules Rules) Rules {
+	rules.AntiKnight = o.AntiKnight
+	rules.AntiKing = o.AntiKing
+	return rules
+}
+
+// GenerateChess generates a classic puzzle under the chess constraints of opts, and its
+// solution. Like GenerateDiagonal, the difficulty's generation profile sets the clue count and
+// symmetry.
+func (s *Service) GenerateChess(difficulty models.Difficulty, opts GenerateOptions) (Board, Board, error) {
+	return s.generateByRules(opts.chessRules(Rules{}), "a chess-constrained", difficulty, opts)
+}
@@ -0,0 +1,41 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+)
+
+// Conflicts lists what is wrong with a board in progress without giving away the solution
+type Conflicts struct {
+	Duplicates []Cell `json:"duplicates"` // Filled cells repeating a value in their row, column or box
+	Dead       []Cell `json:"dead"`       // Empty cells with no candidate left
+	Solvable   bool   `json:"solvable"`   // Whether the board can still be completed
+}
+
+// FindConflicts checks a board in progress for broken row/column/box constraints and, when there
+// are none, whether the filled cells still leave the board a solution. Only the cells at fault are
+// reported, never the values that belong in them.
+func (s *Service) FindConflicts(ctx context.Context, board Board) (*Conflicts, error) {
+	conflicts := &Conflicts{Duplicates: []Cell{}, Dead: []Cell{}}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			peers := peerDigits(board, i, j)
+			switch {
+			case board[i][j] != 0 && peers&(1<<board[i][j]) != 0:
+				conflicts.Duplicates = append(conflicts.Duplicates, Cell{Row: i, Col: j})
+			case board[i][j] == 0 && allDigits&^peers == 0:
+				conflicts.Dead = append(conflicts.Dead, Cell{Row: i, Col: j})
+			}
+		}
+	}
+	if len(conflicts.Duplicates) > 0 || len(conflicts.Dead) > 0 {
+		return conflicts, nil
+	}
+
+	_, err := s.SolvePuzzle(ctx, board)
+	if err != nil && !errors.Is(err, ErrUnsolvable) {
+		return nil, err
+	}
+	conflicts.Solvable = err == nil
+	return conflicts, nil
+}
@@ -0,0 +1,311 @@
+package sudoku
+
+// Constraint is one rule on the digits of a board. A variant is a set of constraints, and the
+// variant solver, checks and generators only go through this interface, so a new variant only
+// needs its own constraints.
+type Constraint interface {
+	// Fits reports whether value can go in the cell (row, col) of board, taken to be empty,
+	// judging only by the other cells
+	Fits(board *Board, row, col, value int) bool
+	// Track starts following the digits of board through a search, reporting false when they
+	// already break the constraint. The search places and takes off digits on that same board.
+	Track(board *Board) (Tracker, bool)
+}
+
+// Tracker follows the digits a search places on a board for one constraint
+type Tracker interface {
+	// Candidates returns the digits the empty cell can still take
+	Candidates(row, col int) uint16
+	Place(row, col, value int)
+	Unplace(row, col, value int)
+}
+
+// RowColBox is the classic rule: every row, column and box holds each digit once. With Regions,
+// the jigsaw regions take the place of the boxes.
+type RowColBox struct {
+	Regions *Regions
+}
+
+type rowColBoxTracker struct {
+	regionOf           *Regions
+	rows, cols, houses [9]uint16
+}
+
+func (c RowColBox) Fits(board *Board, row, col, value int) bool {
+	return regionPeerDigits(*board, row, col, c.Regions)&(1<<value) == 0
+}
+
+func (c RowColBox) Track(board *Board) (Tracker, bool) {
+	t := &rowColBoxTracker{regionOf: c.Regions}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			value := board[i][j]
+			if value == 0 {
+				continue
+			}
+			if t.Candidates(i, j)&(1<<value) == 0 {
+				return nil, false
+			}
+			t.Place(i, j, value)
+		}
+	}
+	return t, true
+}
+
+// Index of the region, or the 3x3 box, holding a cell
+func (t *rowColBoxTracker) house(row, col int) int {
+	if t.regionOf != nil {
+		return t.regionOf[row][col]
+	}
+	return boxIndex(row, col)
+}
+
+func (t *rowColBoxTracker) Candidates(row, col int) uint16 {
+	return allDigits &^ (t.rows[row] | t.cols[col] | t.houses[t.house(row, col)])
+}
+
+func (t *rowColBoxTracker) Place(row, col, value int) {
+	t.toggle(row, col, value)
+}
+
+func (t *rowColBoxTracker) Unplace(row, col, value int) {
+	t.toggle(row, col, value)
+}
+
+func (t *rowColBoxTracker) toggle(row, col, value int) {
+	bit := uint16(1) << value
+	t.rows[row] ^= bit
+	t.cols[col] ^= bit
+	t.houses[t.house(row, col)] ^= bit
+}
+
+// Diagonal is the X-Sudoku rule: both long diagonals hold every digit once
+type Diagonal struct{}
+
+type diagonalTracker struct {
+	diag, anti uint16 // Digits placed on the main and the anti diagonal
+}
+
+func (Diagonal) Fits(board *Board, row, col, value int) bool {
+	for k := 0; k < 9; k++ {
+		if (row == col && k != row && board[k][k] == value) || (row+col == 8 && k != row && board[k][8-k] == value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (Diagonal) Track(board *Board) (Tracker, bool) {
+	t := &diagonalTracker{}
+	for k := 0; k < 9; k++ {
+		if value := board[k][k]; value != 0 {
+			if t.diag&(1<<value) != 0 {
+				return nil, false
+			}
+			t.diag |= 1 << value
+		}
+		if value := board[k][8-k]; value != 0 {
+			if t.anti&(1<<value) != 0 {
+				return nil, false
+			}
+			t.anti |= 1 << value
+		}
+	}
+	return t, true
+}
+
+func (t *diagonalTracker) Candidates(row, col int) uint16 {
+	candidates := allDigits
+	if row == col {
+		candidates &^= t.diag
+	}
+	if row+col == 8 {
+		candidates &^= t.anti
+	}
+	return candidates
+}
+
+func (t *diagonalTracker) Place(row, col, value int) {
+	t.toggle(row, col, value)
+}
+
+func (t *diagonalTracker) Unplace(row, col, value int) {
+	t.toggle(row, col, value)
+}
+
+func (t *diagonalTracker) toggle(row, col, value int) {
+	if row == col {
+		t.diag ^= 1 << value
+	}
+	if row+col == 8 {
+		t.anti ^= 1 << value
+	}
+}
+
+// Cages is the Killer rule: the digits of every cage differ and add up to its sum
+type Cages []Cage
+
+type cagesTracker struct {
+	cageOf [9][9]int // Index into cages, -1 for cells outside every cage
+	cages  []cageState
+}
+
+type cageState struct {
+	left    int    // Sum still to be made by the empty cells
+	empty   int    // Empty cells
+	used    uint16 // Digits placed
+	options uint16 // Digits that can go in an empty cell and still leave the sum reachable
+}
+
+func (c Cages) Fits(board *Board, row, col, value int) bool {
+	for _, cage := range c {
+		if contains(cage.Cells, Cell{Row: row, Col: col}) {
+			without := *board
+			without[row][col] = 0
+			return cageFits(without, cage, value)
+		}
+	}
+	return true
+}
+
+func (c Cages) Track(board *Board) (Tracker, bool) {
+	t := &cagesTracker{cages: make([]cageState, len(c))}
+	for i := range t.cageOf {
+		for j := range t.cageOf[i] {
+			t.cageOf[i][j] = -1
+		}
+	}
+	for n, cage := range c {
+		state := &t.cages[n]
+		state.left = cage.Sum
+		for _, cell := range cage.Cells {
+			t.cageOf[cell.Row][cell.Col] = n
+			value := board[cell.Row][cell.Col]
+			if value == 0 {
+				state.empty++
+				continue
+			}
+			if state.used&(1<<value) != 0 {
+				return nil, false
+			}
+			state.used |= 1 << value
+			state.left -= value
+		}
+		if state.left < 0 || (state.empty == 0 && state.left != 0) {
+			return nil, false
+		}
+		state.update()
+	}
+	return t, true
+}
+
+// Work out the options of the cage from the digits placed in it
+func (s *cageState) update() {
+	s.options = 0
+	if s.left < 0 || s.left > 45 {
+		return
+	}
+	for _, set := range digitSets[s.empty][s.left] {
+		if set&s.used == 0 {
+			s.options |= set
+		}
+	}
+}
+
+func (t *cagesTracker) Candidates(row, col int) uint16 {
+	if n := t.cageOf[row][col]; n >= 0 {
+		return t.cages[n].options
+	}
+	return allDigits
+}
+
+func (t *cagesTracker) Place(row, col, value int) {
+	t.mark(row, col, value, 1)
+}
+
+func (t *cagesTracker) Unplace(row, col, value int) {
+	t.mark(row, col, value, -1)
+}
+
+func (t *cagesTracker) mark(row, col, value, sign int) {
+	n := t.cageOf[row][col]
+	if n < 0 {
+		return
+	}
+	state := &t.cages[n]
+	state.left -= sign * value
+	state.empty -= sign
+	state.used ^= 1 << value
+	state.update()
+}
+
+// Fits reports whether the cell's parity marker, if any, allows the digit. Markers don't depend on
+// the other cells, so Parities is its own tracker.
+func (p *Parities) Fits(board *Board, row, col, value int) bool {
+	return parityDigits[p[row][col]]&(1<<value) != 0
+}
+
+func (p *Parities) Track(board *Board) (Tracker, bool) {
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if value := board[i][j]; value != 0 && !p.Fits(board, i, j, value) {
+				return nil, false
+			}
+		}
+	}
+	return p, true
+}
+
+func (p *Parities) Candidates(row, col int) uint16 {
+	return parityDigits[p[row][col]]
+}
+
+func (p *Parities) Place(row, col, value int)   {}
+func (p *Parities) Unplace(row, col, value int) {}
+
+// AntiKnight is the chess rule that cells a knight's move apart never hold the same digit
+type AntiKnight struct{}
+
+func (AntiKnight) Fits(board *Board, row, col, value int) bool {
+	return movePeerDigits(board, row, col, &knightMoves)&(1<<value) == 0
+}
+
+func (AntiKnight) Track(board *Board) (Tracker, bool) {
+	return trackMoves(board, &knightMoves)
+}
+
+// AntiKing is the chess rule that cells a king's move apart never hold the same digit, so
+// diagonal neighbours differ too
+type AntiKing struct{}
+
+func (AntiKing) Fits(board *Board, row, col, value int) bool {
+	return movePeerDigits(board, row, col, &kingMoves)&(1<<value) == 0
+}
+
+func (AntiKing) Track(board *Board) (Tracker, bool) {
+	return trackMoves(board, &kingMoves)
+}
+
+// moveTracker reads the cells a chess move away off the board being searched
+type moveTracker struct {
+	board *Board
+	moves *[8][2]int
+}
+
+func trackMoves(board *Board, moves *[8][2]int) (Tracker, bool) {
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if value := board[i][j]; value != 0 && movePeerDigits(board, i, j, moves)&(1<<value) != 0 {
+				return nil, false
+			}
+		}
+	}
+	return &moveTracker{board: board, moves: moves}, true
+}
+
+func (t *moveTracker) Candidates(row, col int) uint16 {
+	return allDigits &^ movePeerDigits(t.board, row, col, t.moves)
+}
+
+func (t *moveTracker) Place(row, col, value int)   {}
+func (t *moveTracker) Unplace(row, col, value int) {}
@@ -0,0 +1,58 @@
+package sudoku
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"sudoku/internal/models"
+)
+
+// diagonalRules are the rules of X-Sudoku
+var diagonalRules = Rules{Diagonals: true}
+
+// GenerateDiagonal generates an X-Sudoku puzzle and its solution. The difficulty's generation
+// profile sets the clue count and symmetry; its techniques are not checked, as the human
+// techniques don't know about the diagonals.
+func (s *Service) GenerateDiagonal(difficulty models.Difficulty, opts GenerateOptions) (Board, Board, error) {
+	return s.generateByRules(opts.chessRules(diagonalRules), "an X-Sudoku", difficulty, opts)
+}
+
+// Generate a puzzle under rules that only constrain which digits cells can take, following the
+// clue count and symmetry of the difficulty's generation profile. name describes the puzzle in
+// the error reported when no attempt fits the profile.
+func (s *Service) generateByRules(rules Rules, name string, difficulty models.Difficulty, opts GenerateOptions) (Board, Board, error) {
+	profile, err := s.GetGenerationProfile(difficulty)
+	if err != nil {
+		return Board{}, Board{}, err
+	}
+	if opts.Symmetry != "" {
+		profile.Symmetry = opts.Symmetry
+		if err := ValidateGenerationProfile(profile); err != nil {
+			return Board{}, Board{}, err
+		}
+	}
+
+	attempt := func(rng *rand.Rand) (Board, Board, bool) {
+		var solved Board
+		v, _ := newVariantSearch(&solved, rules)
+		if !v.solveRandom(&solved, rng, newSearch(context.Background(), SolveBudget{MaxNodes: variantCheckNodes})) {
+			return Board{}, Board{}, false
+		}
+		clues := profile.MinClues + rng.Intn(profile.MaxClues-profile.MinClues+1)
+		puzzle, givens := carveVariant(solved, rules, clues, profile.Symmetry, rng)
+		return puzzle, solved, givens <= profile.MaxClues
+	}
+
+	var puzzle, solved Board
+	var ok bool
+	if opts.Seed != nil {
+		puzzle, solved, ok = generateSeeded(*opts.Seed, maxGenerationAttempts, attempt)
+	} else {
+		puzzle, solved, ok = generateConcurrently(maxGenerationAttempts, attempt)
+	}
+	if !ok {
+		return Board{}, Board{}, fmt.Errorf("failed to generate %s puzzle matching the difficulty profile", name)
+	}
+	return puzzle, solved, nil
+}
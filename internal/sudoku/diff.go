@@ -0,0 +1,74 @@
+package sudoku
+
+// GridDiff compares a saved board against its starting grid and solution
+type GridDiff struct {
+	Givens    int `json:"givens"`
+	Filled    int `json:"filled"` // Cells filled by the player
+	Correct   int `json:"correct"`
+	Incorrect int `json:"incorrect"`
+	Empty     int `json:"empty"`
+
+	// Cell lists are only included in detailed diffs
+	CorrectCells   []Cell `json:"correct_cells,omitempty"`
+	IncorrectCells []Cell `json:"incorrect_cells,omitempty"`
+	EmptyCells     []Cell `json:"empty_cells,omitempty"`
+}
+
+// Compare the current board with the starting grid and the solution
+func DiffBoards(start, current, solution Board, detailed bool) GridDiff {
+	var diff GridDiff
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			cell := Cell{Row: i, Col: j}
+			switch {
+			case start[i][j] != 0:
+				diff.Givens++
+			case current[i][j] == 0:
+				diff.Empty++
+				if detailed {
+					diff.EmptyCells = append(diff.EmptyCells, cell)
+				}
+			case current[i][j] == solution[i][j]:
+				diff.Filled++
+				diff.Correct++
+				if detailed {
+					diff.CorrectCells = append(diff.CorrectCells, cell)
+				}
+			default:
+				diff.Filled++
+				diff.Incorrect++
+				if detailed {
+					diff.IncorrectCells = append(diff.IncorrectCells, cell)
+				}
+			}
+		}
+	}
+	return diff
+}
+
+// Divergence is a cell where two solutions of an ambiguous puzzle differ
+type Divergence struct {
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Values [2]int `json:"values"` // The cell's value in each solution
+}
+
+// Ambiguity shows why a puzzle doesn't have a unique solution: two of its solutions and
+// the cells where they differ
+type Ambiguity struct {
+	Solutions [2]string    `json:"solutions"`
+	Cells     []Divergence `json:"cells"`
+}
+
+// DiffSolutions compares two solutions of the same puzzle, as found by Service.Solutions
+func DiffSolutions(first, second Board) *Ambiguity {
+	ambiguity := &Ambiguity{Solutions: [2]string{BoardToString(first), BoardToString(second)}}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if first[i][j] != second[i][j] {
+				ambiguity.Cells = append(ambiguity.Cells, Divergence{Row: i, Col: j, Values: [2]int{first[i][j], second[i][j]}})
+			}
+		}
+	}
+	return ambiguity
+}
@@ -0,0 +1,165 @@
+package sudoku
+
+// Exact cover columns: each cell holds one digit, and each row, column and box holds each digit once
+const (
+	cellColumns   = 0
+	rowColumns    = 81
+	colColumns    = 162
+	boxColumns    = 243
+	coverColumns  = 324
+	candidateRows = 729
+)
+
+// dlx solves a board as an exact cover problem with Knuth's Dancing Links. Every candidate
+// placement is a row covering four columns; removing and restoring nodes is a few pointer
+// writes, which makes enumerating solutions much cheaper than plain backtracking.
+// Nodes live in parallel slices, index 0 is the root and 1-324 are the column headers.
+type dlx struct {
+	left, right, up, down []int
+	column                []int // Column header of each node
+	placement             []int // Placement row of each node, row*81 + col*9 + value-1
+	size                  []int // Nodes left in each column
+
+	chosen []int // Placements of the current partial solution
+	base   Board // The board the problem was built from
+}
+
+// Build the problem for a board. Filled cells contribute only their own placement and
+// empty cells one placement per candidate, so conflicting clues leave no exact cover.
+func newDLX(board Board) *dlx {
+	d := &dlx{base: board}
+	capacity := 1 + coverColumns + 4*candidateRows
+	d.left = make([]int, 0, capacity)
+	d.right = make([]int, 0, capacity)
+	d.up = make([]int, 0, capacity)
+	d.down = make([]int, 0, capacity)
+	d.column = make([]int, 0, capacity)
+	d.placement = make([]int, 0, capacity)
+	d.size = make([]int, 1+coverColumns)
+
+	for h := 0; h <= coverColumns; h++ {
+		d.left = append(d.left, h-1)
+		d.right = append(d.right, h+1)
+		d.up = append(d.up, h)
+		d.down = append(d.down, h)
+		d.column = append(d.column, h)
+		d.placement = append(d.placement, -1)
+	}
+	d.left[0] = coverColumns
+	d.right[coverColumns] = 0
+
+	m := newMasks(&board)
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if value := board[row][col]; value != 0 {
+				d.addPlacement(row, col, value)
+				continue
+			}
+			for _, value := range digits(m.candidates(row, col)) {
+				d.addPlacement(row, col, value)
+			}
+		}
+	}
+	return d
+}
+
+// Append the four nodes of placing value at row, col
+func (d *dlx) addPlacement(row, col, value int) {
+	v := value - 1
+	headers := [4]int{
+		1 + cellColumns + row*9 + col,
+		1 + rowColumns + row*9 + v,
+		1 + colColumns + col*9 + v,
+		1 + boxColumns + boxIndex(row, col)*9 + v,
+	}
+
+	first := len(d.column)
+	for k, h := range headers {
+		node := first + k
+		d.left = append(d.left, first+(k+3)%4)
+		d.right = append(d.right, first+(k+1)%4)
+		d.up = append(d.up, d.up[h])
+		d.down = append(d.down, h)
+		d.column = append(d.column, h)
+		d.placement = append(d.placement, row*81+col*9+v)
+
+		d.down[d.up[h]] = node
+		d.up[h] = node
+		d.size[h]++
+	}
+}
+
+func (d *dlx) cover(c int) {
+	d.right[d.left[c]] = d.right[c]
+	d.left[d.right[c]] = d.left[c]
+	for i := d.down[c]; i != c; i = d.down[i] {
+		for j := d.right[i]; j != i; j = d.right[j] {
+			d.down[d.up[j]] = d.down[j]
+			d.up[d.down[j]] = d.up[j]
+			d.size[d.column[j]]--
+		}
+	}
+}
+
+func (d *dlx) uncover(c int) {
+	for i := d.up[c]; i != c; i = d.up[i] {
+		for j := d.left[i]; j != i; j = d.left[j] {
+			d.size[d.column[j]]++
+			d.down[d.up[j]] = j
+			d.up[d.down[j]] = j
+		}
+	}
+	d.right[d.left[c]] = c
+	d.left[d.right[c]] = c
+}
+
+// Search for exact covers, calling found for each until it returns true.
+// Reports whether the search stopped early, because found asked to or the budget ran out.
+func (d *dlx) search(sr *search, found func(Board) bool) bool {
+	if d.right[0] == 0 {
+		return found(d.board())
+	}
+
+	// The column with the fewest remaining nodes keeps the tree narrow
+	c := d.right[0]
+	for h := d.right[c]; h != 0; h = d.right[h] {
+		if d.size[h] < d.size[c] {
+			c = h
+		}
+	}
+	if d.size[c] == 0 {
+		return false
+	}
+
+	d.cover(c)
+	defer d.uncover(c)
+	for r := d.down[c]; r != c; r = d.down[r] {
+		if sr.visit() {
+			return true
+		}
+		d.chosen = append(d.chosen, d.placement[r])
+		for j := d.right[r]; j != r; j = d.right[j] {
+			d.cover(d.column[j])
+		}
+
+		stop := d.search(sr, found)
+
+		for j := d.left[r]; j != r; j = d.left[j] {
+			d.uncover(d.column[j])
+		}
+		d.chosen = d.chosen[:len(d.chosen)-1]
+		if stop {
+			return true
+		}
+	}
+	return false
+}
+
+// The board described by the chosen placements
+func (d *dlx) board() Board {
+	board := d.base
+	for _, p := range d.chosen {
+		board[p/81][p/9%9] = p%9 + 1
+	}
+	return board
+}
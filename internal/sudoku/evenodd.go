@@ -0,0 +1,119 @@
+package sudoku
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"sudoku/internal/models"
+)
+
+// Parity marks a cell of an Even/Odd puzzle as holding an even or an odd digit
+type Parity byte
+
+const (
+	NoParity Parity = iota
+	Even
+	Odd
+)
+
+// Parities holds the parity marker of every cell of an Even/Odd puzzle
+type Parities [9][9]Parity
+
+// Candidate masks of the digits each marker allows
+var parityDigits = [3]uint16{
+	NoParity: allDigits,
+	Even:     1<<2 | 1<<4 | 1<<6 | 1<<8,
+	Odd:      1<<1 | 1<<3 | 1<<5 | 1<<7 | 1<<9,
+}
+
+// evenOddProfile shapes the Even/Odd puzzles of a difficulty: fewer markers and givens make them harder
+type evenOddProfile struct {
+	Markers int // Cells marked before carving; markers on cells left given are dropped
+	Givens  int // Digits left given once the puzzle is carved
+}
+
+var evenOddProfiles = map[models.Difficulty]evenOddProfile{
+	models.Easy:   {Markers: 40, Givens: 34},
+	models.Medium: {Markers: 32, Givens: 28},
+	models.Hard:   {Markers: 24, Givens: 24},
+	models.Expert: {Markers: 20, Givens: 20},
+}
+
+// FormatParities writes parity markers as stored with Even/Odd puzzles: 81 characters, row by row,
+// 'e' for even, 'o' for odd and '.' for unmarked cells
+func FormatParities(parities Parities) string {
+	var sb strings.Builder
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			sb.WriteByte(".eo"[parities[i][j]])
+		}
+	}
+	return sb.String()
+}
+
+// ParseParities reads parity markers written by FormatParities
+func ParseParities(s string) (Parities, error) {
+	var parities Parities
+	if len(s) != 81 {
+		return parities, errors.New("parity markers must cover 81 cells")
+	}
+	for i, ch := range s {
+		switch ch {
+		case 'e':
+			parities[i/9][i%9] = Even
+		case 'o':
+			parities[i/9][i%9] = Odd
+		case '.':
+		default:
+			return parities, fmt.Errorf("invalid parity marker %q, use 'e', 'o' or '.'", ch)
+		}
+	}
+	return parities, nil
+}
+
+// GenerateEvenOdd generates an Even/Odd puzzle, its solution and its parity markers. Only
+// opts.Seed, opts.Symmetry, which applies to the given digits, and the chess constraints are used.
+func (s *Service) GenerateEvenOdd(difficulty models.Difficulty, opts GenerateOptions) (Board, Board, Parities, error) {
+	profile, ok := evenOddProfiles[difficulty]
+	if !ok {
+		return Board{}, Board{}, Parities{}, fmt.Errorf("no Even/Odd profile for difficulty %q", difficulty)
+	}
+
+	var layouts sync.Map // Markers of each attempt, by its solution
+	attempt := func(rng *rand.Rand) (Board, Board, bool) {
+		solved, ok := s.randomSolution(opts.chessRules(Rules{}), rng)
+		if !ok {
+			return Board{}, Board{}, false
+		}
+		var parities Parities
+		for _, pos := range rng.Perm(81)[:profile.Markers] {
+			parities[pos/9][pos%9] = Even + Parity(solved[pos/9][pos%9]%2)
+		}
+
+		puzzle, givens := carveVariant(solved, opts.chessRules(Rules{Parities: &parities}), profile.Givens, opts.Symmetry, rng)
+		for i := 0; i < 9; i++ {
+			for j := 0; j < 9; j++ {
+				if puzzle[i][j] != 0 {
+					parities[i][j] = NoParity
+				}
+			}
+		}
+		layouts.Store(solved, parities)
+		return puzzle, solved, givens <= profile.Givens
+	}
+
+	var puzzle, solved Board
+	if opts.Seed != nil {
+		puzzle, solved, ok = generateSeeded(*opts.Seed, maxGenerationAttempts, attempt)
+	} else {
+		puzzle, solved, ok = generateConcurrently(maxGenerationAttempts, attempt)
+	}
+	if !ok {
+		return Board{}, Board{}, Parities{}, errors.New("failed to generate an Even/Odd puzzle matching the difficulty profile")
+	}
+	parities, _ := layouts.Load(solved)
+	return puzzle, solved, parities.(Parities), nil
+}
@@ -0,0 +1,209 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explanation is a move or deduction put into words, with the cells the reasoning rests on
+type Explanation struct {
+	Text         string `json:"text"`
+	Constraining []Cell `json:"constraining"` // Placed digits and pattern cells that force the step
+}
+
+// ExplainMove explains why a single follows from the board it was found on: "7 can only go in r4c6
+// within box 5 because the 7s in r4c1 and r2c5 rule out its other cells". Deductions leading to the
+// move are explained first.
+func ExplainMove(board Board, move *Move) *Explanation {
+	e := &Explanation{Constraining: []Cell{}}
+	var steps []string
+	for i := range move.Deductions {
+		d := &move.Deductions[i]
+		steps = append(steps, d.Reason)
+		e.add(d.Cells...)
+	}
+
+	target := Cell{Row: move.Row, Col: move.Col}
+	var sentence string
+	if move.Reason == "Naked Single" {
+		sentence = e.nakedSingle(board, target, move.Value)
+	} else if u, ok := moveUnit(move); ok {
+		sentence = e.hiddenSingle(board, target, move.Value, u)
+	} else {
+		sentence = fmt.Sprintf("%s is %d (%s).", cellName(target), move.Value, move.Reason)
+	}
+
+	if len(steps) > 0 {
+		e.Text = "First, " + strings.Join(steps, "; then ") + ". Now " + sentence
+	} else {
+		e.Text = sentence
+	}
+	return e
+}
+
+// ExplainDeduction explains an elimination step and lists the candidates it removes
+func ExplainDeduction(d *Deduction) *Explanation {
+	e := &Explanation{Constraining: []Cell{}}
+	e.add(d.Cells...)
+
+	var removals []string
+	for _, elim := range d.Eliminations {
+		removals = append(removals, fmt.Sprintf("%s from %s", joinInts(elim.Values), cellName(Cell{Row: elim.Row, Col: elim.Col})))
+	}
+	e.Text = d.Reason + "."
+	if len(removals) > 0 {
+		e.Text += " Remove " + joinWords(removals) + "."
+	}
+	return e
+}
+
+// A naked single: every other digit already sits in the cell's row, column or box
+func (e *Explanation) nakedSingle(board Board, target Cell, value int) string {
+	var seen, missing []int
+	for v := 1; v <= 9; v++ {
+		if v == value {
+			continue
+		}
+		if peer, ok := peerHolding(board, target, v); ok {
+			seen = append(seen, v)
+			e.add(peer)
+		} else {
+			missing = append(missing, v)
+		}
+	}
+
+	text := fmt.Sprintf("%s can only be %d", cellName(target), value)
+	switch {
+	case len(missing) == 0:
+		text += fmt.Sprintf(" because its row, column and box already hold %s.", joinInts(seen))
+	case len(seen) == 0:
+		text += fmt.Sprintf(" because the steps above rule out %s.", joinInts(missing))
+	default:
+		text += fmt.Sprintf(" because its row, column and box already hold %s, and the steps above rule out %s.", joinInts(seen), joinInts(missing))
+	}
+	return text
+}
+
+// A hidden single: every other empty cell of the house sees the value or has lost it to a deduction
+func (e *Explanation) hiddenSingle(board Board, target Cell, value int, u unit) string {
+	var blockers, ruledOut []Cell
+	for _, cell := range u.cells {
+		if cell == target || board[cell.Row][cell.Col] != 0 {
+			continue
+		}
+		// Reuse a blocker already cited so the sentence names as few cells as possible
+		if peer, ok := citedPeer(board, cell, value, blockers); ok {
+			if !contains(blockers, peer) {
+				blockers = append(blockers, peer)
+			}
+			continue
+		}
+		ruledOut = append(ruledOut, cell)
+	}
+	e.add(blockers...)
+
+	text := fmt.Sprintf("%d can only go in %s within %s", value, cellName(target), unitName(u))
+	var names []string
+	for _, cell := range blockers {
+		names = append(names, cellName(cell))
+	}
+	switch {
+	case len(blockers) == 0 && len(ruledOut) == 0:
+		text += fmt.Sprintf(" because it is the last empty cell of the %s.", strings.ToLower(u.kind))
+	case len(ruledOut) == 0:
+		text += fmt.Sprintf(" because the %d%s in %s rule out its other cells.", value, plural(len(blockers)), joinWords(names))
+	case len(blockers) == 0:
+		text += fmt.Sprintf(" because the steps above rule out %d from %s.", value, joinCells(ruledOut))
+	default:
+		text += fmt.Sprintf(" because the %d%s in %s rule out most of its other cells, and the steps above rule it out from %s.", value, plural(len(blockers)), joinWords(names), joinCells(ruledOut))
+	}
+	return text
+}
+
+func (e *Explanation) add(cells ...Cell) {
+	for _, cell := range cells {
+		if !contains(e.Constraining, cell) {
+			e.Constraining = append(e.Constraining, cell)
+		}
+	}
+}
+
+// The house a hidden single was found in, from its reason ("Hidden Single in Box")
+func moveUnit(move *Move) (unit, bool) {
+	parts := strings.SplitN(move.Reason, " in ", 2)
+	if len(parts) != 2 {
+		return unit{}, false
+	}
+	target := Cell{Row: move.Row, Col: move.Col}
+	for _, u := range allUnits() {
+		if u.kind == parts[1] && contains(u.cells, target) {
+			return u, true
+		}
+	}
+	return unit{}, false
+}
+
+// A placed cell seeing cell that holds value
+func peerHolding(board Board, cell Cell, value int) (Cell, bool) {
+	return citedPeer(board, cell, value, nil)
+}
+
+// Like peerHolding, but prefers a peer from cited
+func citedPeer(board Board, cell Cell, value int, cited []Cell) (Cell, bool) {
+	for _, peer := range cited {
+		if peer != cell && sees(peer, cell) && board[peer.Row][peer.Col] == value {
+			return peer, true
+		}
+	}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			peer := Cell{Row: i, Col: j}
+			if peer != cell && board[i][j] == value && sees(peer, cell) {
+				return peer, true
+			}
+		}
+	}
+	return Cell{}, false
+}
+
+// cellName writes a cell as players do, 1-based: r4c6
+func cellName(cell Cell) string {
+	return fmt.Sprintf("r%dc%d", cell.Row+1, cell.Col+1)
+}
+
+// unitName writes a house 1-based: "box 5"
+func unitName(u unit) string {
+	ref := unitRef(u)
+	return fmt.Sprintf("%s %d", strings.ToLower(ref.Kind), ref.Index+1)
+}
+
+func joinCells(cells []Cell) string {
+	var names []string
+	for _, cell := range cells {
+		names = append(names, cellName(cell))
+	}
+	return joinWords(names)
+}
+
+func joinInts(values []int) string {
+	var words []string
+	for _, v := range values {
+		words = append(words, fmt.Sprint(v))
+	}
+	return joinWords(words)
+}
+
+// joinWords lists words as a sentence does: "a, b and c"
+func joinWords(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,330 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strings"
+
+	"sudoku/internal/models"
+)
+
+// Shape is the size of a board and of its boxes. Board hasn't been generalized: it is still the
+// fixed 9x9 array every game, variant, technique and stored puzzle uses. Grid is a separate type
+// for boards of any shape, which can be generated, solved and counted but not played.
+type Shape struct {
+	Size    int `json:"size"`     // Rows, columns and digits
+	BoxRows int `json:"box_rows"` // Rows of a box
+	BoxCols int `json:"box_cols"` // Columns of a box
+}
+
+var (
+	Shape4  = Shape{Size: 4, BoxRows: 2, BoxCols: 2}  // Kids puzzles
+	Shape6  = Shape{Size: 6, BoxRows: 2, BoxCols: 3}  // Minis, with 2x3 boxes
+	Shape9  = Shape{Size: 9, BoxRows: 3, BoxCols: 3}  // Board
+	Shape16 = Shape{Size: 16, BoxRows: 4, BoxCols: 4} // Hex sudoku, with digits 1-9 and A-G
+)
+
+// ShapeOf returns the shape of a board size
+func ShapeOf(size int) (Shape, error) {
+	for _, shape := range []Shape{Shape4, Shape6, Shape9, Shape16} {
+		if shape.Size == size {
+			return shape, nil
+		}
+	}
+	return Shape{}, fmt.Errorf("unsupported board size %d, use 4, 6, 9 or 16", size)
+}
+
+// Cells of the shape
+func (s Shape) Cells() int {
+	return s.Size * s.Size
+}
+
+// Index of the box holding a cell
+func (s Shape) box(row, col int) int {
+	return row/s.BoxRows*(s.Size/s.BoxCols) + col/s.BoxCols
+}
+
+// Grid is a board of any shape, its cells row by row with 0 for empty ones
+type Grid struct {
+	Shape Shape
+	Cells []int
+}
+
+// NewGrid returns an empty grid of the shape
+func NewGrid(shape Shape) Grid {
+	return Grid{Shape: shape, Cells: make([]int, shape.Cells())}
+}
+
+func (g Grid) At(row, col int) int {
+	return g.Cells[row*g.Shape.Size+col]
+}
+
+func (g Grid) Set(row, col, value int) {
+	g.Cells[row*g.Shape.Size+col] = value
+}
+
+// Copy returns a grid with the same digits that doesn't share its cells
+func (g Grid) Copy() Grid {
+	return Grid{Shape: g.Shape, Cells: append([]int(nil), g.Cells...)}
+}
+
+// GridFromBoard converts a 9x9 board to a grid
+func GridFromBoard(board Board) Grid {
+	g := NewGrid(Shape9)
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			g.Set(i, j, board[i][j])
+		}
+	}
+	return g
+}
+
+// Board converts a 9x9 grid back to a board
+func (g Grid) Board() (Board, error) {
+	var board Board
+	if g.Shape != Shape9 {
+		return board, errors.New("only 9x9 grids convert to a board")
+	}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			board[i][j] = g.At(i, j)
+		}
+	}
+	return board, nil
+}
+
+// Digits past 9 are written as letters, so a 16x16 cell is still one character
+const gridDigits = "0123456789ABCDEFG"
+
+// StringToGrid parses a grid of the shape written like StringToBoard does a board: a character
+// per cell, '0' or '.' for empty cells and 'A'-'G' for 10-16
+func StringToGrid(s string, shape Shape) (Grid, error) {
+	if len(s) != shape.Cells() {
+		return Grid{}, fmt.Errorf("a %dx%d grid must be exactly %d characters", shape.Size, shape.Size, shape.Cells())
+	}
+	g := NewGrid(shape)
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch == '.' {
+			continue
+		}
+		value := strings.IndexByte(gridDigits, ch)
+		if ch >= 'a' && ch <= 'g' {
+			value = strings.IndexByte(gridDigits, ch-'a'+'A')
+		}
+		if value < 0 || value > shape.Size {
+			return Grid{}, fmt.Errorf("invalid digit %q for a %dx%d grid", ch, shape.Size, shape.Size)
+		}
+		g.Cells[i] = value
+	}
+	return g, nil
+}
+
+// GridToString writes a grid the way StringToGrid reads it
+func GridToString(g Grid) string {
+	buf := make([]byte, len(g.Cells))
+	for i, value := range g.Cells {
+		buf[i] = gridDigits[value]
+	}
+	return string(buf)
+}
+
+// The rows, columns and boxes of a shape, as lists of cells for newHouseSearch
+func (s Shape) houses() [][]int {
+	n := s.Size
+	houses := make([][]int, 3*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			pos := i*n + j
+			houses[i] = append(houses[i], pos)
+			houses[n+j] = append(houses[n+j], pos)
+			houses[2*n+s.box(i, j)] = append(houses[2*n+s.box(i, j)], pos)
+		}
+	}
+	return houses
+}
+
+// Set up the search of a grid, reporting false when its digits repeat in a row, column or box
+func newGridSearch(g Grid) (*houseSearch, bool) {
+	return newHouseSearch(g.Cells, g.Shape.Size, g.Shape.houses())
+}
+
+// IsValidGridMove is IsValidMove for a grid of any shape: the value must be missing from the
+// other cells of the row, column and box
+func (s *Service) IsValidGridMove(g Grid, row, col, value int) bool {
+	shape := g.Shape
+	boxRow, boxCol := row/shape.BoxRows*shape.BoxRows, col/shape.BoxCols*shape.BoxCols
+	for k := 0; k < shape.Size; k++ {
+		r, c := boxRow+k/shape.BoxCols, boxCol+k%shape.BoxCols
+		if (k != col && g.At(row, k) == value) || (k != row && g.At(k, col) == value) || ((r != row || c != col) && g.At(r, c) == value) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetGridCandidates is GetCandidates for a grid of any shape
+func (s *Service) GetGridCandidates(g Grid, row, col int) []int {
+	candidates := []int{}
+	h, ok := newGridSearch(g)
+	if !ok || g.At(row, col) != 0 {
+		return candidates
+	}
+	for c := h.candidates(row*g.Shape.Size + col); c != 0; c &= c - 1 {
+		candidates = append(candidates, bits.TrailingZeros32(c))
+	}
+	return candidates
+}
+
+// SolveGrid solves a grid of any shape, within the service's solve budget
+func (s *Service) SolveGrid(ctx context.Context, g Grid) (Grid, error) {
+	h, ok := newGridSearch(g)
+	if !ok {
+		return g, ErrUnsolvable
+	}
+	var solution *Grid
+	sr := newSearch(ctx, s.budget)
+	h.search(g.Copy().Cells, sr, func(cells []int) bool {
+		solution = &Grid{Shape: g.Shape, Cells: append([]int(nil), cells...)}
+		return true
+	})
+	if solution != nil {
+		return *solution, nil
+	}
+	if sr.err != nil {
+		return g, sr.err
+	}
+	return g, ErrUnsolvable
+}
+
+// CountGridSolutions returns the number of solutions of a grid of any shape, stopping once limit
+// is reached, within the service's solve budget
+func (s *Service) CountGridSolutions(ctx context.Context, g Grid, limit int) (int, error) {
+	h, ok := newGridSearch(g)
+	if !ok {
+		return 0, nil
+	}
+	count := 0
+	sr := newSearch(ctx, s.budget)
+	h.search(g.Copy().Cells, sr, func([]int) bool {
+		count++
+		return count >= limit
+	})
+	return count, sr.err
+}
+
+// Digits given in generated puzzles of each shape other than 9x9, by difficulty
+var gridGivens = map[int]map[models.Difficulty]int{
+	4:  {models.Easy: 8, models.Medium: 7, models.Hard: 6, models.Expert: 5},
+	6:  {models.Easy: 20, models.Medium: 17, models.Hard: 14, models.Expert: 12},
+	16: {models.Easy: 160, models.Medium: 145, models.Hard: 130, models.Expert: 120},
+}
+
+// Placements a uniqueness check of the grid generator may try, and a 16x16 fill
+const gridCheckNodes = 200_000
+
+// GenerateGrid generates a puzzle of any shape and its solution, until the context ends. 9x9
+// puzzles come from GeneratePuzzle; other shapes are carved to the difficulty's number of givens
+// without checking techniques. Only opts.Seed and opts.Symmetry are used.
+func (s *Service) GenerateGrid(ctx context.Context, shape Shape, difficulty models.Difficulty, opts GenerateOptions) (Grid, Grid, error) {
+	if shape == Shape9 {
+		puzzle, solution, err := s.GeneratePuzzle(difficulty, opts)
+		return GridFromBoard(puzzle), GridFromBoard(solution), err
+	}
+	if _, err := ShapeOf(shape.Size); err != nil {
+		return Grid{}, Grid{}, err
+	}
+	target, ok := gridGivens[shape.Size][difficulty]
+	if !ok {
+		return Grid{}, Grid{}, fmt.Errorf("invalid difficulty %q", difficulty)
+	}
+
+	var rng *rand.Rand
+	if opts.Seed != nil {
+		rng = rand.New(rand.NewSource(*opts.Seed))
+	} else {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	for attempt := 0; attempt < maxGenerationAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return Grid{}, Grid{}, err
+		}
+		solved := NewGrid(shape)
+		h, _ := newGridSearch(solved)
+		if !h.solveRandom(solved.Cells, rng, newSearch(ctx, SolveBudget{MaxNodes: gridCheckNodes})) {
+			continue
+		}
+		if puzzle, givens := carveGrid(ctx, solved, target, opts.Symmetry, rng); givens <= target {
+			return puzzle, solved, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return Grid{}, Grid{}, err
+	}
+	return Grid{}, Grid{}, fmt.Errorf("failed to generate a %dx%d puzzle matching the difficulty", shape.Size, shape.Size)
+}
+
+// Remove digits down to target while the solution stays unique, like carveVariant, returning the
+// puzzle and how many digits are still given
+func carveGrid(ctx context.Context, solved Grid, target int, symmetry models.Symmetry, rng *rand.Rand) (Grid, int) {
+	shape := solved.Shape
+	puzzle := solved.Copy()
+	givens := shape.Cells()
+	for _, pos := range rng.Perm(shape.Cells()) {
+		if givens <= target {
+			break
+		}
+		if puzzle.Cells[pos] == 0 {
+			continue
+		}
+		backup := puzzle.Copy()
+		removed := 0
+		for _, p := range gridSymmetricCells(shape, pos, symmetry) {
+			if puzzle.Cells[p] != 0 {
+				puzzle.Cells[p] = 0
+				removed++
+			}
+		}
+
+		h, _ := newGridSearch(puzzle)
+		count := 0
+		sr := newSearch(ctx, SolveBudget{MaxNodes: gridCheckNodes})
+		h.search(puzzle.Copy().Cells, sr, func([]int) bool {
+			count++
+			return count >= 2
+		})
+		if count != 1 || sr.err != nil {
+			puzzle = backup
+		} else {
+			givens -= removed
+		}
+	}
+	return puzzle, givens
+}
+
+// The cells cleared together with pos under a clue symmetry, like symmetricCells
+func gridSymmetricCells(shape Shape, pos int, symmetry models.Symmetry) []int {
+	n := shape.Size
+	last := shape.Cells() - 1
+	row, col := pos/n, pos%n
+	var candidates []int
+	switch symmetry {
+	case models.RotationalSymmetry:
+		candidates = []int{pos, last - pos}
+	case models.MirrorSymmetry:
+		candidates = []int{pos, last - pos, row*n + n - 1 - col, (n-1-row)*n + col}
+	default:
+		return []int{pos}
+	}
+
+	var cells []int
+	for _, p := range candidates {
+		if !containsInt(cells, p) {
+			cells = append(cells, p)
+		}
+	}
+	return cells
+}
@@ -0,0 +1,88 @@
+package sudoku
+
+import "strings"
+
+// Highlight tells clients how to draw a move or deduction, so every client renders
+// technique explanations the same way
+type Highlight struct {
+	Target  *Cell         `json:"target,omitempty"` // Cell receiving the value
+	Outline []Cell        `json:"outline"`          // Cells forming the pattern
+	Strike  []Elimination `json:"strike"`           // Candidates to strike out
+	Shade   []UnitRef     `json:"shade"`            // Houses the technique reasons about
+}
+
+// HighlightMove builds the highlight of a move: its cell, the pattern cells and eliminations
+// of the deductions leading to it, and the houses they reason about
+func HighlightMove(move *Move) *Highlight {
+	h := &Highlight{Target: &Cell{Row: move.Row, Col: move.Col}, Outline: []Cell{}, Strike: []Elimination{}, Shade: []UnitRef{}}
+	for i := range move.Deductions {
+		h.add(&move.Deductions[i])
+	}
+
+	// A hidden single is found in one house, a naked single by looking at all three
+	kind := ""
+	if parts := strings.SplitN(move.Reason, " in ", 2); len(parts) == 2 {
+		kind = parts[1]
+	}
+	for _, u := range allUnits() {
+		if contains(u.cells, *h.Target) && (u.kind == kind || move.Reason == "Naked Single") {
+			h.shade(unitRef(u))
+		}
+	}
+	return h
+}
+
+// HighlightDeduction builds the highlight of a single deduction
+func HighlightDeduction(d *Deduction) *Highlight {
+	h := &Highlight{Outline: []Cell{}, Strike: []Elimination{}, Shade: []UnitRef{}}
+	h.add(d)
+	return h
+}
+
+func (h *Highlight) add(d *Deduction) {
+	for _, cell := range d.Cells {
+		if !contains(h.Outline, cell) {
+			h.Outline = append(h.Outline, cell)
+		}
+	}
+	h.Strike = append(h.Strike, d.Eliminations...)
+
+	// Fish reason about their base lines, other patterns about the houses holding all their cells
+	if d.Fish != nil {
+		kind := "Row"
+		if d.Fish.BaseKind == "columns" {
+			kind = "Column"
+		}
+		for _, line := range d.Fish.BaseLines {
+			h.shade(UnitRef{Kind: kind, Index: line})
+		}
+		return
+	}
+	for _, u := range allUnits() {
+		if allIn(d.Cells, u) {
+			h.shade(unitRef(u))
+		}
+	}
+}
+
+func (h *Highlight) shade(ref UnitRef) {
+	for _, shaded := range h.Shade {
+		if shaded == ref {
+			return
+		}
+	}
+	h.Shade = append(h.Shade, ref)
+}
+
+// unitRef names a unit by its kind and 0-based index
+func unitRef(u unit) UnitRef {
+	cell := u.cells[0]
+	ref := UnitRef{Kind: u.kind, Index: cell.Row}
+	switch u.kind {
+	case "Column":
+		ref.Index = cell.Col
+	case "Box":
+		ref.Index = (cell.Row/3)*3 + cell.Col/3
+	}
+	return ref
+}
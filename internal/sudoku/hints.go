@@ -0,0 +1,59 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoLogicalMove is returned when none of the human techniques applies to the board
+var ErrNoLogicalMove = errors.New("no logical move available")
+
+// Technique recommended to learners when the logical solver gets stuck.
+// Anything beyond the registered techniques needs chain-based reasoning.
+const StudyTechnique = "Forcing Chains"
+
+// Get a hint for a specific cell that can be reached with human techniques only.
+// Logical moves are played on a copy of the board until the cell is filled.
+func (s *Service) LogicalHintForCell(ctx context.Context, board Board, row, col int) (*Move, error) {
+	if board[row][col] != 0 {
+		return nil, errors.New("cell is already filled")
+	}
+	if _, err := s.SolvePuzzle(ctx, board); err != nil {
+		return nil, err
+	}
+
+	for {
+		move, err := s.LogicalStep(board)
+		if err != nil {
+			return nil, err
+		}
+		if move.Row == row && move.Col == col {
+			return move, nil
+		}
+		board[move.Row][move.Col] = move.Value
+	}
+}
+
+// Find the next logical move for hint highlighting, never falling back to backtracking
+func (s *Service) FindLogicalCell(ctx context.Context, board Board) (*Move, error) {
+	if _, err := s.SolvePuzzle(ctx, board); err != nil {
+		return nil, err
+	}
+	return s.LogicalStep(board)
+}
+
+// FindElimination returns the first candidate technique that applies to the board. It tells the
+// learner which candidates to remove without revealing the value of any cell.
+func (s *Service) FindElimination(ctx context.Context, board Board) (*Deduction, error) {
+	if _, err := s.SolvePuzzle(ctx, board); err != nil {
+		return nil, err
+	}
+
+	c := s.ComputeCandidates(board)
+	for _, t := range techniques {
+		if d := t.find(s, &c); d != nil {
+			return d, nil
+		}
+	}
+	return nil, ErrNoLogicalMove
+}
@@ -0,0 +1,123 @@
+package sudoku
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+// houseSearch is the bitmask search for boards whose only rule is that every house (a row,
+// column or box) holds each digit once. Grids of any shape and Samurai boards share it, their
+// cells flattened row by row. Cells in no house, like the gaps of a Samurai board, are left
+// alone.
+type houseSearch struct {
+	digits  uint32   // Bits of the digits a cell can take
+	houseOf [][]int  // Houses holding each cell
+	used    []uint32 // Digits placed in each house. Bit v is set when digit v is placed.
+}
+
+// Set up the search of cells holding digits 1 to size, houses listing the cells of every house.
+// Reports false when digits repeat in a house.
+func newHouseSearch(cells []int, size int, houses [][]int) (*houseSearch, bool) {
+	h := &houseSearch{
+		digits:  uint32(1)<<(size+1) - 2,
+		houseOf: make([][]int, len(cells)),
+		used:    make([]uint32, len(houses)),
+	}
+	for n, house := range houses {
+		for _, pos := range house {
+			h.houseOf[pos] = append(h.houseOf[pos], n)
+		}
+	}
+	for pos, value := range cells {
+		if value == 0 {
+			continue
+		}
+		if h.candidates(pos)&(1<<value) == 0 {
+			return nil, false
+		}
+		h.toggle(pos, value)
+	}
+	return h, true
+}
+
+func (h *houseSearch) candidates(pos int) uint32 {
+	candidates := h.digits
+	for _, n := range h.houseOf[pos] {
+		candidates &^= h.used[n]
+	}
+	return candidates
+}
+
+func (h *houseSearch) toggle(pos, value int) {
+	for _, n := range h.houseOf[pos] {
+		h.used[n] ^= 1 << value
+	}
+}
+
+// The empty cell with the fewest candidates, ok false when every cell is filled
+func (h *houseSearch) nextCell(cells []int) (pos int, candidates uint32, ok bool) {
+	best := -1
+	for p, value := range cells {
+		if value != 0 || len(h.houseOf[p]) == 0 {
+			continue
+		}
+		c := h.candidates(p)
+		if n := bits.OnesCount32(c); best < 0 || n < best {
+			pos, candidates, best = p, c, n
+			if n <= 1 {
+				return pos, candidates, true
+			}
+		}
+	}
+	return pos, candidates, best >= 0
+}
+
+// Backtrack through the solutions, handing each to found until it returns true. Reports whether
+// the search was stopped, by found or the budget.
+func (h *houseSearch) search(cells []int, sr *search, found func([]int) bool) bool {
+	pos, candidates, ok := h.nextCell(cells)
+	if !ok {
+		return found(cells)
+	}
+	for ; candidates != 0; candidates &= candidates - 1 {
+		if sr.visit() {
+			return true
+		}
+		value := bits.TrailingZeros32(candidates)
+		cells[pos] = value
+		h.toggle(pos, value)
+		stop := h.search(cells, sr, found)
+		h.toggle(pos, value)
+		cells[pos] = 0
+		if stop {
+			return true
+		}
+	}
+	return false
+}
+
+// Fill the cells with a random solution
+func (h *houseSearch) solveRandom(cells []int, rng *rand.Rand, sr *search) bool {
+	pos, candidates, ok := h.nextCell(cells)
+	if !ok {
+		return true
+	}
+	var values []int
+	for ; candidates != 0; candidates &= candidates - 1 {
+		values = append(values, bits.TrailingZeros32(candidates))
+	}
+	rng.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	for _, value := range values {
+		if sr.visit() {
+			return false
+		}
+		cells[pos] = value
+		h.toggle(pos, value)
+		if h.solveRandom(cells, rng, sr) {
+			return true
+		}
+		h.toggle(pos, value)
+		cells[pos] = 0
+	}
+	return false
+}
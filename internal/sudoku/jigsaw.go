@@ -0,0 +1,178 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"sudoku/internal/models"
+)
+
+// Regions maps every cell of a Jigsaw puzzle to its region, 0-8. Each region is nine connected
+// cells and takes the place of a 3x3 box.
+type Regions [9][9]int
+
+// Swaps of cells between neighbouring regions that shape a random layout out of the boxes
+const regionSwaps = 60
+
+// Placements a random solve of a region layout may try before the layout is dropped
+const jigsawSolveNodes = 20_000
+
+// FormatRegions writes a region layout as stored with Jigsaw puzzles: 81 digits, row by row,
+// each the region (1-9) of its cell
+func FormatRegions(regions Regions) string {
+	var sb strings.Builder
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			sb.WriteByte(byte('1' + regions[i][j]))
+		}
+	}
+	return sb.String()
+}
+
+// ParseRegions reads a region layout written by FormatRegions, checking it with ValidateRegions
+func ParseRegions(s string) (Regions, error) {
+	var regions Regions
+	if len(s) != 81 {
+		return regions, errors.New("region layout must have 81 cells")
+	}
+	for i, ch := range s {
+		if ch < '1' || ch > '9' {
+			return regions, errors.New("region layout may only contain the digits 1-9")
+		}
+		regions[i/9][i%9] = int(ch - '1')
+	}
+	return regions, ValidateRegions(regions)
+}
+
+// ValidateRegions checks that every region has nine cells joined by shared edges
+func ValidateRegions(regions Regions) error {
+	for r, cells := range regionCells(regions) {
+		if len(cells) != 9 {
+			return fmt.Errorf("region %d has %d cells instead of 9", r+1, len(cells))
+		}
+		if !connected(cells) {
+			return fmt.Errorf("region %d is not one connected group of cells", r+1)
+		}
+	}
+	return nil
+}
+
+// The cells of every region
+func regionCells(regions Regions) [9][]Cell {
+	var cells [9][]Cell
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			r := regions[i][j]
+			if r >= 0 && r < 9 {
+				cells[r] = append(cells[r], Cell{Row: i, Col: j})
+			}
+		}
+	}
+	return cells
+}
+
+// Shape a random region layout: starting from the boxes, trade cells between neighbouring
+// regions, keeping every region nine connected cells
+func randomRegions(rng *rand.Rand) Regions {
+	var regions Regions
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			regions[i][j] = boxIndex(i, j)
+		}
+	}
+
+	// Cells of region into with a neighbour in region from
+	border := func(into, from int) []Cell {
+		var cells []Cell
+		for _, cell := range regionCells(regions)[into] {
+			for _, n := range neighbours(cell) {
+				if regions[n.Row][n.Col] == from {
+					cells = append(cells, cell)
+					break
+				}
+			}
+		}
+		return cells
+	}
+
+	for swaps, tries := 0, 0; swaps < regionSwaps && tries < regionSwaps*20; tries++ {
+		a := Cell{Row: rng.Intn(9), Col: rng.Intn(9)}
+		near := neighbours(a)
+		b := near[rng.Intn(len(near))]
+		ra, rb := regions[a.Row][a.Col], regions[b.Row][b.Col]
+		if ra == rb {
+			continue
+		}
+		// a moves to b's region and a cell of b's region bordering a's moves back
+		candidates := border(rb, ra)
+		c := candidates[rng.Intn(len(candidates))]
+		regions[a.Row][a.Col], regions[c.Row][c.Col] = rb, ra
+		cells := regionCells(regions)
+		if !connected(cells[ra]) || !connected(cells[rb]) {
+			regions[a.Row][a.Col], regions[c.Row][c.Col] = ra, rb
+			continue
+		}
+		swaps++
+	}
+	return regions
+}
+
+// The cells sharing an edge with a cell
+func neighbours(cell Cell) []Cell {
+	var cells []Cell
+	for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		r, c := cell.Row+d[0], cell.Col+d[1]
+		if r >= 0 && r < 9 && c >= 0 && c < 9 {
+			cells = append(cells, Cell{Row: r, Col: c})
+		}
+	}
+	return cells
+}
+
+// GenerateJigsaw generates a Jigsaw puzzle, its solution and its regions. The difficulty's
+// generation profile sets the clue count and symmetry, which applies to the given digits; its
+// techniques are not checked, as the human techniques only know about boxes.
+func (s *Service) GenerateJigsaw(difficulty models.Difficulty, opts GenerateOptions) (Board, Board, Regions, error) {
+	profile, err := s.GetGenerationProfile(difficulty)
+	if err != nil {
+		return Board{}, Board{}, Regions{}, err
+	}
+	if opts.Symmetry != "" {
+		profile.Symmetry = opts.Symmetry
+		if err := ValidateGenerationProfile(profile); err != nil {
+			return Board{}, Board{}, Regions{}, err
+		}
+	}
+
+	var layouts sync.Map // Regions of each attempt, by its solution
+	attempt := func(rng *rand.Rand) (Board, Board, bool) {
+		regions := randomRegions(rng)
+		rules := opts.chessRules(Rules{Regions: &regions})
+		var solved Board
+		v, _ := newVariantSearch(&solved, rules)
+		if !v.solveRandom(&solved, rng, newSearch(context.Background(), SolveBudget{MaxNodes: jigsawSolveNodes})) {
+			return Board{}, Board{}, false
+		}
+		clues := profile.MinClues + rng.Intn(profile.MaxClues-profile.MinClues+1)
+		puzzle, givens := carveVariant(solved, rules, clues, profile.Symmetry, rng)
+		layouts.Store(solved, regions)
+		return puzzle, solved, givens <= profile.MaxClues
+	}
+
+	var puzzle, solved Board
+	var ok bool
+	if opts.Seed != nil {
+		puzzle, solved, ok = generateSeeded(*opts.Seed, maxGenerationAttempts, attempt)
+	} else {
+		puzzle, solved, ok = generateConcurrently(maxGenerationAttempts, attempt)
+	}
+	if !ok {
+		return Board{}, Board{}, Regions{}, errors.New("failed to generate a Jigsaw puzzle matching the difficulty profile")
+	}
+	regions, _ := layouts.Load(solved)
+	return puzzle, solved, regions.(Regions), nil
+}
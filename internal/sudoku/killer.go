@@ -0,0 +1,245 @@
+package sudoku
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sudoku/internal/models"
+)
+
+// Cage is a group of cells of a Killer puzzle whose digits are all different and add up to Sum
+type Cage struct {
+	Sum   int    `json:"sum"`
+	Cells []Cell `json:"cells"`
+}
+
+// Digits of every set of distinct digits, by set size and sum. Used to find the digits
+// that can still complete a cage.
+var digitSets [10][46][]uint16
+
+func init() {
+	for set := uint16(0); set < 1<<9; set++ {
+		mask := set << 1 // Bit d for digit d, as in candidate masks
+		sum := 0
+		for _, d := range digits(mask) {
+			sum += d
+		}
+		size := bits.OnesCount16(mask)
+		digitSets[size][sum] = append(digitSets[size][sum], mask)
+	}
+}
+
+// FormatCages writes cages as the cage layout stored with Killer puzzles: cages separated by
+// ';', each its sum and the grid positions (0-80, row by row) of its cells, "12:0,1;7:9,18"
+func FormatCages(cages []Cage) string {
+	parts := make([]string, len(cages))
+	for i, cage := range cages {
+		positions := make([]string, len(cage.Cells))
+		for j, cell := range cage.Cells {
+			positions[j] = strconv.Itoa(cell.Row*9 + cell.Col)
+		}
+		parts[i] = strconv.Itoa(cage.Sum) + ":" + strings.Join(positions, ",")
+	}
+	return strings.Join(parts, ";")
+}
+
+// ParseCages reads a cage layout written by FormatCages, checking the cages with ValidateCages
+func ParseCages(s string) ([]Cage, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("cage layout is empty")
+	}
+	var cages []Cage
+	for i, part := range strings.Split(s, ";") {
+		sum, list, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("cage %d must be written as sum:cell,cell", i+1)
+		}
+		cage := Cage{}
+		var err error
+		if cage.Sum, err = strconv.Atoi(sum); err != nil {
+			return nil, fmt.Errorf("cage %d has an invalid sum %q", i+1, sum)
+		}
+		for _, p := range strings.Split(list, ",") {
+			pos, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || pos < 0 || pos > 80 {
+				return nil, fmt.Errorf("cage %d has an invalid cell %q, use positions 0-80", i+1, p)
+			}
+			cage.Cells = append(cage.Cells, Cell{Row: pos / 9, Col: pos % 9})
+		}
+		cages = append(cages, cage)
+	}
+	return cages, ValidateCages(cages)
+}
+
+// ValidateCages checks that no cell is in two cages, every cage is one connected group of at most
+// nine cells, and its sum can be made of that many different digits
+func ValidateCages(cages []Cage) error {
+	var seen [9][9]bool
+	for i, cage := range cages {
+		if len(cage.Cells) == 0 || len(cage.Cells) > 9 {
+			return fmt.Errorf("cage %d must have 1 to 9 cells", i+1)
+		}
+		if cage.Sum < 1 || cage.Sum > 45 || len(digitSets[len(cage.Cells)][cage.Sum]) == 0 {
+			return fmt.Errorf("cage %d can't add up to %d with %d different digits", i+1, cage.Sum, len(cage.Cells))
+		}
+		for _, cell := range cage.Cells {
+			if cell.Row < 0 || cell.Row > 8 || cell.Col < 0 || cell.Col > 8 {
+				return fmt.Errorf("cage %d has a cell outside the grid", i+1)
+			}
+			if seen[cell.Row][cell.Col] {
+				return fmt.Errorf("%s is in more than one cage", cellName(cell))
+			}
+			seen[cell.Row][cell.Col] = true
+		}
+		if !connected(cage.Cells) {
+			return fmt.Errorf("cage %d is not one connected group of cells", i+1)
+		}
+	}
+	return nil
+}
+
+// Whether the cells form one group joined by shared edges
+func connected(cells []Cell) bool {
+	reached := []Cell{cells[0]}
+	for i := 0; i < len(reached); i++ {
+		for _, cell := range cells {
+			if !contains(reached, cell) && adjacent(reached[i], cell) {
+				reached = append(reached, cell)
+			}
+		}
+	}
+	return len(reached) == len(cells)
+}
+
+func adjacent(a, b Cell) bool {
+	dr, dc := a.Row-b.Row, a.Col-b.Col
+	return dr*dr+dc*dc == 1
+}
+
+// killerProfile shapes the Killer puzzles of a difficulty: bigger cages hide more and fewer
+// digits are given
+type killerProfile struct {
+	MaxCage int // Most cells in a cage
+	Givens  int // Digits left given once the puzzle is carved
+}
+
+var killerProfiles = map[models.Difficulty]killerProfile{
+	models.Easy:   {MaxCage: 3, Givens: 24},
+	models.Medium: {MaxCage: 3, Givens: 12},
+	models.Hard:   {MaxCage: 4, Givens: 4},
+	models.Expert: {MaxCage: 4, Givens: 0},
+}
+
+// GenerateKiller generates a Killer puzzle, its solution and its cages. Only opts.Seed,
+// opts.Symmetry, which applies to the given digits, and the chess constraints are used.
+func (s *Service) GenerateKiller(difficulty models.Difficulty, opts GenerateOptions) (Board, Board, []Cage, error) {
+	profile, ok := killerProfiles[difficulty]
+	if !ok {
+		return Board{}, Board{}, nil, fmt.Errorf("no Killer profile for difficulty %q", difficulty)
+	}
+
+	var layouts sync.Map // Cages of each attempt, by its solution
+	attempt := func(rng *rand.Rand) (Board, Board, bool) {
+		solved, ok := s.randomSolution(opts.chessRules(Rules{}), rng)
+		if !ok {
+			return Board{}, Board{}, false
+		}
+		cages := buildCages(solved, profile.MaxCage, rng)
+		puzzle, givens := carveVariant(solved, opts.chessRules(Rules{Cages: cages}), profile.Givens, opts.Symmetry, rng)
+		layouts.Store(solved, cages)
+		return puzzle, solved, givens <= profile.Givens
+	}
+
+	var puzzle, solved Board
+	if opts.Seed != nil {
+		puzzle, solved, ok = generateSeeded(*opts.Seed, maxGenerationAttempts, attempt)
+	} else {
+		puzzle, solved, ok = generateConcurrently(maxGenerationAttempts, attempt)
+	}
+	if !ok {
+		return Board{}, Board{}, nil, errors.New("failed to generate a Killer puzzle matching the difficulty profile")
+	}
+	cages, _ := layouts.Load(solved)
+	return puzzle, solved, cages.([]Cage), nil
+}
+
+// Split a solved board into connected cages of up to maxSize cells without repeated digits.
+// Cells left on their own are merged into a neighbouring cage where the digit fits.
+func buildCages(solved Board, maxSize int, rng *rand.Rand) []Cage {
+	var cageOf [9][9]int
+	for i := range cageOf {
+		for j := range cageOf[i] {
+			cageOf[i][j] = -1
+		}
+	}
+	var groups [][]Cell
+	holds := func(group []Cell, value int) bool {
+		for _, cell := range group {
+			if solved[cell.Row][cell.Col] == value {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, pos := range rng.Perm(81) {
+		start := Cell{Row: pos / 9, Col: pos % 9}
+		if cageOf[start.Row][start.Col] >= 0 {
+			continue
+		}
+		size := 2 + rng.Intn(maxSize-1)
+		group := []Cell{start}
+		cageOf[start.Row][start.Col] = len(groups)
+		for len(group) < size {
+			var frontier []Cell
+			for _, cell := range group {
+				for _, n := range neighbours(cell) {
+					if cageOf[n.Row][n.Col] < 0 && !contains(frontier, n) && !holds(group, solved[n.Row][n.Col]) {
+						frontier = append(frontier, n)
+					}
+				}
+			}
+			if len(frontier) == 0 {
+				break
+			}
+			next := frontier[rng.Intn(len(frontier))]
+			cageOf[next.Row][next.Col] = len(groups)
+			group = append(group, next)
+		}
+		groups = append(groups, group)
+	}
+
+	for g, group := range groups {
+		if len(group) != 1 {
+			continue
+		}
+		cell := group[0]
+		for _, n := range neighbours(cell) {
+			target := cageOf[n.Row][n.Col]
+			if target != g && len(groups[target]) > 0 && len(groups[target]) < maxSize && !holds(groups[target], solved[cell.Row][cell.Col]) {
+				groups[target] = append(groups[target], cell)
+				cageOf[cell.Row][cell.Col] = target
+				groups[g] = nil
+				break
+			}
+		}
+	}
+
+	var cages []Cage
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		cage := Cage{Cells: group}
+		for _, cell := range group {
+			cage.Sum += solved[cell.Row][cell.Col]
+		}
+		cages = append(cages, cage)
+	}
+	return cages
+}
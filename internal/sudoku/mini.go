@@ -0,0 +1,39 @@
+package sudoku
+
+import (
+	"math/rand"
+)
+
+// MiniBoard fills in solution cells of a puzzle at random until only blanks empty cells
+// remain. Adding clues keeps the solution unique, so the result is a quick timed exercise.
+func MiniBoard(puzzle, solution Board, blanks int) Board {
+	var empty []int
+	for pos := 0; pos < 81; pos++ {
+		if puzzle[pos/9][pos%9] == 0 {
+			empty = append(empty, pos)
+		}
+	}
+	if blanks >= len(empty) {
+		return puzzle
+	}
+
+	rand.Shuffle(len(empty), func(i, j int) { empty[i], empty[j] = empty[j], empty[i] })
+	for _, pos := range empty[blanks:] {
+		puzzle[pos/9][pos%9] = solution[pos/9][pos%9]
+	}
+	return puzzle
+}
+
+// NextTechnique returns the technique taught after name, or StudyTechnique past the last one
+func NextTechnique(name string) string {
+	names := TechniqueNames()
+	for i, n := range names {
+		if n == name && i+1 < len(names) {
+			return names[i+1]
+		}
+	}
+	if name == "" {
+		return names[0]
+	}
+	return StudyTechnique
+}
@@ -0,0 +1,67 @@
+package sudoku
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+type generated struct {
+	puzzle Board
+	solved Board
+}
+
+// An attempt at generating a puzzle, drawing all of its randomness from rng
+type generateAttempt func(rng *rand.Rand) (Board, Board, bool)
+
+// generateConcurrently spreads up to attempts calls of attempt over GOMAXPROCS workers
+// and returns the first puzzle produced. Workers still busy with a losing attempt
+// finish it in the background and then stop.
+func generateConcurrently(attempts int, attempt generateAttempt) (Board, Board, bool) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > attempts {
+		workers = attempts
+	}
+
+	var started atomic.Int64
+	var found atomic.Bool
+	results := make(chan generated, 1)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		rng := rand.New(rand.NewSource(rand.Int63()))
+		go func() {
+			defer wg.Done()
+			for !found.Load() && started.Add(1) <= int64(attempts) {
+				puzzle, solved, ok := attempt(rng)
+				if ok && found.CompareAndSwap(false, true) {
+					results <- generated{puzzle: puzzle, solved: solved}
+					return
+				}
+			}
+		}()
+	}
+
+	// Closing after every worker is done tells the caller all attempts failed
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	result, ok := <-results
+	return result.puzzle, result.solved, ok
+}
+
+// generateSeeded makes up to attempts calls of attempt one after another, all drawing from
+// a generator seeded with seed, so the same seed always produces the same puzzle
+func generateSeeded(seed int64, attempts int, attempt generateAttempt) (Board, Board, bool) {
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < attempts; i++ {
+		if puzzle, solved, ok := attempt(rng); ok {
+			return puzzle, solved, true
+		}
+	}
+	return Board{}, Board{}, false
+}
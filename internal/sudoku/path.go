@@ -0,0 +1,71 @@
+package sudoku
+
+import "context"
+
+// Path is the complete, ordered list of moves that solves a board
+type Path struct {
+	Moves      []Move   `json:"moves"`
+	Techniques []string `json:"techniques"` // Techniques used, in order of first use
+	Logical    bool     `json:"logical"`    // Solved by human techniques only, without an "Advanced Step"
+}
+
+// SolvePath solves the board step by step, recording every move with the deductions behind it.
+// When the human techniques get stuck the next empty cell is filled from the solution as an
+// "Advanced Step", just like SolveStep.
+func (s *Service) SolvePath(ctx context.Context, board Board) (*Path, error) {
+	solution, err := s.SolvePuzzle(ctx, board)
+	if err != nil {
+		return nil, err
+	}
+
+	path := &Path{Moves: []Move{}, Techniques: []string{}, Logical: true}
+	used := make(map[string]bool)
+	for !isFull(board) {
+		move, err := s.LogicalStep(board)
+		if err != nil {
+			move = firstEmptyMove(board, solution)
+			path.Logical = false
+		}
+		for _, name := range moveTechniques(move) {
+			if !used[name] {
+				used[name] = true
+				path.Techniques = append(path.Techniques, name)
+			}
+		}
+		path.Moves = append(path.Moves, *move)
+		board[move.Row][move.Col] = move.Value
+	}
+	return path, nil
+}
+
+// SolveSteps applies the next count moves SolveStep would make, stopping early when the board is
+// full. The board is solved once up front, so a batch costs no more than a single step.
+func (s *Service) SolveSteps(ctx context.Context, board Board, count int) ([]Move, error) {
+	solution, err := s.SolvePuzzle(ctx, board)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := []Move{}
+	for len(moves) < count && !isFull(board) {
+		move, err := s.LogicalStep(board)
+		if err != nil {
+			move = firstEmptyMove(board, solution)
+		}
+		moves = append(moves, *move)
+		board[move.Row][move.Col] = move.Value
+	}
+	return moves, nil
+}
+
+// Fill the first empty cell from the solution
+func firstEmptyMove(board, solution Board) *Move {
+	for r := 0; r < 9; r++ {
+		for c := 0; c < 9; c++ {
+			if board[r][c] == 0 {
+				return &Move{Row: r, Col: c, Value: solution[r][c], Reason: "Advanced Step"}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+)
+
+// Mask marks the cells that must hold a clue
+type Mask [9][9]bool
+
+// ErrPatternNotFound is returned when no uniquely solvable puzzle was found for a mask
+var ErrPatternNotFound = errors.New("no puzzle with a unique solution found for this pattern")
+
+// ParseMask converts an 81 character pattern into a Mask. 'x', 'X' and '1' mark clue cells,
+// '.' and '0' mark empty cells.
+func ParseMask(s string) (Mask, error) {
+	var mask Mask
+	if len(s) != 81 {
+		return mask, errors.New("pattern must be exactly 81 characters")
+	}
+	for i := 0; i < 81; i++ {
+		switch s[i] {
+		case 'x', 'X', '1':
+			mask[i/9][i%9] = true
+		case '.', '0':
+		default:
+			return mask, errors.New("pattern may only contain 'x', '1', '.' or '0'")
+		}
+	}
+	return mask, nil
+}
+
+// Clues returns the number of clue cells in the mask
+func (m Mask) Clues() int {
+	clues := 0
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if m[i][j] {
+				clues++
+			}
+		}
+	}
+	return clues
+}
+
+// GenerateFromMask looks for a puzzle whose clues sit exactly on the mask cells and whose
+// solution is unique, trying up to attempts random solved boards until the context ends.
+func (s *Service) GenerateFromMask(ctx context.Context, mask Mask, attempts int) (Board, Board, error) {
+	if mask.Clues() < 17 {
+		return Board{}, Board{}, errors.New("a unique puzzle needs at least 17 clues")
+	}
+
+	puzzle, solved, ok := generateConcurrently(attempts, func(rng *rand.Rand) (Board, Board, bool) {
+		if ctx.Err() != nil {
+			return Board{}, Board{}, false
+		}
+		var solved Board
+		if !s.solveRandom(&solved, rng) {
+			return Board{}, Board{}, false
+		}
+
+		var puzzle Board
+		for i := 0; i < 9; i++ {
+			for j := 0; j < 9; j++ {
+				if mask[i][j] {
+					puzzle[i][j] = solved[i][j]
+				}
+			}
+		}
+		count, err := s.CountSolutions(ctx, puzzle, 2)
+		return puzzle, solved, err == nil && count == 1
+	})
+	if err := ctx.Err(); err != nil && !ok {
+		return Board{}, Board{}, err
+	}
+	if !ok {
+		return Board{}, Board{}, ErrPatternNotFound
+	}
+	return puzzle, solved, nil
+}
@@ -0,0 +1,164 @@
+package sudoku
+
+import (
+	"errors"
+	"math/rand"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+)
+
+// Number of boards tried before giving up on a profile that cannot be met
+const maxGenerationAttempts = 20
+
+// Number of boards tried for a profile with a minimum technique, which few random boards need
+const maxSearchAttempts = 100
+
+// Default profiles used when no profile is stored in the database
+var defaultProfiles = map[models.Difficulty]models.GenerationProfile{
+	models.Easy:   {Difficulty: models.Easy, MinClues: 46, MaxClues: 46, Symmetry: models.NoSymmetry},
+	models.Medium: {Difficulty: models.Medium, MinClues: 36, MaxClues: 36, Symmetry: models.NoSymmetry},
+	models.Hard:   {Difficulty: models.Hard, MinClues: 27, MaxClues: 27, Symmetry: models.NoSymmetry},
+	models.Expert: {Difficulty: models.Expert, MinClues: 22, MaxClues: 24, Symmetry: models.NoSymmetry, MinTechnique: "X-Wing"},
+}
+
+// Get the generation profile for a difficulty, falling back to the built-in defaults
+func (s *Service) GetGenerationProfile(difficulty models.Difficulty) (models.GenerationProfile, error) {
+	profile, ok := defaultProfiles[difficulty]
+	if !ok {
+		return models.GenerationProfile{}, errors.New("invalid difficulty")
+	}
+	if s.db == nil {
+		return profile, nil
+	}
+
+	var stored models.GenerationProfile
+	err := s.db.Where("difficulty = ?", difficulty).First(&stored).Error
+	if err == nil {
+		return stored, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return profile, nil
+	}
+	return models.GenerationProfile{}, err
+}
+
+// List the generation profiles of all difficulties
+func (s *Service) ListGenerationProfiles() ([]models.GenerationProfile, error) {
+	var profiles []models.GenerationProfile
+	for _, difficulty := range []models.Difficulty{models.Easy, models.Medium, models.Hard, models.Expert} {
+		profile, err := s.GetGenerationProfile(difficulty)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// Validate a profile before it is stored
+func ValidateGenerationProfile(profile models.GenerationProfile) error {
+	if _, ok := defaultProfiles[profile.Difficulty]; !ok {
+		return errors.New("invalid difficulty")
+	}
+	if profile.MinClues < 17 || profile.MaxClues > 80 || profile.MinClues > profile.MaxClues {
+		return errors.New("clue range must satisfy 17 <= min_clues <= max_clues <= 80")
+	}
+	switch profile.Symmetry {
+	case models.NoSymmetry, models.RotationalSymmetry, models.MirrorSymmetry:
+	default:
+		return errors.New("invalid symmetry")
+	}
+	if profile.MaxTechnique != "" && techniqueRank(profile.MaxTechnique) < 0 {
+		return errors.New("unknown technique")
+	}
+	if profile.MinTechnique != "" && techniqueRank(profile.MinTechnique) < 0 {
+		return errors.New("unknown technique")
+	}
+	if profile.MinTechnique != "" && profile.MaxTechnique != "" && techniqueRank(profile.MinTechnique) > techniqueRank(profile.MaxTechnique) {
+		return errors.New("min_technique must not be harder than max_technique")
+	}
+	return nil
+}
+
+// Remove clues from a solved board until the profile's clue count is reached,
+// keeping the solution unique
+func (s *Service) carvePuzzle(solved Board, profile models.GenerationProfile, rng *rand.Rand) Board {
+	clues := profile.MinClues + rng.Intn(profile.MaxClues-profile.MinClues+1)
+	vacantTiles := 81 - clues
+
+	puzzle := solved
+	positions := rng.Perm(81) // Randomize cell positions
+	for _, pos := range positions {
+		if vacantTiles <= 0 {
+			break
+		}
+		cells := symmetricCells(pos, profile.Symmetry)
+		if puzzle[pos/9][pos%9] == 0 || len(cells) > vacantTiles {
+			continue
+		}
+
+		backup := puzzle
+		for _, p := range cells {
+			puzzle[p/9][p%9] = 0
+		}
+
+		// Check if the puzzle still has a unique solution
+		temp := puzzle
+		solutionCount := 0
+		s.countSolutions(&temp, &solutionCount, 2, nil)
+		if solutionCount != 1 {
+			puzzle = backup // Restore the cells if multiple solutions exist
+		} else {
+			vacantTiles -= len(cells)
+		}
+	}
+	return puzzle
+}
+
+// Cells that are cleared together with a cell to keep the clue pattern symmetric, the cell included
+func symmetricCells(pos int, symmetry models.Symmetry) []int {
+	row, col := pos/9, pos%9
+	var candidates []int
+	switch symmetry {
+	case models.RotationalSymmetry:
+		candidates = []int{pos, 80 - pos}
+	case models.MirrorSymmetry:
+		candidates = []int{pos, 80 - pos, row*9 + 8 - col, (8-row)*9 + col}
+	default:
+		return []int{pos}
+	}
+
+	var cells []int
+	for _, p := range candidates {
+		if !containsInt(cells, p) {
+			cells = append(cells, p)
+		}
+	}
+	return cells
+}
+
+// Check that the logical solve of a puzzle needs at least the profile's minimum technique
+// and stays within its hardest technique
+func (s *Service) meetsProfile(puzzle Board, profile models.GenerationProfile) bool {
+	if profile.MaxTechnique == "" && profile.MinTechnique == "" {
+		return true
+	}
+	hardest, ok := s.HardestTechnique(puzzle)
+	if !ok {
+		return false
+	}
+	if profile.MinTechnique != "" && techniqueRank(hardest) < techniqueRank(profile.MinTechnique) {
+		return false
+	}
+	return profile.MaxTechnique == "" || techniqueRank(hardest) <= techniqueRank(profile.MaxTechnique)
+}
+
+// Number of boards to try for a profile
+func generationAttempts(profile models.GenerationProfile) int {
+	if profile.MinTechnique != "" {
+		return maxSearchAttempts
+	}
+	return maxGenerationAttempts
+}
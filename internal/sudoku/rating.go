@@ -0,0 +1,83 @@
+package sudoku
+
+import (
+	"context"
+
+	"sudoku/internal/models"
+)
+
+// Rating classifies a puzzle by the hardest technique its logical solve needs
+type Rating struct {
+	Difficulty       models.Difficulty
+	HardestTechnique string // Empty when the puzzle can't be solved by human techniques
+}
+
+// Hardest technique rated at each difficulty, easiest first. Anything harder (fish, wings and
+// beyond), or a puzzle that needs backtracking, is rated expert.
+var ratingTiers = []struct {
+	difficulty models.Difficulty
+	ceiling    string
+}{
+	{models.Easy, "Hidden Single"},
+	{models.Medium, "Box/Line Reduction"},
+	{models.Hard, "Hidden Triple"},
+}
+
+// RatePuzzle solves the puzzle with the human technique chain and rates its difficulty
+// by the hardest technique required, regardless of how many clues it has
+func (s *Service) RatePuzzle(board Board) Rating {
+	hardest, ok := s.HardestTechnique(board)
+	if !ok {
+		return Rating{Difficulty: models.Expert}
+	}
+	return Rating{Difficulty: TechniqueDifficulty(hardest), HardestTechnique: hardest}
+}
+
+// TechniqueDifficulty is the rating of a puzzle whose hardest technique is name. Techniques the
+// logical solver doesn't know, such as "Advanced Step", are expert.
+func TechniqueDifficulty(name string) models.Difficulty {
+	rank := techniqueRank(name)
+	if rank < 0 {
+		return models.Expert
+	}
+	for _, tier := range ratingTiers {
+		if rank <= techniqueRank(tier.ceiling) {
+			return tier.difficulty
+		}
+	}
+	return models.Expert
+}
+
+// Grade breaks a puzzle's rating down into what its logical solve takes
+type Grade struct {
+	Rating           models.Difficulty `json:"rating"`
+	HardestTechnique string            `json:"hardest_technique"` // Empty when human techniques get stuck
+	Clues            int               `json:"clues"`
+	Placements       map[string]int    `json:"placements"`   // Cells filled with each technique, "Advanced Step" for cells past the techniques
+	Eliminations     map[string]int    `json:"eliminations"` // Candidate eliminations made with each technique to unlock placements
+	Logical          bool              `json:"logical"`      // Solved by human techniques only
+}
+
+// GradePuzzle rates a puzzle and counts the techniques of its logical solve
+func (s *Service) GradePuzzle(ctx context.Context, board Board) (*Grade, error) {
+	path, err := s.SolvePath(ctx, board)
+	if err != nil {
+		return nil, err
+	}
+	rating := s.RatePuzzle(board)
+	grade := &Grade{
+		Rating:           rating.Difficulty,
+		HardestTechnique: rating.HardestTechnique,
+		Clues:            81 - len(path.Moves),
+		Placements:       map[string]int{},
+		Eliminations:     map[string]int{},
+		Logical:          path.Logical,
+	}
+	for _, move := range path.Moves {
+		grade.Placements[move.Reason]++
+		for _, deduction := range move.Deductions {
+			grade.Eliminations[deduction.Technique]++
+		}
+	}
+	return grade, nil
+}
@@ -0,0 +1,70 @@
+package sudoku
+
+import (
+	"errors"
+	"math/rand"
+
+	"sudoku/internal/models"
+)
+
+// ErrUnknownTechnique is returned for a technique the logical solver doesn't know
+var ErrUnknownTechnique = errors.New("unknown technique")
+
+// ErrTechniqueNotFound is returned when none of the generated boards needed the technique.
+// The rarest techniques seldom show up in random puzzles.
+var ErrTechniqueNotFound = errors.New("no puzzle requiring the technique was found")
+
+// GeneratePuzzleRequiring generates a puzzle whose logical solve path uses the technique.
+// Clues are removed following the profile of the technique's difficulty.
+func (s *Service) GeneratePuzzleRequiring(technique string) (Board, Board, error) {
+	if techniqueRank(technique) < 0 {
+		return Board{}, Board{}, ErrUnknownTechnique
+	}
+	profile, err := s.GetGenerationProfile(carvingDifficulty(technique))
+	if err != nil {
+		return Board{}, Board{}, err
+	}
+
+	puzzle, solved, ok := generateConcurrently(maxSearchAttempts, func(rng *rand.Rand) (Board, Board, bool) {
+		var solved Board
+		if !s.solveRandom(&solved, rng) {
+			return Board{}, Board{}, false
+		}
+		puzzle := s.carvePuzzle(solved, profile, rng)
+		return puzzle, solved, s.usesTechnique(puzzle, technique)
+	})
+	if !ok {
+		return Board{}, Board{}, ErrTechniqueNotFound
+	}
+	return puzzle, solved, nil
+}
+
+// Difficulty whose clue count suits a technique. Easy puzzles fall to naked singles alone and
+// medium ones rarely need more than hidden singles, so everything harder uses the hard profile.
+// Advanced techniques show up about as often there as with fewer clues, and carving is much faster.
+func carvingDifficulty(technique string) models.Difficulty {
+	switch {
+	case technique == "Naked Single":
+		return models.Easy
+	case technique == "Hidden Single":
+		return models.Medium
+	default:
+		return models.Hard
+	}
+}
+
+// Reports whether the logical solve of the board uses the technique and finishes without backtracking
+func (s *Service) usesTechnique(board Board, technique string) bool {
+	used := false
+	for !isFull(board) {
+		move, err := s.LogicalStep(board)
+		if err != nil {
+			return false
+		}
+		for _, name := range moveTechniques(move) {
+			used = used || name == technique
+		}
+		board[move.Row][move.Col] = move.Value
+	}
+	return used
+}
@@ -0,0 +1,316 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"sudoku/internal/models"
+)
+
+// SamuraiSize is the width and height of a Samurai board: five 9x9 grids, four in the corners
+// and one in the middle sharing a corner box with each of them
+const SamuraiSize = 21
+
+// Top-left cell of each grid of a Samurai board, the middle one third
+var samuraiOrigins = [5]Cell{{Row: 0, Col: 0}, {Row: 0, Col: 12}, {Row: 6, Col: 6}, {Row: 12, Col: 0}, {Row: 12, Col: 12}}
+
+// SamuraiBoard is a Samurai board, 0 for empty cells. Cells outside the five grids stay 0.
+type SamuraiBoard [SamuraiSize][SamuraiSize]int
+
+// samuraiPlace is where a Samurai cell sits in one of its grids
+type samuraiPlace struct {
+	grid, row, col int
+}
+
+// The places of every Samurai cell: none outside the grids, two in the shared corner boxes
+var samuraiPlaces = func() (places [SamuraiSize][SamuraiSize][]samuraiPlace) {
+	for k, origin := range samuraiOrigins {
+		for i := 0; i < 9; i++ {
+			for j := 0; j < 9; j++ {
+				cell := &places[origin.Row+i][origin.Col+j]
+				*cell = append(*cell, samuraiPlace{grid: k, row: i, col: j})
+			}
+		}
+	}
+	return places
+}()
+
+// InSamurai reports whether the cell is part of one of the five grids
+func InSamurai(row, col int) bool {
+	return row >= 0 && row < SamuraiSize && col >= 0 && col < SamuraiSize && len(samuraiPlaces[row][col]) > 0
+}
+
+// ParseSamurai reads a board written by SamuraiToString: 441 characters row by row, a digit with
+// '0' for empty cells inside the grids and '.' for the cells outside them
+func ParseSamurai(s string) (SamuraiBoard, error) {
+	var board SamuraiBoard
+	if len(s) != SamuraiSize*SamuraiSize {
+		return board, fmt.Errorf("a Samurai grid must be exactly %d characters", SamuraiSize*SamuraiSize)
+	}
+	for pos := 0; pos < len(s); pos++ {
+		row, col, ch := pos/SamuraiSize, pos%SamuraiSize, s[pos]
+		if !InSamurai(row, col) {
+			if ch != '.' {
+				return board, fmt.Errorf("cell %d is outside the grids and must be '.'", pos)
+			}
+			continue
+		}
+		if ch < '0' || ch > '9' {
+			return board, fmt.Errorf("cell %d must be a digit, 0 for empty", pos)
+		}
+		board[row][col] = int(ch - '0')
+	}
+	return board, nil
+}
+
+// SamuraiToString writes a board the way ParseSamurai reads it
+func SamuraiToString(board SamuraiBoard) string {
+	buf := make([]byte, 0, SamuraiSize*SamuraiSize)
+	for i := 0; i < SamuraiSize; i++ {
+		for j := 0; j < SamuraiSize; j++ {
+			if InSamurai(i, j) {
+				buf = append(buf, byte(board[i][j]+'0'))
+			} else {
+				buf = append(buf, '.')
+			}
+		}
+	}
+	return string(buf)
+}
+
+// KeepsSamuraiGivens reports whether board still holds every digit given in start, like KeepsGivens
+func KeepsSamuraiGivens(start, board SamuraiBoard) bool {
+	for i := 0; i < SamuraiSize; i++ {
+		for j := 0; j < SamuraiSize; j++ {
+			if start[i][j] != 0 && board[i][j] != start[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FirstEmptySamurai returns the first empty cell of the grids, row by row
+func FirstEmptySamurai(board SamuraiBoard) (Cell, bool) {
+	for i := 0; i < SamuraiSize; i++ {
+		for j := 0; j < SamuraiSize; j++ {
+			if InSamurai(i, j) && board[i][j] == 0 {
+				return Cell{Row: i, Col: j}, true
+			}
+		}
+	}
+	return Cell{}, false
+}
+
+// The rows, columns and boxes of the five grids, as lists of cells of the flattened board for
+// newHouseSearch. A cell in a shared corner box is in the houses of both its grids.
+var samuraiHouses = func() [][]int {
+	var houses [][]int
+	for _, origin := range samuraiOrigins {
+		for _, house := range Shape9.houses() {
+			cells := make([]int, len(house))
+			for n, pos := range house {
+				cells[n] = (origin.Row+pos/9)*SamuraiSize + origin.Col + pos%9
+			}
+			houses = append(houses, cells)
+		}
+	}
+	return houses
+}()
+
+// The cells of the board row by row, as the search works on them
+func (b *SamuraiBoard) cells() []int {
+	cells := make([]int, 0, SamuraiSize*SamuraiSize)
+	for i := range b {
+		cells = append(cells, b[i][:]...)
+	}
+	return cells
+}
+
+// samuraiFromCells reads a board back from its cells
+func samuraiFromCells(cells []int) SamuraiBoard {
+	var board SamuraiBoard
+	for i := range board {
+		copy(board[i][:], cells[i*SamuraiSize:])
+	}
+	return board
+}
+
+// Set up the search of a board's cells, reporting false when its digits repeat in a row, column
+// or box of a grid
+func newSamuraiSearch(cells []int) (*houseSearch, bool) {
+	return newHouseSearch(cells, 9, samuraiHouses)
+}
+
+// SolveSamurai solves a Samurai board, within the service's solve budget
+func (s *Service) SolveSamurai(ctx context.Context, board SamuraiBoard) (SamuraiBoard, error) {
+	cells := board.cells()
+	h, ok := newSamuraiSearch(cells)
+	if !ok {
+		return board, ErrUnsolvable
+	}
+	var solution *SamuraiBoard
+	sr := newSearch(ctx, s.budget)
+	h.search(cells, sr, func(solved []int) bool {
+		found := samuraiFromCells(solved)
+		solution = &found
+		return true
+	})
+	if solution != nil {
+		return *solution, nil
+	}
+	if sr.err != nil {
+		return board, sr.err
+	}
+	return board, ErrUnsolvable
+}
+
+// CountSamuraiSolutions returns the number of solutions of a Samurai board, stopping once limit
+// is reached, within the service's solve budget
+func (s *Service) CountSamuraiSolutions(ctx context.Context, board SamuraiBoard, limit int) (int, error) {
+	cells := board.cells()
+	h, ok := newSamuraiSearch(cells)
+	if !ok {
+		return 0, nil
+	}
+	count := 0
+	sr := newSearch(ctx, s.budget)
+	h.search(cells, sr, func([]int) bool {
+		count++
+		return count >= limit
+	})
+	return count, sr.err
+}
+
+// SamuraiDuplicates lists the filled cells whose digit repeats in a row, column or box of one of
+// their grids
+func SamuraiDuplicates(board SamuraiBoard) []Cell {
+	duplicates := []Cell{}
+	for i := 0; i < SamuraiSize; i++ {
+		for j := 0; j < SamuraiSize; j++ {
+			value := board[i][j]
+			if value == 0 {
+				continue
+			}
+			if samuraiPeerDigits(board, i, j)&(1<<value) != 0 {
+				duplicates = append(duplicates, Cell{Row: i, Col: j})
+			}
+		}
+	}
+	return duplicates
+}
+
+// Digits used by the other cells in the rows, columns and boxes of a cell's grids, like peerDigits
+func samuraiPeerDigits(board SamuraiBoard, row, col int) uint16 {
+	var used uint16
+	for _, p := range samuraiPlaces[row][col] {
+		used |= peerDigits(board.grid(p.grid), p.row, p.col)
+	}
+	return used
+}
+
+// One of the five grids, as a board
+func (b *SamuraiBoard) grid(k int) Board {
+	origin := samuraiOrigins[k]
+	var board Board
+	for i := 0; i < 9; i++ {
+		copy(board[i][:], b[origin.Row+i][origin.Col:origin.Col+9])
+	}
+	return board
+}
+
+// Digits given in generated Samurai puzzles, by difficulty
+var samuraiGivens = map[models.Difficulty]int{
+	models.Easy:   190,
+	models.Medium: 160,
+	models.Hard:   135,
+	models.Expert: 120,
+}
+
+// Placements a uniqueness check of the Samurai generator may try, and a fill of the board
+const samuraiCheckNodes = 200_000
+
+// GenerateSamurai generates a Samurai puzzle and its solution until the context ends, carved to
+// the difficulty's number of givens without checking techniques. Only opts.Seed and
+// opts.Symmetry are used.
+func (s *Service) GenerateSamurai(ctx context.Context, difficulty models.Difficulty, opts GenerateOptions) (SamuraiBoard, SamuraiBoard, error) {
+	target, ok := samuraiGivens[difficulty]
+	if !ok {
+		return SamuraiBoard{}, SamuraiBoard{}, fmt.Errorf("invalid difficulty %q", difficulty)
+	}
+
+	var rng *rand.Rand
+	if opts.Seed != nil {
+		rng = rand.New(rand.NewSource(*opts.Seed))
+	} else {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	for attempt := 0; attempt < maxGenerationAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return SamuraiBoard{}, SamuraiBoard{}, err
+		}
+		cells := make([]int, SamuraiSize*SamuraiSize)
+		h, _ := newSamuraiSearch(cells)
+		if !h.solveRandom(cells, rng, newSearch(ctx, SolveBudget{MaxNodes: samuraiCheckNodes})) {
+			continue
+		}
+		solved := samuraiFromCells(cells)
+		if puzzle, givens := carveSamurai(ctx, solved, target, opts.Symmetry, rng); givens <= target {
+			return puzzle, solved, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return SamuraiBoard{}, SamuraiBoard{}, err
+	}
+	return SamuraiBoard{}, SamuraiBoard{}, errors.New("failed to generate a Samurai puzzle matching the difficulty")
+}
+
+// Remove digits down to target while the solution stays unique, like carveGrid, returning the
+// puzzle and how many digits are still given. The layout is symmetric itself, so clue symmetry
+// works on the whole 21x21 board.
+func carveSamurai(ctx context.Context, solved SamuraiBoard, target int, symmetry models.Symmetry, rng *rand.Rand) (SamuraiBoard, int) {
+	puzzle := solved
+	givens := 0
+	for i := 0; i < SamuraiSize; i++ {
+		for j := 0; j < SamuraiSize; j++ {
+			if InSamurai(i, j) {
+				givens++
+			}
+		}
+	}
+
+	whole := Shape{Size: SamuraiSize}
+	for _, pos := range rng.Perm(whole.Cells()) {
+		if givens <= target {
+			break
+		}
+		if puzzle[pos/SamuraiSize][pos%SamuraiSize] == 0 {
+			continue
+		}
+		backup := puzzle
+		removed := 0
+		for _, p := range gridSymmetricCells(whole, pos, symmetry) {
+			if puzzle[p/SamuraiSize][p%SamuraiSize] != 0 {
+				puzzle[p/SamuraiSize][p%SamuraiSize] = 0
+				removed++
+			}
+		}
+
+		cells := puzzle.cells()
+		h, _ := newSamuraiSearch(cells)
+		count := 0
+		sr := newSearch(ctx, SolveBudget{MaxNodes: samuraiCheckNodes})
+		h.search(cells, sr, func([]int) bool {
+			count++
+			return count >= 2
+		})
+		if count != 1 || sr.err != nil {
+			puzzle = backup
+		} else {
+			givens -= removed
+		}
+	}
+	return puzzle, givens
+}
@@ -1,8 +1,11 @@
 package sudoku
 
 import (
+	"context"
 	"errors"
+	"math/bits"
 	"math/rand"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,20 +14,23 @@ import (
 )
 
 type Service struct {
-	db *gorm.DB
+	db     *gorm.DB
+	budget SolveBudget
 }
 
 type Board [9][9]int
 
 type Move struct {
-	Row    int    `json:"row"`
-	Col    int    `json:"col"`
-	Value  int    `json:"value"`
-	Reason string `json:"reason"`
+	Row        int         `json:"row"`
+	Col        int         `json:"col"`
+	Value      int         `json:"value"`
+	Reason     string      `json:"reason"`
+	Deductions []Deduction `json:"deductions,omitempty"` // Candidate eliminations that lead to this move
+	Highlight  *Highlight  `json:"highlight,omitempty"`  // Drawing instructions, set on hint and solve-step responses
 }
 
 func NewService(db *gorm.DB) *Service {
-	return &Service{db: db}
+	return &Service{db: db, budget: DefaultSolveBudget}
 }
 
 // Convert string representation to Board
@@ -38,45 +44,66 @@ func StringToBoard(s string) Board {
 	return board
 }
 
-// Convert Board to string representation
-func BoardToString(board Board) string {
-	var s string
-	for i := 0; i < 9; i++ {
-		for j := 0; j < 9; j++ {
-			s += string(rune(board[i][j] + '0'))
+// ParseBoard converts an untrusted string to a Board, validating its format
+func ParseBoard(s string) (Board, error) {
+	if len(s) != 81 {
+		return Board{}, errors.New("grid must be exactly 81 characters")
+	}
+	for i := 0; i < 81; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return Board{}, errors.New("grid may only contain digits 0-9")
 		}
 	}
-	return s
+	return StringToBoard(s), nil
 }
 
-// Validate if a move is valid
-func (s *Service) IsValidMove(board Board, row, col, value int) bool {
-	// Check row
-	for j := 0; j < 9; j++ {
-		if board[row][j] == value && j != col {
-			return false
+// NormalizeGrid repairs common client encodings of a grid: whitespace and
+// separators are dropped and '.', '-' or '_' become empty cells
+func NormalizeGrid(s string) (string, bool) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == '-' || r == '_':
+			b.WriteRune('0')
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == ',' || r == '|':
+		default:
+			return "", false
 		}
 	}
+	if b.Len() != 81 {
+		return "", false
+	}
+	return b.String(), true
+}
 
-	// Check column
+// KeepsGivens reports whether every clue of the starting board is unchanged
+func KeepsGivens(start, board Board) bool {
 	for i := 0; i < 9; i++ {
-		if board[i][col] == value && i != row {
-			return false
+		for j := 0; j < 9; j++ {
+			if start[i][j] != 0 && board[i][j] != start[i][j] {
+				return false
+			}
 		}
 	}
+	return true
+}
 
-	// Check 3x3 box
-	boxRow := (row / 3) * 3
-	boxCol := (col / 3) * 3
-	for i := boxRow; i < boxRow+3; i++ {
-		for j := boxCol; j < boxCol+3; j++ {
-			if board[i][j] == value && (i != row || j != col) {
-				return false
-			}
+// Convert Board to string representation
+func BoardToString(board Board) string {
+	var buf [81]byte
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			buf[i*9+j] = byte(board[i][j] + '0')
 		}
 	}
+	return string(buf[:])
+}
 
-	return true
+// Validate if a move is valid
+func (s *Service) IsValidMove(board Board, row, col, value int) bool {
+	return peerDigits(board, row, col)&(1<<value) == 0
 }
 
 // Get valid candidates for a cell
@@ -84,14 +111,7 @@ func (s *Service) GetCandidates(board Board, row, col int) []int {
 	if board[row][col] != 0 {
 		return []int{}
 	}
-
-	var candidates []int
-	for value := 1; value <= 9; value++ {
-		if s.IsValidMove(board, row, col, value) {
-			candidates = append(candidates, value)
-		}
-	}
-	return candidates
+	return digits(allDigits &^ peerDigits(board, row, col))
 }
 
 // Find naked singles (cells with only one candidate)
@@ -116,22 +136,22 @@ func (s *Service) FindNakedSingles(board Board) []Move {
 }
 
 // Get hint for a specific cell
-func (s *Service) GetHint(board Board, row, col int) (*Move, error) {
+func (s *Service) GetHint(ctx context.Context, board Board, row, col int) (*Move, error) {
 	if board[row][col] != 0 {
 		return nil, errors.New("cell is already filled")
 	}
 
 	// Use the solver to find the correct value for the cell, ensuring the hint is always correct.
-	solvedBoard, success := s.SolvePuzzle(board)
-	if !success {
-		return nil, errors.New("puzzle cannot be solved from current state")
+	solvedBoard, err := s.SolvePuzzle(ctx, board)
+	if err != nil {
+		return nil, err
 	}
 
 	correctValue := solvedBoard[row][col]
 
 	// We can try to find a reason for the move.
 	// This is not essential for correctness but provides better user feedback.
-	step, err := s.SolveStep(board)
+	step, err := s.SolveStep(ctx, board)
 	if err == nil && step.Row == row && step.Col == col {
 		// If SolveStep identifies the same cell, we can use its reason.
 		return step, nil
@@ -147,9 +167,9 @@ func (s *Service) GetHint(board Board, row, col int) (*Move, error) {
 }
 
 // Find a solvable cell for hint highlighting
-func (s *Service) FindSolvableCell(board Board) (*Move, error) {
+func (s *Service) FindSolvableCell(ctx context.Context, board Board) (*Move, error) {
 	// Use the same logic as SolveStep to ensure the hint is always a correct and logical next move.
-	return s.SolveStep(board)
+	return s.SolveStep(ctx, board)
 }
 
 // Find hidden singles (cells where a candidate is unique in a row, column, or box)
@@ -243,8 +263,8 @@ func (s *Service) FindHiddenSingles(board Board) []Move {
 	return moves
 }
 
-// Solve puzzle step-by-step
-func (s *Service) SolveStep(board Board) (*Move, error) {
+// Find the next move using human techniques only (no backtracking)
+func (s *Service) LogicalStep(board Board) (*Move, error) {
 	// 1. Find Naked Singles
 	nakedSingles := s.FindNakedSingles(board)
 	if len(nakedSingles) > 0 {
@@ -257,80 +277,93 @@ func (s *Service) SolveStep(board Board) (*Move, error) {
 		return &hiddenSingles[0], nil
 	}
 
-	// 3. If no simple moves, use backtracking to find the next logical step
-	solvedBoard, success := s.SolvePuzzle(board)
-	if !success {
-		return nil, errors.New("puzzle cannot be solved")
+	// 3. Apply candidate techniques until a single appears
+	if move := s.logicalStep(board, true); move != nil {
+		return move, nil
+	}
+
+	return nil, ErrNoLogicalMove
+}
+
+// Solve puzzle step-by-step
+func (s *Service) SolveStep(ctx context.Context, board Board) (*Move, error) {
+	if move, err := s.LogicalStep(board); err == nil {
+		return move, nil
+	}
+
+	// If no logical moves, use backtracking to find the next step
+	solvedBoard, err := s.SolvePuzzle(ctx, board)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the first empty cell and return the solved value
-	for r := 0; r < 9; r++ {
-		for c := 0; c < 9; c++ {
-			if board[r][c] == 0 {
-				return &Move{
-					Row:    r,
-					Col:    c,
-					Value:  solvedBoard[r][c],
-					Reason: "Advanced Step",
-				}, nil
-			}
-		}
+	if move := firstEmptyMove(board, solvedBoard); move != nil {
+		return move, nil
 	}
 
 	return nil, errors.New("could not fill any cell")
 }
 
-// Solve puzzle using backtracking
-func (s *Service) SolvePuzzle(board Board) (Board, bool) {
+// Solve puzzle using backtracking, within the service's solve budget.
+// The context's error is returned if it is cancelled or its deadline passes first.
+func (s *Service) SolvePuzzle(ctx context.Context, board Board) (Board, error) {
 	var solved Board
 	copy(solved[:], board[:])
 
-	if s.solve(&solved) {
-		return solved, true
+	sr := newSearch(ctx, s.budget)
+	if s.solve(&solved, sr) {
+		return solved, nil
+	}
+	if sr.err != nil {
+		return board, sr.err
 	}
-	return board, false
+	return board, ErrUnsolvable
 }
 
-func (s *Service) solve(board *Board) bool {
-	for i := 0; i < 9; i++ {
-		for j := 0; j < 9; j++ {
-			if board[i][j] == 0 {
-				for value := 1; value <= 9; value++ {
-					if s.IsValidMove(*board, i, j, value) {
-						board[i][j] = value
-						if s.solve(board) {
-							return true
-						}
-						board[i][j] = 0
-					}
-				}
-				return false
-			}
+func (s *Service) solve(board *Board, sr *search) bool {
+	return newMasks(board).solve(board, sr)
+}
+
+func (m *masks) solve(board *Board, sr *search) bool {
+	row, col, candidates, ok := m.nextCell(board)
+	if !ok {
+		return true
+	}
+	for ; candidates != 0; candidates &= candidates - 1 {
+		if sr.visit() {
+			return false
+		}
+		value := bits.TrailingZeros16(candidates)
+		m.place(board, row, col, value)
+		if m.solve(board, sr) {
+			return true
 		}
+		m.unplace(board, row, col, value)
 	}
-	return true
+	return false
 }
 
-func (s *Service) solveRandom(board *Board) bool {
-	for i := 0; i < 9; i++ {
-		for j := 0; j < 9; j++ {
-			if board[i][j] == 0 {
-				numbers := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
-				rand.Shuffle(len(numbers), func(i, j int) { numbers[i], numbers[j] = numbers[j], numbers[i] })
-				for _, value := range numbers {
-					if s.IsValidMove(*board, i, j, value) {
-						board[i][j] = value
-						if s.solveRandom(board) {
-							return true
-						}
-						board[i][j] = 0
-					}
-				}
-				return false
-			}
+// Fill the board with a random solution
+func (s *Service) solveRandom(board *Board, rng *rand.Rand) bool {
+	return newMasks(board).solveRandom(board, rng)
+}
+
+func (m *masks) solveRandom(board *Board, rng *rand.Rand) bool {
+	row, col, candidates, ok := m.nextCell(board)
+	if !ok {
+		return true
+	}
+	values := digits(candidates)
+	rng.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	for _, value := range values {
+		m.place(board, row, col, value)
+		if m.solveRandom(board, rng) {
+			return true
 		}
+		m.unplace(board, row, col, value)
 	}
-	return true
+	return false
 }
 
 // IsSolved checks if the final board matches the solution board.
@@ -465,71 +498,115 @@ func (s *Service) RandomizeBoard(board *Board) {
 	}
 }
 
-func (s *Service) GeneratePuzzle(difficulty models.Difficulty) (Board, Board, error) {
-	var vacantTiles int
-	switch difficulty {
-	case models.Easy:
-		vacantTiles = 35 // Fewer vacant tiles for easy puzzles
-	case models.Medium:
-		vacantTiles = 45 // Moderate vacant tiles for medium puzzles
-	case models.Hard:
-		vacantTiles = 54 // Most vacant tiles for hard puzzles
-	default:
-		return Board{}, Board{}, errors.New("invalid difficulty")
-	}
+// GenerateOptions override parts of a difficulty's generation profile
+type GenerateOptions struct {
+	Symmetry models.Symmetry // Clue pattern symmetry, empty to use the profile's
+	Seed     *int64          // Generates the same puzzle for the same seed and profile, nil for a random one
 
-	// Generate a fully solved board
-	var solved Board
-	rand.Seed(time.Now().UnixNano())
-	if !s.solveRandom(&solved) {
-		return Board{}, Board{}, errors.New("failed to generate solved board")
-	}
+	// Chess constraints added to a variant's rules, see GenerateChess for classic puzzles
+	AntiKnight bool
+	AntiKing   bool
+}
 
-	// Create a puzzle by removing tiles while ensuring a single solution
-	puzzle := solved
-	positions := rand.Perm(81) // Randomize cell positions
-	for _, pos := range positions {
-		if vacantTiles <= 0 {
-			break
+// GeneratePuzzle generates a puzzle and its solution following the difficulty's generation profile
+func (s *Service) GeneratePuzzle(difficulty models.Difficulty, opts GenerateOptions) (Board, Board, error) {
+	profile, err := s.GetGenerationProfile(difficulty)
+	if err != nil {
+		return Board{}, Board{}, err
+	}
+	if opts.Symmetry != "" {
+		profile.Symmetry = opts.Symmetry
+		if err := ValidateGenerationProfile(profile); err != nil {
+			return Board{}, Board{}, err
 		}
-		row, col := pos/9, pos%9
-		backup := puzzle[row][col]
-		puzzle[row][col] = 0
-
-		// Check if the puzzle still has a unique solution
-		temp := puzzle
-		solutionCount := 0
-		s.countSolutions(&temp, &solutionCount)
-		if solutionCount != 1 {
-			puzzle[row][col] = backup // Restore the cell if multiple solutions exist
-		} else {
-			vacantTiles--
+	}
+
+	attempt := func(rng *rand.Rand) (Board, Board, bool) {
+		// Generate a fully solved board
+		var solved Board
+		if !s.solveRandom(&solved, rng) {
+			return Board{}, Board{}, false
 		}
+
+		// Create a puzzle by removing tiles while ensuring a single solution
+		puzzle := s.carvePuzzle(solved, profile, rng)
+		return puzzle, solved, s.meetsProfile(puzzle, profile)
 	}
 
+	var puzzle, solved Board
+	var ok bool
+	if opts.Seed != nil {
+		puzzle, solved, ok = generateSeeded(*opts.Seed, generationAttempts(profile), attempt)
+	} else {
+		puzzle, solved, ok = generateConcurrently(generationAttempts(profile), attempt)
+	}
+	if !ok {
+		return Board{}, Board{}, errors.New("failed to generate a puzzle matching the difficulty profile")
+	}
 	return puzzle, solved, nil
 }
 
-func (s *Service) countSolutions(board *Board, count *int) bool {
+// CountSolutions returns the number of solutions of the board, stopping once limit is reached.
+// A BudgetExceededError is returned if the count can't finish within the solve budget,
+// or the context's error if it ends first.
+func (s *Service) CountSolutions(ctx context.Context, board Board, limit int) (int, error) {
+	count := 0
+	sr := newSearch(ctx, s.budget)
+	newDLX(board).search(sr, func(Board) bool {
+		count++
+		return count >= limit
+	})
+	return count, sr.err
+}
+
+// Solutions enumerates up to limit distinct solutions of the board, within the solve budget
+func (s *Service) Solutions(ctx context.Context, board Board, limit int) ([]Board, error) {
+	var solutions []Board
+	sr := newSearch(ctx, s.budget)
+	newDLX(board).search(sr, func(solution Board) bool {
+		solutions = append(solutions, solution)
+		return len(solutions) >= limit
+	})
+	return solutions, sr.err
+}
+
+// IsConsistent reports whether no filled cell conflicts with another in its row, column or box
+func (s *Service) IsConsistent(board Board) bool {
 	for i := 0; i < 9; i++ {
 		for j := 0; j < 9; j++ {
-			if board[i][j] == 0 {
-				for value := 1; value <= 9; value++ {
-					if s.IsValidMove(*board, i, j, value) {
-						board[i][j] = value
-						// Recurse and then always backtrack
-						finished := s.countSolutions(board, count)
-						board[i][j] = 0
-
-						if finished {
-							return true // Propagate early exit
-						}
-					}
-				}
+			if board[i][j] != 0 && !s.IsValidMove(board, i, j, board[i][j]) {
 				return false
 			}
 		}
 	}
-	*count++
-	return *count > 1 // Stop if more than one solution is found
+	return true
+}
+
+// Count solutions by backtracking over bitmasks. Building the exact cover problem costs more
+// than the whole search on the nearly full boards the generator checks, so it uses this instead.
+func (s *Service) countSolutions(board *Board, count *int, limit int, sr *search) bool {
+	return newMasks(board).countSolutions(board, count, limit, sr)
+}
+
+func (m *masks) countSolutions(board *Board, count *int, limit int, sr *search) bool {
+	row, col, candidates, ok := m.nextCell(board)
+	if !ok {
+		*count++
+		return *count >= limit // Stop once the limit is reached
+	}
+	for ; candidates != 0; candidates &= candidates - 1 {
+		if sr.visit() {
+			return true // Out of budget, stop the whole search
+		}
+		value := bits.TrailingZeros16(candidates)
+		m.place(board, row, col, value)
+		// Recurse and then always backtrack
+		finished := m.countSolutions(board, count, limit, sr)
+		m.unplace(board, row, col, value)
+
+		if finished {
+			return true // Propagate early exit
+		}
+	}
+	return false
 }
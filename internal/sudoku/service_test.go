@@ -0,0 +1,31 @@
+package sudoku
+
+import (
+	"context"
+	"testing"
+)
+
+// Puzzle the benchmarks work on
+const benchmarkPuzzle = "530070000600195000098000060800060003400803001700020006060000280000419005000080079"
+
+func BenchmarkSolveStep(b *testing.B) {
+	s := NewService(nil)
+	board := StringToBoard(benchmarkPuzzle)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.SolveStep(ctx, board); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBoardToString(b *testing.B) {
+	board := StringToBoard(benchmarkPuzzle)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BoardToString(board)
+	}
+}
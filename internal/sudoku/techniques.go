@@ -0,0 +1,1139 @@
+package sudoku
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+)
+
+// CandidateGrid holds the pencil marks of every cell as a bitmask (bit v set means v is possible)
+type CandidateGrid [9][9]uint16
+
+// Cell identifies a single position on the board
+type Cell struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// Elimination lists the candidates removed from one cell by a technique
+type Elimination struct {
+	Row    int   `json:"row"`
+	Col    int   `json:"col"`
+	Values []int `json:"values"`
+}
+
+// Deduction is a candidate-level step: the technique used, the cells forming
+// the pattern and the eliminations it allows.
+type Deduction struct {
+	Technique    string        `json:"technique"`
+	Reason       string        `json:"reason"`
+	Cells        []Cell        `json:"cells"`
+	Eliminations []Elimination `json:"eliminations"`
+	Fish         *Fish         `json:"fish,omitempty"`      // Set by X-Wing, Swordfish and Jellyfish
+	Wing         *Wing         `json:"wing,omitempty"`      // Set by XY-Wing and XYZ-Wing
+	Chain        *Chain        `json:"chain,omitempty"`     // Set by Simple Coloring
+	Highlight    *Highlight    `json:"highlight,omitempty"` // Drawing instructions, set on elimination hints
+	Explanation  *Explanation  `json:"explanation,omitempty"`
+}
+
+// Chain describes a single-digit coloring chain: the two colors and the conjugate links
+// joining them, so clients can draw it
+type Chain struct {
+	Value  int       `json:"value"`
+	Colors [2][]Cell `json:"colors"`
+	Links  [][2]Cell `json:"links"`
+}
+
+// Wing describes the pivot and pincers of an XY-Wing or XYZ-Wing; Value is the eliminated candidate
+type Wing struct {
+	Pivot   Cell   `json:"pivot"`
+	Pincers []Cell `json:"pincers"`
+	Value   int    `json:"value"`
+}
+
+// Fish describes the lines of a fish pattern so clients can draw it. Lines are 0-based.
+type Fish struct {
+	Value      int    `json:"value"`
+	BaseKind   string `json:"base_kind"` // "rows" or "columns"
+	BaseLines  []int  `json:"base_lines"`
+	CoverLines []int  `json:"cover_lines"`
+}
+
+// Technique is a human solving strategy that works on the candidate grid
+type Technique struct {
+	Name string
+	// Uniqueness marks techniques that are only valid because the puzzle has a single solution
+	Uniqueness bool
+	find       func(s *Service, c *CandidateGrid) *Deduction
+}
+
+// Techniques in the order a human solver would try them (easiest first)
+var techniques = []Technique{
+	{Name: "Pointing Pair", find: (*Service).findPointing},
+	{Name: "Box/Line Reduction", find: (*Service).findBoxLineReduction},
+	{Name: "Naked Pair", find: (*Service).findNakedPair},
+	{Name: "Hidden Pair", find: (*Service).findHiddenPair},
+	{Name: "Naked Triple", find: (*Service).findNakedTriple},
+	{Name: "Hidden Triple", find: (*Service).findHiddenTriple},
+	{Name: "X-Wing", find: (*Service).findXWing},
+	{Name: "XY-Wing", find: (*Service).findXYWing},
+	{Name: "XYZ-Wing", find: (*Service).findXYZWing},
+	{Name: "Swordfish", find: (*Service).findSwordfish},
+	{Name: "Jellyfish", find: (*Service).findJellyfish},
+	{Name: "Unique Rectangle", Uniqueness: true, find: (*Service).findUniqueRectangle},
+	{Name: "Simple Coloring", find: (*Service).findSimpleColoring},
+	{Name: "Remote Pairs", find: (*Service).findRemotePairs},
+}
+
+// Has reports whether value is still a candidate for the cell
+func (c *CandidateGrid) Has(row, col, value int) bool {
+	return c[row][col]&(1<<value) != 0
+}
+
+// Count returns the number of candidates left in the cell
+func (c *CandidateGrid) Count(row, col int) int {
+	return bits.OnesCount16(c[row][col])
+}
+
+// Values returns the candidates of the cell in ascending order
+func (c *CandidateGrid) Values(row, col int) []int {
+	return maskValues(c[row][col])
+}
+
+// Lists returns the candidates of every cell, indexed by row*9+col; filled cells have none
+func (c *CandidateGrid) Lists() [][]int {
+	lists := make([][]int, 81)
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			lists[i*9+j] = append([]int{}, c.Values(i, j)...)
+		}
+	}
+	return lists
+}
+
+func maskValues(mask uint16) []int {
+	var values []int
+	for value := 1; value <= 9; value++ {
+		if mask&(1<<value) != 0 {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Apply removes the eliminated candidates of a deduction from the grid
+func (c *CandidateGrid) Apply(d *Deduction) {
+	for _, e := range d.Eliminations {
+		for _, value := range e.Values {
+			c[e.Row][e.Col] &^= 1 << value
+		}
+	}
+}
+
+// Scratch candidate grids for logicalStep, which runs once per placed cell
+// while grading and generating puzzles
+var candidatePool = sync.Pool{
+	New: func() interface{} { return new(CandidateGrid) },
+}
+
+// Compute the candidate grid for a board
+func (s *Service) ComputeCandidates(board Board) CandidateGrid {
+	var c CandidateGrid
+	m := newMasks(&board)
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 {
+				c[i][j] = m.candidates(i, j)
+			}
+		}
+	}
+	return c
+}
+
+// Find a cell that can be filled directly from the candidate grid
+func (s *Service) findCandidateSingle(c *CandidateGrid) *Move {
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if c.Count(i, j) == 1 {
+				return &Move{Row: i, Col: j, Value: c.Values(i, j)[0], Reason: "Naked Single"}
+			}
+		}
+	}
+
+	for _, unit := range allUnits() {
+		for value := 1; value <= 9; value++ {
+			var found Cell
+			count := 0
+			for _, cell := range unit.cells {
+				if c.Has(cell.Row, cell.Col, value) {
+					found = cell
+					count++
+				}
+			}
+			if count == 1 {
+				return &Move{Row: found.Row, Col: found.Col, Value: value, Reason: "Hidden Single in " + unit.kind}
+			}
+		}
+	}
+	return nil
+}
+
+// Find a logical placement by applying candidate techniques until a single appears.
+// The deductions applied on the way are returned on the move.
+func (s *Service) logicalStep(board Board, allowUniqueness bool) *Move {
+	c := candidatePool.Get().(*CandidateGrid)
+	defer candidatePool.Put(c)
+	*c = s.ComputeCandidates(board)
+
+	var applied []Deduction
+	for {
+		if move := s.findCandidateSingle(c); move != nil {
+			move.Deductions = applied
+			return move
+		}
+
+		var d *Deduction
+		for _, t := range techniques {
+			if t.Uniqueness && !allowUniqueness {
+				continue
+			}
+			if d = t.find(s, c); d != nil {
+				break
+			}
+		}
+		if d == nil {
+			return nil
+		}
+		c.Apply(d)
+		applied = append(applied, *d)
+	}
+}
+
+// Rank of a technique in solving order, -1 if unknown. Singles come before the candidate techniques.
+func techniqueRank(name string) int {
+	switch name {
+	case "Naked Single":
+		return 0
+	case "Hidden Single":
+		return 1
+	}
+	for i, t := range techniques {
+		if t.Name == name {
+			return i + 2
+		}
+	}
+	return -1
+}
+
+// TechniqueNames lists every technique known to the logical solver, easiest first
+func TechniqueNames() []string {
+	names := []string{"Naked Single", "Hidden Single"}
+	for _, t := range techniques {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// HardestTechnique solves the puzzle logically and returns the hardest technique needed.
+// Returns false if the puzzle cannot be solved without backtracking.
+func (s *Service) HardestTechnique(board Board) (string, bool) {
+	hardest := ""
+	for !isFull(board) {
+		move := s.logicalStep(board, true)
+		if move == nil {
+			return hardest, false
+		}
+		for _, name := range moveTechniques(move) {
+			if techniqueRank(name) > techniqueRank(hardest) {
+				hardest = name
+			}
+		}
+		board[move.Row][move.Col] = move.Value
+	}
+	return hardest, true
+}
+
+// moveTechniques lists the techniques behind a move: its candidate deductions, then the single placing the value
+func moveTechniques(move *Move) []string {
+	var used []string
+	for _, d := range move.Deductions {
+		used = append(used, d.Technique)
+	}
+	return append(used, strings.SplitN(move.Reason, " in ", 2)[0]) // "Hidden Single in Row" -> "Hidden Single"
+}
+
+// Fill the board using logical steps only. Returns false when the solver gets stuck.
+func (s *Service) solveLogically(board Board, allowUniqueness bool) (Board, bool) {
+	for !isFull(board) {
+		move := s.logicalStep(board, allowUniqueness)
+		if move == nil {
+			return board, false
+		}
+		board[move.Row][move.Col] = move.Value
+	}
+	return board, true
+}
+
+// UsesUniqueness reports whether solving the puzzle by logic requires a uniqueness argument,
+// i.e. it gets stuck without uniqueness techniques but not with them.
+func (s *Service) UsesUniqueness(board Board) bool {
+	if _, ok := s.solveLogically(board, false); ok {
+		return false
+	}
+	_, ok := s.solveLogically(board, true)
+	return ok
+}
+
+// Pointing Pair: when a value's places in a box all lie on one row or column, the value
+// belongs to that box, so the rest of the line loses it
+func (s *Service) findPointing(c *CandidateGrid) *Deduction {
+	for _, box := range allUnits() {
+		if box.kind != "Box" {
+			continue
+		}
+		for value := 1; value <= 9; value++ {
+			var places []Cell
+			for _, cell := range box.cells {
+				if c.Has(cell.Row, cell.Col, value) {
+					places = append(places, cell)
+				}
+			}
+			if len(places) < 2 {
+				continue
+			}
+			for _, line := range lineUnits() {
+				if !allIn(places, line) {
+					continue
+				}
+				eliminations := eliminateOutside(c, line, box, value)
+				if len(eliminations) > 0 {
+					return &Deduction{
+						Technique:    "Pointing Pair",
+						Reason:       fmt.Sprintf("Pointing Pair: %d in this box can only go in one %s, so the rest of the %s can't hold %d", value, strings.ToLower(line.kind), strings.ToLower(line.kind), value),
+						Cells:        places,
+						Eliminations: eliminations,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Box/Line Reduction (claiming): when a value's places in a row or column all lie in one
+// box, the line claims the value, so the rest of the box loses it
+func (s *Service) findBoxLineReduction(c *CandidateGrid) *Deduction {
+	boxes := allUnits()
+	for _, line := range lineUnits() {
+		for value := 1; value <= 9; value++ {
+			var places []Cell
+			for _, cell := range line.cells {
+				if c.Has(cell.Row, cell.Col, value) {
+					places = append(places, cell)
+				}
+			}
+			if len(places) < 2 {
+				continue
+			}
+			for _, box := range boxes {
+				if box.kind != "Box" || !allIn(places, box) {
+					continue
+				}
+				eliminations := eliminateOutside(c, box, line, value)
+				if len(eliminations) > 0 {
+					return &Deduction{
+						Technique:    "Box/Line Reduction",
+						Reason:       fmt.Sprintf("Box/Line Reduction: %d in this %s can only go in one box, so the rest of the box can't hold %d", value, strings.ToLower(line.kind), value),
+						Cells:        places,
+						Eliminations: eliminations,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Remove value from the cells of target that are not part of keep
+func eliminateOutside(c *CandidateGrid, target, keep unit, value int) []Elimination {
+	var eliminations []Elimination
+	for _, cell := range target.cells {
+		if !contains(keep.cells, cell) && c.Has(cell.Row, cell.Col, value) {
+			eliminations = append(eliminations, Elimination{Row: cell.Row, Col: cell.Col, Values: []int{value}})
+		}
+	}
+	return eliminations
+}
+
+// Naked Pair: two cells of a unit holding the same two candidates take those values,
+// so the rest of the unit loses them
+func (s *Service) findNakedPair(c *CandidateGrid) *Deduction {
+	return s.findNakedSubset(c, 2, "Naked Pair")
+}
+
+// Naked Triple: three cells of a unit whose candidates together are only three values
+func (s *Service) findNakedTriple(c *CandidateGrid) *Deduction {
+	return s.findNakedSubset(c, 3, "Naked Triple")
+}
+
+// Find size cells in one unit whose combined candidates are exactly size values
+func (s *Service) findNakedSubset(c *CandidateGrid, size int, name string) *Deduction {
+	for _, unit := range allUnits() {
+		var open []Cell
+		for _, cell := range unit.cells {
+			if n := c.Count(cell.Row, cell.Col); n >= 2 && n <= size {
+				open = append(open, cell)
+			}
+		}
+
+		for _, combo := range combinations(len(open), size) {
+			var mask uint16
+			subset := make([]Cell, size)
+			for x, index := range combo {
+				subset[x] = open[index]
+				mask |= c[open[index].Row][open[index].Col]
+			}
+			if bits.OnesCount16(mask) != size {
+				continue
+			}
+
+			var eliminations []Elimination
+			for _, cell := range unit.cells {
+				if contains(subset, cell) || c[cell.Row][cell.Col]&mask == 0 {
+					continue
+				}
+				eliminations = append(eliminations, Elimination{Row: cell.Row, Col: cell.Col, Values: maskValues(c[cell.Row][cell.Col] & mask)})
+			}
+			if len(eliminations) > 0 {
+				return &Deduction{
+					Technique:    name,
+					Reason:       fmt.Sprintf("%s in %s: these cells must hold %v, so no other cell in the %s can", name, unit.kind, maskValues(mask), strings.ToLower(unit.kind)),
+					Cells:        subset,
+					Eliminations: eliminations,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Hidden Pair: two values that fit in only the same two cells of a unit claim those
+// cells, so every other candidate there goes
+func (s *Service) findHiddenPair(c *CandidateGrid) *Deduction {
+	return s.findHiddenSubset(c, 2, "Hidden Pair")
+}
+
+// Hidden Triple: three values confined to the same three cells of a unit
+func (s *Service) findHiddenTriple(c *CandidateGrid) *Deduction {
+	return s.findHiddenSubset(c, 3, "Hidden Triple")
+}
+
+// Find size values whose places in one unit are exactly size cells
+func (s *Service) findHiddenSubset(c *CandidateGrid, size int, name string) *Deduction {
+	for _, unit := range allUnits() {
+		// Values still open in the unit that fit in at most size cells
+		var values []int
+		places := make(map[int][]Cell)
+		for value := 1; value <= 9; value++ {
+			for _, cell := range unit.cells {
+				if c.Has(cell.Row, cell.Col, value) {
+					places[value] = append(places[value], cell)
+				}
+			}
+			if n := len(places[value]); n >= 2 && n <= size {
+				values = append(values, value)
+			}
+		}
+
+		for _, combo := range combinations(len(values), size) {
+			var mask uint16
+			var subset []Cell
+			for _, index := range combo {
+				mask |= 1 << values[index]
+				for _, cell := range places[values[index]] {
+					if !contains(subset, cell) {
+						subset = append(subset, cell)
+					}
+				}
+			}
+			if len(subset) != size {
+				continue
+			}
+
+			var eliminations []Elimination
+			for _, cell := range subset {
+				if extra := c[cell.Row][cell.Col] &^ mask; extra != 0 {
+					eliminations = append(eliminations, Elimination{Row: cell.Row, Col: cell.Col, Values: maskValues(extra)})
+				}
+			}
+			if len(eliminations) > 0 {
+				return &Deduction{
+					Technique:    name,
+					Reason:       fmt.Sprintf("%s in %s: %v only fit in these cells, so they can hold nothing else", name, unit.kind, maskValues(mask)),
+					Cells:        subset,
+					Eliminations: eliminations,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// X-Wing: when a value fits in exactly the same two columns of two rows, those
+// columns hold it in those rows, so the rest of both columns loses it (and vice versa)
+func (s *Service) findXWing(c *CandidateGrid) *Deduction {
+	return s.findFish(c, 2, "X-Wing")
+}
+
+// XY-Wing: a pivot {x,y} sees pincers {x,z} and {y,z}. Whichever value the pivot takes,
+// one pincer is z, so cells seeing both pincers lose z.
+func (s *Service) findXYWing(c *CandidateGrid) *Deduction {
+	return s.findWing(c, 2, "XY-Wing")
+}
+
+// XYZ-Wing: a pivot {x,y,z} sees pincers {x,z} and {y,z}. One of the three is z, so cells
+// seeing all three lose z.
+func (s *Service) findXYZWing(c *CandidateGrid) *Deduction {
+	return s.findWing(c, 3, "XYZ-Wing")
+}
+
+// Find a wing whose pivot has pivotSize candidates (2 for XY-Wing, 3 for XYZ-Wing)
+func (s *Service) findWing(c *CandidateGrid, pivotSize int, name string) *Deduction {
+	var bivalue []Cell
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if c.Count(i, j) == 2 {
+				bivalue = append(bivalue, Cell{Row: i, Col: j})
+			}
+		}
+	}
+
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if c.Count(i, j) != pivotSize {
+				continue
+			}
+			pivot := Cell{Row: i, Col: j}
+			pivotMask := c[i][j]
+
+			for x, a := range bivalue {
+				for _, b := range bivalue[x+1:] {
+					if a == pivot || b == pivot || !sees(pivot, a) || !sees(pivot, b) {
+						continue
+					}
+					maskA, maskB := c[a.Row][a.Col], c[b.Row][b.Col]
+					// The pincers share exactly one value z and together cover the pivot
+					shared := maskA & maskB
+					if maskA == maskB || bits.OnesCount16(shared) != 1 {
+						continue
+					}
+					if pivotSize == 2 && (shared&pivotMask != 0 || (maskA|maskB)&^shared != pivotMask) {
+						continue
+					}
+					if pivotSize == 3 && (maskA|maskB) != pivotMask {
+						continue
+					}
+					z := maskValues(shared)[0]
+
+					wing := []Cell{a, b}
+					if pivotSize == 3 {
+						wing = append(wing, pivot)
+					}
+					var eliminations []Elimination
+					for r := 0; r < 9; r++ {
+						for col := 0; col < 9; col++ {
+							cell := Cell{Row: r, Col: col}
+							if cell == pivot || contains(wing, cell) || !c.Has(r, col, z) {
+								continue
+							}
+							if allSee(cell, wing) {
+								eliminations = append(eliminations, Elimination{Row: r, Col: col, Values: []int{z}})
+							}
+						}
+					}
+					if len(eliminations) > 0 {
+						return &Deduction{
+							Technique:    name,
+							Reason:       fmt.Sprintf("%s: the pivot %v and its pincers force a %d into the wing, so cells seeing it lose %d", name, maskValues(pivotMask), z, z),
+							Cells:        []Cell{pivot, a, b},
+							Eliminations: eliminations,
+							Wing:         &Wing{Pivot: pivot, Pincers: []Cell{a, b}, Value: z},
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Swordfish: the X-Wing idea over three rows and three columns
+func (s *Service) findSwordfish(c *CandidateGrid) *Deduction {
+	return s.findFish(c, 3, "Swordfish")
+}
+
+// Jellyfish: the X-Wing idea over four rows and four columns
+func (s *Service) findJellyfish(c *CandidateGrid) *Deduction {
+	return s.findFish(c, 4, "Jellyfish")
+}
+
+// Find size base lines (rows, then columns) whose places for a value fall into size cover lines
+func (s *Service) findFish(c *CandidateGrid, size int, name string) *Deduction {
+	for _, byRow := range []bool{true, false} {
+		baseKind, coverKind := "rows", "columns"
+		if !byRow {
+			baseKind, coverKind = "columns", "rows"
+		}
+		// Cell at position cover of base line base
+		at := func(base, cover int) Cell {
+			if byRow {
+				return Cell{Row: base, Col: cover}
+			}
+			return Cell{Row: cover, Col: base}
+		}
+
+		for value := 1; value <= 9; value++ {
+			// Base lines where the value has between 2 and size places, as a bitmask of cover positions
+			var bases []int
+			var positions [9]uint16
+			for base := 0; base < 9; base++ {
+				for cover := 0; cover < 9; cover++ {
+					cell := at(base, cover)
+					if c.Has(cell.Row, cell.Col, value) {
+						positions[base] |= 1 << cover
+					}
+				}
+				if n := bits.OnesCount16(positions[base]); n >= 2 && n <= size {
+					bases = append(bases, base)
+				}
+			}
+
+			for _, combo := range combinations(len(bases), size) {
+				var covers uint16
+				var baseLines []int
+				for _, index := range combo {
+					covers |= positions[bases[index]]
+					baseLines = append(baseLines, bases[index])
+				}
+				if bits.OnesCount16(covers) != size {
+					continue
+				}
+
+				var pattern []Cell
+				var eliminations []Elimination
+				for cover := 0; cover < 9; cover++ {
+					if covers&(1<<cover) == 0 {
+						continue
+					}
+					for base := 0; base < 9; base++ {
+						cell := at(base, cover)
+						if !c.Has(cell.Row, cell.Col, value) {
+							continue
+						}
+						if containsInt(baseLines, base) {
+							pattern = append(pattern, cell)
+						} else {
+							eliminations = append(eliminations, Elimination{Row: cell.Row, Col: cell.Col, Values: []int{value}})
+						}
+					}
+				}
+				if len(eliminations) > 0 {
+					return &Deduction{
+						Technique: name,
+						Reason: fmt.Sprintf("%s on %d in %s %s: %d must sit in %s %s of those %s, so the rest of those %s can't hold %d",
+							name, value, baseKind, lineNumbers(baseLines), value, coverKind, lineNumbers(maskLines(covers)), baseKind, coverKind, value),
+						Cells:        pattern,
+						Eliminations: eliminations,
+						Fish: &Fish{
+							Value:      value,
+							BaseKind:   baseKind,
+							BaseLines:  baseLines,
+							CoverLines: maskLines(covers),
+						},
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Unique Rectangle: a rectangle spanning two boxes must never end up with all four corners
+// holding only {a,b}, otherwise the puzzle would have two solutions.
+//   - Type 1: three corners hold only {a,b}, so a and b can be removed from the fourth corner.
+//   - Type 2: two corners hold only {a,b} and the other two hold {a,b,x}. One of those two must
+//     be x, so cells seeing both of them lose x.
+func (s *Service) findUniqueRectangle(c *CandidateGrid) *Deduction {
+	for _, rect := range rectangles() {
+		if d := uniqueRectangleType1(c, rect); d != nil {
+			return d
+		}
+	}
+	for _, rect := range rectangles() {
+		if d := uniqueRectangleType2(c, rect); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+func uniqueRectangleType1(c *CandidateGrid, rect [4]Cell) *Deduction {
+	var pairs, extras []Cell
+	var pairMask uint16
+	for _, cell := range rect {
+		mask := c[cell.Row][cell.Col]
+		switch {
+		case bits.OnesCount16(mask) == 2 && (pairMask == 0 || pairMask == mask):
+			pairMask = mask
+			pairs = append(pairs, cell)
+		case bits.OnesCount16(mask) > 2:
+			extras = append(extras, cell)
+		default:
+			return nil
+		}
+	}
+	if len(pairs) != 3 || len(extras) != 1 {
+		return nil
+	}
+
+	target := extras[0]
+	if c[target.Row][target.Col]&pairMask != pairMask {
+		return nil
+	}
+	values := maskValues(pairMask)
+	return &Deduction{
+		Technique: "Unique Rectangle",
+		Reason: fmt.Sprintf("Unique Rectangle on %v: r%dc%d cannot be %d or %d without creating a deadly pattern",
+			values, target.Row+1, target.Col+1, values[0], values[1]),
+		Cells:        rect[:],
+		Eliminations: []Elimination{{Row: target.Row, Col: target.Col, Values: values}},
+	}
+}
+
+func uniqueRectangleType2(c *CandidateGrid, rect [4]Cell) *Deduction {
+	var floor, roof []Cell
+	for _, cell := range rect {
+		switch bits.OnesCount16(c[cell.Row][cell.Col]) {
+		case 2:
+			floor = append(floor, cell)
+		case 3:
+			roof = append(roof, cell)
+		default:
+			return nil
+		}
+	}
+	if len(floor) != 2 || len(roof) != 2 {
+		return nil
+	}
+
+	pairMask := c[floor[0].Row][floor[0].Col]
+	roofMask := c[roof[0].Row][roof[0].Col]
+	if c[floor[1].Row][floor[1].Col] != pairMask || c[roof[1].Row][roof[1].Col] != roofMask || roofMask&pairMask != pairMask {
+		return nil
+	}
+	extra := maskValues(roofMask &^ pairMask)[0]
+
+	var eliminations []Elimination
+	for r := 0; r < 9; r++ {
+		for col := 0; col < 9; col++ {
+			cell := Cell{Row: r, Col: col}
+			if c.Has(r, col, extra) && !contains(rect[:], cell) && allSee(cell, roof) {
+				eliminations = append(eliminations, Elimination{Row: r, Col: col, Values: []int{extra}})
+			}
+		}
+	}
+	if len(eliminations) == 0 {
+		return nil
+	}
+
+	values := maskValues(pairMask)
+	return &Deduction{
+		Technique: "Unique Rectangle",
+		Reason: fmt.Sprintf("Unique Rectangle (type 2) on %v: r%dc%d or r%dc%d must be %d to avoid a deadly pattern, so cells seeing both lose %d",
+			values, roof[0].Row+1, roof[0].Col+1, roof[1].Row+1, roof[1].Col+1, extra, extra),
+		Cells:        rect[:],
+		Eliminations: eliminations,
+	}
+}
+
+// FindDeadlyPattern returns the four corners of a rectangle spanning two boxes whose
+// cells all hold the same two candidates. Such a pattern can be solved both ways, so
+// a board containing one has more than one solution.
+func (s *Service) FindDeadlyPattern(board Board) []Cell {
+	c := s.ComputeCandidates(board)
+	for _, rect := range rectangles() {
+		mask := c[rect[0].Row][rect[0].Col]
+		if bits.OnesCount16(mask) != 2 {
+			continue
+		}
+		deadly := true
+		for _, cell := range rect[1:] {
+			if c[cell.Row][cell.Col] != mask {
+				deadly = false
+				break
+			}
+		}
+		if deadly {
+			return rect[:]
+		}
+	}
+	return nil
+}
+
+// Simple Coloring: the conjugate pairs of a digit form a chain whose cells alternate between
+// two colors, exactly one of which is true. A color that sees itself is false (color wrap), and a
+// cell that sees both colors cannot hold the digit (color trap).
+func (s *Service) findSimpleColoring(c *CandidateGrid) *Deduction {
+	for value := 1; value <= 9; value++ {
+		links := make(map[Cell][]Cell)
+		for _, unit := range allUnits() {
+			var found []Cell
+			for _, cell := range unit.cells {
+				if c.Has(cell.Row, cell.Col, value) {
+					found = append(found, cell)
+				}
+			}
+			if len(found) == 2 {
+				links[found[0]] = append(links[found[0]], found[1])
+				links[found[1]] = append(links[found[1]], found[0])
+			}
+		}
+
+		for _, component := range colorComponents(links) {
+			chain := append(append([]Cell{}, component[0]...), component[1]...)
+			if len(chain) < 4 {
+				continue
+			}
+
+			// Color wrap
+			for color, cells := range component {
+				if hasMutualSight(cells) {
+					var eliminations []Elimination
+					for _, cell := range cells {
+						eliminations = append(eliminations, Elimination{Row: cell.Row, Col: cell.Col, Values: []int{value}})
+					}
+					return &Deduction{
+						Technique:    "Simple Coloring",
+						Reason:       fmt.Sprintf("Simple Coloring on %d: two cells of color %d see each other, so that color is false", value, color+1),
+						Cells:        chain,
+						Eliminations: eliminations,
+						Chain:        newChain(value, component, links),
+					}
+				}
+			}
+
+			// Color trap
+			var eliminations []Elimination
+			for i := 0; i < 9; i++ {
+				for j := 0; j < 9; j++ {
+					cell := Cell{Row: i, Col: j}
+					if !c.Has(i, j, value) || contains(chain, cell) {
+						continue
+					}
+					if seesAny(cell, component[0]) && seesAny(cell, component[1]) {
+						eliminations = append(eliminations, Elimination{Row: i, Col: j, Values: []int{value}})
+					}
+				}
+			}
+			if len(eliminations) > 0 {
+				return &Deduction{
+					Technique:    "Simple Coloring",
+					Reason:       fmt.Sprintf("Simple Coloring on %d: cells that see both colors of the chain cannot be %d", value, value),
+					Cells:        chain,
+					Eliminations: eliminations,
+					Chain:        newChain(value, component, links),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Remote Pairs: a chain of bivalue cells sharing the same pair {a,b} alternates between a and b,
+// so a cell seeing two chain cells of opposite color cannot hold a or b.
+func (s *Service) findRemotePairs(c *CandidateGrid) *Deduction {
+	seen := make(map[uint16]bool)
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			mask := c[i][j]
+			if bits.OnesCount16(mask) != 2 || seen[mask] {
+				continue
+			}
+			seen[mask] = true
+
+			var pairCells []Cell
+			for r := 0; r < 9; r++ {
+				for col := 0; col < 9; col++ {
+					if c[r][col] == mask {
+						pairCells = append(pairCells, Cell{Row: r, Col: col})
+					}
+				}
+			}
+			links := make(map[Cell][]Cell)
+			for x, a := range pairCells {
+				for _, b := range pairCells[x+1:] {
+					if sees(a, b) {
+						links[a] = append(links[a], b)
+						links[b] = append(links[b], a)
+					}
+				}
+			}
+
+			values := maskValues(mask)
+			for _, component := range colorComponents(links) {
+				chain := append(append([]Cell{}, component[0]...), component[1]...)
+				if len(chain) < 4 {
+					continue
+				}
+				var eliminations []Elimination
+				for r := 0; r < 9; r++ {
+					for col := 0; col < 9; col++ {
+						cell := Cell{Row: r, Col: col}
+						if c[r][col]&mask == 0 || contains(chain, cell) {
+							continue
+						}
+						if seesAny(cell, component[0]) && seesAny(cell, component[1]) {
+							eliminations = append(eliminations, Elimination{Row: r, Col: col, Values: maskValues(c[r][col] & mask)})
+						}
+					}
+				}
+				if len(eliminations) > 0 {
+					return &Deduction{
+						Technique:    "Remote Pairs",
+						Reason:       fmt.Sprintf("Remote Pairs on %v: the chain alternates between %d and %d, so cells seeing both ends lose them", values, values[0], values[1]),
+						Cells:        chain,
+						Eliminations: eliminations,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// allSee reports whether cell sees every one of cells
+func allSee(cell Cell, cells []Cell) bool {
+	for _, other := range cells {
+		if !sees(cell, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Indexes of the set bits of a line mask
+func maskLines(mask uint16) []int {
+	var lines []int
+	for i := 0; i < 9; i++ {
+		if mask&(1<<i) != 0 {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
+
+// Format line indexes the way players count them, e.g. "2, 5 and 8"
+func lineNumbers(lines []int) string {
+	var parts []string
+	for _, line := range lines {
+		parts = append(parts, fmt.Sprint(line+1))
+	}
+	if len(parts) < 2 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// combinations lists every way to choose k of the indexes 0..n-1, in ascending order
+func combinations(n, k int) [][]int {
+	var result [][]int
+	combo := make([]int, 0, k)
+	var pick func(start int)
+	pick = func(start int) {
+		if len(combo) == k {
+			result = append(result, append([]int{}, combo...))
+			return
+		}
+		for i := start; i <= n-(k-len(combo)); i++ {
+			combo = append(combo, i)
+			pick(i + 1)
+			combo = combo[:len(combo)-1]
+		}
+	}
+	pick(0)
+	return result
+}
+
+// colorComponents two-colors every connected component of a link graph. Components that
+// cannot be two-colored are skipped.
+func colorComponents(links map[Cell][]Cell) [][2][]Cell {
+	var components [][2][]Cell
+	color := make(map[Cell]int)
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			start := Cell{Row: i, Col: j}
+			if _, done := color[start]; done || len(links[start]) == 0 {
+				continue
+			}
+
+			var component [2][]Cell
+			bipartite := true
+			color[start] = 0
+			queue := []Cell{start}
+			for len(queue) > 0 {
+				cell := queue[0]
+				queue = queue[1:]
+				component[color[cell]] = append(component[color[cell]], cell)
+				for _, next := range links[cell] {
+					if nc, ok := color[next]; ok {
+						if nc == color[cell] {
+							bipartite = false
+						}
+						continue
+					}
+					color[next] = 1 - color[cell]
+					queue = append(queue, next)
+				}
+			}
+			if bipartite {
+				components = append(components, component)
+			}
+		}
+	}
+	return components
+}
+
+// newChain collects the links of a colored component. Every link joins the two colors, so
+// walking the first color finds them all; a pair linked in two units is listed once.
+func newChain(value int, component [2][]Cell, links map[Cell][]Cell) *Chain {
+	chain := &Chain{Value: value, Colors: component}
+	listed := make(map[[2]Cell]bool)
+	for _, cell := range component[0] {
+		for _, next := range links[cell] {
+			if !listed[[2]Cell{cell, next}] {
+				listed[[2]Cell{cell, next}] = true
+				chain.Links = append(chain.Links, [2]Cell{cell, next})
+			}
+		}
+	}
+	return chain
+}
+
+// sees reports whether two different cells share a row, column or box
+func sees(a, b Cell) bool {
+	if a == b {
+		return false
+	}
+	return a.Row == b.Row || a.Col == b.Col || (a.Row/3 == b.Row/3 && a.Col/3 == b.Col/3)
+}
+
+func seesAny(cell Cell, cells []Cell) bool {
+	for _, other := range cells {
+		if sees(cell, other) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMutualSight(cells []Cell) bool {
+	for i, a := range cells {
+		if seesAny(a, cells[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(cells []Cell, cell Cell) bool {
+	for _, other := range cells {
+		if other == cell {
+			return true
+		}
+	}
+	return false
+}
+
+// unit is a row, column or box of the board
+type unit struct {
+	kind  string
+	cells []Cell
+}
+
+func allUnits() []unit {
+	var units []unit
+	for i := 0; i < 9; i++ {
+		row := unit{kind: "Row"}
+		col := unit{kind: "Column"}
+		box := unit{kind: "Box"}
+		for j := 0; j < 9; j++ {
+			row.cells = append(row.cells, Cell{Row: i, Col: j})
+			col.cells = append(col.cells, Cell{Row: j, Col: i})
+			box.cells = append(box.cells, Cell{Row: (i/3)*3 + j/3, Col: (i%3)*3 + j%3})
+		}
+		units = append(units, row, col, box)
+	}
+	return units
+}
+
+// lineUnits returns the rows and columns
+func lineUnits() []unit {
+	var lines []unit
+	for _, u := range allUnits() {
+		if u.kind != "Box" {
+			lines = append(lines, u)
+		}
+	}
+	return lines
+}
+
+// allIn reports whether every cell lies in the unit
+func allIn(cells []Cell, u unit) bool {
+	for _, cell := range cells {
+		if !contains(u.cells, cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// rectangles returns every rectangle whose corners lie in exactly two boxes
+func rectangles() [][4]Cell {
+	var rects [][4]Cell
+	for r1 := 0; r1 < 9; r1++ {
+		for r2 := r1 + 1; r2 < 9; r2++ {
+			for c1 := 0; c1 < 9; c1++ {
+				for c2 := c1 + 1; c2 < 9; c2++ {
+					sameBand := r1/3 == r2/3
+					sameStack := c1/3 == c2/3
+					if sameBand == sameStack {
+						continue
+					}
+					rects = append(rects, [4]Cell{{r1, c1}, {r1, c2}, {r2, c1}, {r2, c2}})
+				}
+			}
+		}
+	}
+	return rects
+}
+
+func isFull(board Board) bool {
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package sudoku
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Levels of a tiered hint. Each level gives away more of the same move.
+const (
+	TechniqueLevel = 1 // The technique and the house to look in
+	CellLevel      = 2 // The cell the technique fills
+	ValueLevel     = 3 // The cell filled in
+)
+
+// Points deducted from a Play mode score for every hint level consumed
+const HintLevelPenalty = 5
+
+// TieredHint is a hint revealed up to a level. Fields beyond the level are left empty.
+type TieredHint struct {
+	Level       int          `json:"level"`
+	Text        string       `json:"text"`
+	Technique   string       `json:"technique"`
+	Region      *UnitRef     `json:"region,omitempty"`
+	Cell        *Cell        `json:"cell,omitempty"`
+	Value       int          `json:"value,omitempty"`
+	Reason      string       `json:"reason,omitempty"`
+	Highlight   *Highlight   `json:"highlight,omitempty"`
+	Explanation *Explanation `json:"explanation,omitempty"` // Set on Learn mode hints at the value level
+}
+
+// MoveTechnique names the technique that found a move, without the house it was found in
+func MoveTechnique(move *Move) string {
+	if len(move.Deductions) > 0 {
+		return move.Deductions[0].Technique
+	}
+	return strings.SplitN(move.Reason, " in ", 2)[0]
+}
+
+// TierMove reveals a move up to the given level: "there's a hidden single in box 5",
+// then the cell, then its value
+func TierMove(move *Move, level int) TieredHint {
+	technique := MoveTechnique(move)
+	focus := []Cell{{Row: move.Row, Col: move.Col}}
+	if len(move.Deductions) > 0 {
+		focus = move.Deductions[0].Cells
+	}
+
+	hint := TieredHint{Level: level, Technique: technique}
+	region, _ := focusUnit(move, focus)
+	if region != nil && technique != "Advanced Step" {
+		hint.Region = region
+		hint.Text = fmt.Sprintf("There's a %s in %s %d.", technique, strings.ToLower(region.Kind), region.Index+1)
+		hint.Highlight = &Highlight{Outline: []Cell{}, Strike: []Elimination{}, Shade: []UnitRef{*region}}
+	} else {
+		hint.Text = "The next step can't be found with the techniques the hints know; try the next level."
+	}
+	if level == TechniqueLevel {
+		return hint
+	}
+
+	hint.Cell = &Cell{Row: move.Row, Col: move.Col}
+	hint.Highlight = HighlightMove(move)
+	hint.Text = fmt.Sprintf("The %s fills row %d, column %d.", technique, move.Row+1, move.Col+1)
+	if level == CellLevel {
+		return hint
+	}
+
+	hint.Value = move.Value
+	hint.Reason = move.Reason
+	hint.Text = fmt.Sprintf("Row %d, column %d is %d (%s).", move.Row+1, move.Col+1, move.Value, move.Reason)
+	return hint
+}
+
+// HintPenalty is the score deduction for the hint levels consumed in a game
+func HintPenalty(levels int) int {
+	return levels * HintLevelPenalty
+}
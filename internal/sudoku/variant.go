@@ -0,0 +1,403 @@
+package sudoku
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"math/rand"
+
+	"sudoku/internal/models"
+)
+
+// Rules are the constraints a variant adds to the classic row, column and box rules.
+// The zero value is classic Sudoku.
+type Rules struct {
+	Cages     []Cage    // Killer: the digits of every cage differ and add up to its sum
+	Diagonals bool      // X-Sudoku: both long diagonals hold every digit once
+	Regions   *Regions  // Jigsaw: irregular regions taking the place of the 3x3 boxes
+	Parities  *Parities // Even/Odd: marked cells only take digits of their parity
+
+	// Chess constraints, which can be added to any variant
+	AntiKnight bool // Cells a knight's move apart never hold the same digit
+	AntiKing   bool // Nor do cells a king's move apart, so diagonal neighbours differ too
+
+	Extra []Constraint // Constraints of variants without a field of their own
+}
+
+// Constraints lists the constraints making up the rules, the row, column and box rule first
+func (r Rules) Constraints() []Constraint {
+	constraints := []Constraint{RowColBox{Regions: r.Regions}}
+	if r.Diagonals {
+		constraints = append(constraints, Diagonal{})
+	}
+	if len(r.Cages) > 0 {
+		constraints = append(constraints, Cages(r.Cages))
+	}
+	if r.Parities != nil {
+		constraints = append(constraints, r.Parities)
+	}
+	if r.AntiKnight {
+		constraints = append(constraints, AntiKnight{})
+	}
+	if r.AntiKing {
+		constraints = append(constraints, AntiKing{})
+	}
+	return append(constraints, r.Extra...)
+}
+
+// Placements one uniqueness check of a variant generator may try. Layouts needing more are
+// treated as ambiguous, which keeps generation quick.
+const variantCheckNodes = 100_000
+
+// variantSearch is the bitmask search under a variant's rules: the candidates of a cell are
+// the digits every constraint's tracker still allows
+type variantSearch struct {
+	trackers []Tracker
+}
+
+// Set up the search for a board, reporting false when its digits already break the rules
+func newVariantSearch(board *Board, rules Rules) (*variantSearch, bool) {
+	v := &variantSearch{}
+	for _, constraint := range rules.Constraints() {
+		tracker, ok := constraint.Track(board)
+		if !ok {
+			return nil, false
+		}
+		v.trackers = append(v.trackers, tracker)
+	}
+	return v, true
+}
+
+// Candidates of an empty cell
+func (v *variantSearch) cellCandidates(row, col int) uint16 {
+	candidates := allDigits
+	for _, tracker := range v.trackers {
+		if candidates &= tracker.Candidates(row, col); candidates == 0 {
+			break
+		}
+	}
+	return candidates
+}
+
+func (v *variantSearch) place(board *Board, row, col, value int) {
+	board[row][col] = value
+	for _, tracker := range v.trackers {
+		tracker.Place(row, col, value)
+	}
+}
+
+func (v *variantSearch) unplace(board *Board, row, col, value int) {
+	board[row][col] = 0
+	for _, tracker := range v.trackers {
+		tracker.Unplace(row, col, value)
+	}
+}
+
+// Like masks.nextCell, with candidates narrowed by the variant's rules
+func (v *variantSearch) nextCell(board *Board) (row, col int, candidates uint16, ok bool) {
+	best := 10
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] != 0 {
+				continue
+			}
+			c := v.cellCandidates(i, j)
+			if n := bits.OnesCount16(c); n < best {
+				row, col, candidates, best = i, j, c, n
+				if n <= 1 {
+					return row, col, candidates, true
+				}
+			}
+		}
+	}
+	return row, col, candidates, best < 10
+}
+
+// Backtrack through the solutions, handing each to found until it returns true.
+// Reports whether the search was stopped, by found or the budget.
+func (v *variantSearch) search(board *Board, sr *search, found func(Board) bool) bool {
+	row, col, candidates, ok := v.nextCell(board)
+	if !ok {
+		return found(*board)
+	}
+	for ; candidates != 0; candidates &= candidates - 1 {
+		if sr.visit() {
+			return true
+		}
+		value := bits.TrailingZeros16(candidates)
+		v.place(board, row, col, value)
+		stop := v.search(board, sr, found)
+		v.unplace(board, row, col, value)
+		if stop {
+			return true
+		}
+	}
+	return false
+}
+
+// Same as masks.solveRandom, under the variant's rules. Some region layouts have no solution at
+// all, so the search gives up once sr's budget runs out.
+func (v *variantSearch) solveRandom(board *Board, rng *rand.Rand, sr *search) bool {
+	row, col, candidates, ok := v.nextCell(board)
+	if !ok {
+		return true
+	}
+	values := digits(candidates)
+	rng.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	for _, value := range values {
+		if sr.visit() {
+			return false
+		}
+		v.place(board, row, col, value)
+		if v.solveRandom(board, rng, sr) {
+			return true
+		}
+		v.unplace(board, row, col, value)
+	}
+	return false
+}
+
+// Digits used by the other cells in the row, column and region of a cell, like peerDigits
+func regionPeerDigits(board Board, row, col int, regions *Regions) uint16 {
+	if regions == nil {
+		return peerDigits(board, row, col)
+	}
+	var used uint16
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if (i == row || j == col || regions[i][j] == regions[row][col]) && (i != row || j != col) {
+				used |= 1 << board[i][j]
+			}
+		}
+	}
+	return used &^ 1
+}
+
+// IsValidVariantMove is IsValidMove under a variant's rules: every constraint has to fit the
+// value, judging by the other cells
+func (s *Service) IsValidVariantMove(board Board, row, col, value int, rules Rules) bool {
+	board[row][col] = 0
+	for _, constraint := range rules.Constraints() {
+		if !constraint.Fits(&board, row, col, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether value can join the digits of the cage on the board and leave its sum reachable by the
+// cells still empty
+func cageFits(board Board, cage Cage, value int) bool {
+	used := uint16(1) << value
+	left, empty := cage.Sum-value, -1 // The cell value goes in is empty
+	for _, cell := range cage.Cells {
+		v := board[cell.Row][cell.Col]
+		if v == 0 {
+			empty++
+			continue
+		}
+		if used&(1<<v) != 0 {
+			return false
+		}
+		used |= 1 << v
+		left -= v
+	}
+	if left < 0 || left > 45 {
+		return false
+	}
+	for _, set := range digitSets[empty][left] {
+		if set&used == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVariantCandidates is GetCandidates under a variant's rules
+func (s *Service) GetVariantCandidates(board Board, row, col int, rules Rules) []int {
+	if board[row][col] != 0 {
+		return []int{}
+	}
+	grid := s.VariantCandidates(board, rules)
+	return digits(grid[row][col])
+}
+
+// VariantCandidates computes the candidates of every empty cell under a variant's rules, like
+// ComputeCandidates. A board already breaking the rules has none.
+func (s *Service) VariantCandidates(board Board, rules Rules) CandidateGrid {
+	var grid CandidateGrid
+	v, ok := newVariantSearch(&board, rules)
+	if !ok {
+		return grid
+	}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 {
+				grid[i][j] = v.cellCandidates(i, j)
+			}
+		}
+	}
+	return grid
+}
+
+// SolveVariant solves a board under a variant's rules, within the service's solve budget
+func (s *Service) SolveVariant(ctx context.Context, board Board, rules Rules) (Board, error) {
+	solved := board
+	v, ok := newVariantSearch(&solved, rules)
+	if !ok {
+		return board, ErrUnsolvable
+	}
+
+	var solution *Board
+	sr := newSearch(ctx, s.budget)
+	v.search(&solved, sr, func(b Board) bool {
+		solution = &b
+		return true
+	})
+	if solution != nil {
+		return *solution, nil
+	}
+	if sr.err != nil {
+		return board, sr.err
+	}
+	return board, ErrUnsolvable
+}
+
+// CountVariantSolutions returns the number of solutions of a board under a variant's rules,
+// stopping once limit is reached, within the service's solve budget
+func (s *Service) CountVariantSolutions(ctx context.Context, board Board, rules Rules, limit int) (int, error) {
+	v, ok := newVariantSearch(&board, rules)
+	if !ok {
+		return 0, nil
+	}
+	count := 0
+	sr := newSearch(ctx, s.budget)
+	v.search(&board, sr, func(Board) bool {
+		count++
+		return count >= limit
+	})
+	return count, sr.err
+}
+
+// VariantHint gives the value of an empty cell under a variant's rules, like GetHint
+func (s *Service) VariantHint(ctx context.Context, board Board, rules Rules, row, col int) (*Move, error) {
+	if board[row][col] != 0 {
+		return nil, errors.New("cell is already filled")
+	}
+	solution, err := s.SolveVariant(ctx, board, rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Move{Row: row, Col: col, Value: solution[row][col], Reason: "Hint"}, nil
+}
+
+// VariantSteps fills the next count empty cells of a board from its solution under a variant's
+// rules. The human techniques don't know about them, so every move is an "Advanced Step".
+func (s *Service) VariantSteps(ctx context.Context, board Board, rules Rules, count int) ([]Move, error) {
+	solution, err := s.SolveVariant(ctx, board, rules)
+	if err != nil {
+		return nil, err
+	}
+	moves := []Move{}
+	for len(moves) < count && !isFull(board) {
+		move := firstEmptyMove(board, solution)
+		moves = append(moves, *move)
+		board[move.Row][move.Col] = move.Value
+	}
+	return moves, nil
+}
+
+// VariantPath is SolvePath under a variant's rules
+func (s *Service) VariantPath(ctx context.Context, board Board, rules Rules) (*Path, error) {
+	moves, err := s.VariantSteps(ctx, board, rules, 81)
+	if err != nil {
+		return nil, err
+	}
+	path := &Path{Moves: moves, Techniques: []string{}}
+	if len(moves) > 0 {
+		path.Techniques = append(path.Techniques, moves[0].Reason)
+	}
+	return path, nil
+}
+
+// FindVariantConflicts is FindConflicts under a variant's rules. Repeats on a diagonal, in a
+// cage or a chess move apart count as duplicates, and a cell whose cage sum can no longer be made is dead.
+func (s *Service) FindVariantConflicts(ctx context.Context, board Board, rules Rules) (*Conflicts, error) {
+	conflicts := &Conflicts{Duplicates: []Cell{}, Dead: []Cell{}}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 {
+				continue
+			}
+			without := board
+			without[i][j] = 0
+			if !s.IsValidVariantMove(without, i, j, board[i][j], rules) {
+				conflicts.Duplicates = append(conflicts.Duplicates, Cell{Row: i, Col: j})
+			}
+		}
+	}
+	if len(conflicts.Duplicates) > 0 {
+		return conflicts, nil
+	}
+
+	v, ok := newVariantSearch(&board, rules)
+	if !ok {
+		return conflicts, nil
+	}
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 && v.cellCandidates(i, j) == 0 {
+				conflicts.Dead = append(conflicts.Dead, Cell{Row: i, Col: j})
+			}
+		}
+	}
+	if len(conflicts.Dead) > 0 {
+		return conflicts, nil
+	}
+
+	_, err := s.SolveVariant(ctx, board, rules)
+	if err != nil && !errors.Is(err, ErrUnsolvable) {
+		return nil, err
+	}
+	conflicts.Solvable = err == nil
+	return conflicts, nil
+}
+
+// Remove given digits down to target while the rules keep the solution unique, returning the
+// puzzle and how many digits are still given
+func carveVariant(solved Board, rules Rules, target int, symmetry models.Symmetry, rng *rand.Rand) (Board, int) {
+	puzzle := solved
+	givens := 81
+	budget := SolveBudget{MaxNodes: variantCheckNodes}
+	for _, pos := range rng.Perm(81) {
+		if givens <= target {
+			break
+		}
+		if puzzle[pos/9][pos%9] == 0 {
+			continue
+		}
+		backup := puzzle
+		removed := 0
+		for _, p := range symmetricCells(pos, symmetry) {
+			if puzzle[p/9][p%9] != 0 {
+				puzzle[p/9][p%9] = 0
+				removed++
+			}
+		}
+
+		temp := puzzle
+		v, _ := newVariantSearch(&temp, rules)
+		count := 0
+		sr := newSearch(context.Background(), budget)
+		v.search(&temp, sr, func(Board) bool {
+			count++
+			return count >= 2
+		})
+		if count != 1 || sr.err != nil {
+			puzzle = backup
+		} else {
+			givens -= removed
+		}
+	}
+	return puzzle, givens
+}
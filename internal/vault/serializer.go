@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+}
+
+// Serializer encrypts string columns tagged `gorm:"serializer:encrypted"` with the configured
+// keyring. Plain values written before encryption was enabled are read as they are, so
+// existing rows keep working until the migration encrypts them.
+type Serializer struct{}
+
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted column %s", dbValue, field.DBName)
+	}
+
+	value, err := Reveal(field, value)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Reveal decrypts a value of an encrypted column read without the serializer, such as through a
+// map or raw query. Plain values are returned as they are.
+func Reveal(field *schema.Field, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	k := configured()
+	if k == nil {
+		return "", ErrNotConfigured
+	}
+	plaintext, err := k.Decrypt(value, columnContext(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %w", field.DBName, err)
+	}
+	return plaintext, nil
+}
+
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted column %s must be a string", field.DBName)
+	}
+	k := configured()
+	if k == nil {
+		return value, nil
+	}
+	return k.Encrypt(value, columnContext(field))
+}
+
+// Ciphertexts are bound to their table and column
+func columnContext(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
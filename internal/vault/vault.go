@@ -0,0 +1,207 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Encrypted values are stored as "enc:<key id>:<base64 of nonce and sealed text>"
+const prefix = "enc:"
+
+var (
+	ErrUnknownKey    = errors.New("value was encrypted with a key that is not configured")
+	ErrNotConfigured = errors.New("encrypted value found but no encryption keys are configured")
+
+	keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,32}$`)
+)
+
+// Keyring holds the AES-GCM keys of sensitive columns. New values are encrypted with the current
+// key, older keys are kept to read values written before a rotation.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseKeys reads a keyring from "id:base64key,id:base64key"; the first key is the current one.
+// Keys are 32 bytes (AES-256).
+func ParseKeys(spec string) (*Keyring, error) {
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key entry %q, use id:base64key with a letter, digit or dash id", id)
+		}
+		if _, exists := k.keys[id]; exists {
+			return nil, fmt.Errorf("key %q is listed twice", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes of base64", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[id] = aead
+		if k.current == "" {
+			k.current = id
+		}
+	}
+	if k.current == "" {
+		return nil, errors.New("no encryption keys given")
+	}
+	return k, nil
+}
+
+// LoadEnv reads the keyring from ENCRYPTION_KEYS, or from the file named by ENCRYPTION_KEYS_FILE
+// (as written by a KMS or secrets manager agent). It returns nil when no keys are configured,
+// leaving sensitive columns in plain text.
+func LoadEnv() (*Keyring, error) {
+	spec := os.Getenv("ENCRYPTION_KEYS")
+	if path := os.Getenv("ENCRYPTION_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		spec = string(data)
+	}
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	return ParseKeys(spec)
+}
+
+// LoadIndexKey reads the blind index key from ENCRYPTION_INDEX_KEY, base64 of at least 32 bytes.
+// It is required even without encryption keys, since an unkeyed hash of an email can be reversed
+// by hashing guesses.
+func LoadIndexKey() ([]byte, error) {
+	value := os.Getenv("ENCRYPTION_INDEX_KEY")
+	if value == "" {
+		return nil, errors.New("ENCRYPTION_INDEX_KEY is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("ENCRYPTION_INDEX_KEY must be base64")
+	}
+	if len(key) < 32 {
+		return nil, errors.New("ENCRYPTION_INDEX_KEY must be at least 32 bytes")
+	}
+	return key, nil
+}
+
+// Current is the id of the key new values are encrypted with
+func (k *Keyring) Current() string {
+	return k.current
+}
+
+// Encrypt seals plaintext with the current key. context binds the value to where it is stored,
+// so a ciphertext copied into another column fails to decrypt.
+func (k *Keyring) Encrypt(plaintext, context string) (string, error) {
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(context))
+	return prefix + k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value written by Encrypt with any key of the ring
+func (k *Keyring) Decrypt(value, context string) (string, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(context))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+var (
+	mu       sync.RWMutex
+	keyring  *Keyring
+	indexKey []byte
+)
+
+// Configure sets the keyring used by the "encrypted" column serializer and blind indexes.
+// With a nil keyring values are written in plain text.
+func Configure(k *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+	keyring = k
+}
+
+// ConfigureIndex sets the key of the blind indexes
+func ConfigureIndex(key []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	indexKey = key
+}
+
+func configured() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keyring
+}
+
+// Prefix is how values encrypted with the current key start, so rows still needing
+// encryption or rotation can be found with LIKE. Empty when no keys are configured.
+func Prefix() string {
+	k := configured()
+	if k == nil {
+		return ""
+	}
+	return prefix + k.current + ":"
+}
+
+// IsEncrypted reports whether a stored value is ciphertext rather than a legacy plain value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Index is the blind index of a sensitive value: an HMAC under the index key that lets rows be
+// looked up and kept unique by value without decrypting them. It is returned as a pointer for the
+// nullable index columns, which are empty on rows written before encryption was added. It panics
+// if ConfigureIndex hasn't been called.
+func Index(value string) *string {
+	mu.RLock()
+	key := indexKey
+	mu.RUnlock()
+	if key == nil {
+		panic("vault: blind index key is not configured")
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	index := hex.EncodeToString(mac.Sum(nil))
+	return &index
+}
@@ -0,0 +1,154 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"sudoku/internal/models"
+	"sudoku/internal/sudoku"
+)
+
+// Tokens a new account starts with, matching the default of User.LearnTokens
+const StartingTokens = 100
+
+// Transactions returned by History
+const historySize = 50
+
+// Price of a technique hint at the first level, by the rating of the technique it reveals. Every
+// further level costs as much again, so a value costs three times the technique.
+var techniquePrices = map[models.Difficulty]int{
+	models.Easy:   1,
+	models.Medium: 2,
+	models.Hard:   4,
+	models.Expert: 8,
+}
+
+// Tokens earned by solving a Learn mode puzzle without the auto-solver
+var rewards = map[models.Difficulty]int{
+	models.Easy:   5,
+	models.Medium: 10,
+	models.Hard:   20,
+	models.Expert: 40,
+}
+
+// InsufficientError is returned when a user can't afford a hint
+type InsufficientError struct {
+	Price   int
+	Balance int
+}
+
+func (e *InsufficientError) Error() string {
+	return fmt.Sprintf("this hint costs %d tokens and you have %d", e.Price, e.Balance)
+}
+
+// HintPrice is the cost of a tiered hint revealing technique up to level
+func HintPrice(technique string, level int) int {
+	return techniquePrices[sudoku.TechniqueDifficulty(technique)] * level
+}
+
+// EliminationPrice is the cost of an elimination hint, which shows the whole deduction
+func EliminationPrice(technique string) int {
+	return techniquePrices[sudoku.TechniqueDifficulty(technique)] * 2
+}
+
+// Service keeps the learn token wallets of users
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Spend takes a hint's price from the user's wallet and logs it. An *InsufficientError is
+// returned if the balance doesn't cover it.
+func (s *Service) Spend(spend models.TokenTransaction, price int) (*models.TokenTransaction, error) {
+	spend.Amount = -price
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND learn_tokens >= ?", spend.UserID, price).
+			Update("learn_tokens", gorm.Expr("learn_tokens - ?", price))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			balance, err := balance(tx, spend.UserID)
+			if err != nil {
+				return err
+			}
+			return &InsufficientError{Price: price, Balance: balance}
+		}
+		return record(tx, &spend)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &spend, nil
+}
+
+// Reward credits the user for solving a Learn mode puzzle of the difficulty
+func (s *Service) Reward(userID, gameResultID uint, difficulty models.Difficulty) (*models.TokenTransaction, error) {
+	reward := models.TokenTransaction{UserID: userID, GameResultID: &gameResultID, Amount: rewards[difficulty], Reason: models.LearnReward}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("learn_tokens", gorm.Expr("learn_tokens + ?", reward.Amount)).Error
+		if err != nil {
+			return err
+		}
+		return record(tx, &reward)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reward, nil
+}
+
+// Balance returns the user's current balance
+func (s *Service) Balance(userID uint) (int, error) {
+	return balance(s.db, userID)
+}
+
+// History returns the user's latest transactions, newest first
+func (s *Service) History(userID uint) ([]models.TokenTransaction, error) {
+	transactions := []models.TokenTransaction{}
+	err := s.db.Where("user_id = ?", userID).Order("id DESC").Limit(historySize).Find(&transactions).Error
+	return transactions, err
+}
+
+// Transfer moves the source's balance onto the target's, as part of an account merge in tx
+func Transfer(tx *gorm.DB, sourceID, targetID uint) error {
+	amount, err := balance(tx, sourceID)
+	if err != nil || amount == 0 {
+		return err
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", sourceID).Update("learn_tokens", 0).Error; err != nil {
+		return err
+	}
+	err = tx.Model(&models.User{}).Where("id = ?", targetID).Update("learn_tokens", gorm.Expr("learn_tokens + ?", amount)).Error
+	if err != nil {
+		return err
+	}
+	return record(tx, &models.TokenTransaction{UserID: targetID, Amount: amount, Reason: models.MergeTransfer})
+}
+
+// Log a transaction whose amount was just applied, with the balance it left
+func record(tx *gorm.DB, transaction *models.TokenTransaction) error {
+	var err error
+	if transaction.Balance, err = balance(tx, transaction.UserID); err != nil {
+		return err
+	}
+	return tx.Create(transaction).Error
+}
+
+func balance(db *gorm.DB, userID uint) (int, error) {
+	var user models.User
+	if err := db.Select("learn_tokens").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("user not found")
+		}
+		return 0, err
+	}
+	return user.LearnTokens, nil
+}
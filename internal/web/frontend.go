@@ -0,0 +1,61 @@
+package web
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Routes is the client-side router's pages (frontend/src/App.js). Browsers navigating to them
+// get the app even where an API route has the same path, like /leaderboard.
+var Routes = []string{"/", "/login", "/register", "/game", "/leaderboard", "/profile"}
+
+// Frontend serves the built frontend from assets in front of the API: page navigations to the
+// app's routes get index.html, files of the build are served as they are, and everything else
+// goes to the API.
+func Frontend(assets fs.FS, api http.Handler) http.Handler {
+	files := http.FileServer(http.FS(assets))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			api.ServeHTTP(w, r)
+			return
+		}
+
+		if isPage(r) {
+			serveIndex(w, r, assets)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if info, err := fs.Stat(assets, name); err == nil && !info.IsDir() {
+			files.ServeHTTP(w, r)
+			return
+		}
+		api.ServeHTTP(w, r)
+	})
+}
+
+// A browser navigating to one of the app's pages, as opposed to the app calling the API
+func isPage(r *http.Request) bool {
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return false
+	}
+	for _, route := range Routes {
+		if r.URL.Path == route {
+			return true
+		}
+	}
+	return false
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request, assets fs.FS) {
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		http.Error(w, "Frontend build has no index.html", http.StatusInternalServerError)
+		return
+	}
+	// The page names hashed bundles, so it must be fetched again after an upgrade
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(index)
+}
@@ -0,0 +1,125 @@
+package worksheet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"sudoku/internal/models"
+	"sudoku/internal/pdf"
+	"sudoku/internal/sudoku"
+)
+
+// Most puzzles a worksheet can hold
+const MaxPuzzles = 12
+
+const (
+	margin = 40.0
+
+	// Puzzle pages hold a 2x2 block of full size grids
+	puzzlesPerPage = 4
+	puzzleCell     = 26.0
+	// The answer key fits a 3x3 block of small grids per page
+	answersPerPage = 9
+	answerCell     = 16.0
+)
+
+// Puzzle is one exercise of a worksheet
+type Puzzle struct {
+	Difficulty models.Difficulty
+	Board      sudoku.Board
+	Solution   sudoku.Board
+}
+
+// Worksheet is a printable set of puzzles followed by an answer key
+type Worksheet struct {
+	Title   string
+	Puzzles []Puzzle
+}
+
+// Render writes the worksheet as a PDF: puzzle pages with a name and date header, then the answer key
+func Render(w io.Writer, sheet Worksheet) error {
+	doc := pdf.NewDocument()
+
+	for start := 0; start < len(sheet.Puzzles); start += puzzlesPerPage {
+		page := doc.AddPage()
+		top := header(page, sheet.Title, true)
+		for i := start; i < start+puzzlesPerPage && i < len(sheet.Puzzles); i++ {
+			slot := i - start
+			x, y := slotPosition(slot, 2, puzzleCell*9, top)
+			label := fmt.Sprintf("%d. %s", i+1, difficultyName(sheet.Puzzles[i].Difficulty))
+			page.Text(x, y-8, 12, pdf.HelveticaBold, label)
+			drawGrid(page, x, y, puzzleCell, sheet.Puzzles[i].Board, 14)
+		}
+	}
+
+	for start := 0; start < len(sheet.Puzzles); start += answersPerPage {
+		page := doc.AddPage()
+		top := header(page, sheet.Title+" - Answer Key", false)
+		for i := start; i < start+answersPerPage && i < len(sheet.Puzzles); i++ {
+			slot := i - start
+			x, y := slotPosition(slot, 3, answerCell*9, top)
+			page.Text(x, y-6, 10, pdf.HelveticaBold, fmt.Sprintf("%d.", i+1))
+			drawGrid(page, x, y, answerCell, sheet.Puzzles[i].Solution, 9)
+		}
+	}
+
+	return doc.Write(w)
+}
+
+// Draw the page title and, on puzzle pages, name and date lines. Returns where the grids start.
+func header(page *pdf.Page, title string, nameLines bool) float64 {
+	page.Text(margin, margin+18, 18, pdf.HelveticaBold, title)
+	if !nameLines {
+		return margin + 60
+	}
+
+	y := margin + 50
+	page.Text(margin, y, 11, pdf.Helvetica, "Name:")
+	page.Line(margin+36, y+2, margin+300, y+2, 0.5)
+	page.Text(margin+330, y, 11, pdf.Helvetica, "Date:")
+	page.Line(margin+362, y+2, pdf.PageWidth-margin, y+2, 0.5)
+	return y + 40
+}
+
+// Top-left corner of a grid slot in a columns x columns block spread over the page width
+func slotPosition(slot, columns int, gridSize, top float64) (float64, float64) {
+	gap := (pdf.PageWidth - 2*margin - float64(columns)*gridSize) / float64(columns-1)
+	row, col := slot/columns, slot%columns
+	return margin + float64(col)*(gridSize+gap), top + float64(row)*(gridSize+gap+10)
+}
+
+// Draw a grid with its given digits, thicker lines marking the boxes
+func drawGrid(page *pdf.Page, x, y, cell float64, board sudoku.Board, fontSize float64) {
+	size := cell * 9
+	for k := 0; k <= 9; k++ {
+		width := 0.5
+		if k%3 == 0 {
+			width = 1.5
+		}
+		offset := float64(k) * cell
+		page.Line(x, y+offset, x+size, y+offset, width)
+		page.Line(x+offset, y, x+offset, y+size, width)
+	}
+
+	digitWidth := pdf.DigitWidth * fontSize
+	for i := 0; i < 9; i++ {
+		for j := 0; j < 9; j++ {
+			if board[i][j] == 0 {
+				continue
+			}
+			// Center the digit; its cap height is about 0.7 of the font size
+			tx := x + float64(j)*cell + (cell-digitWidth)/2
+			ty := y + float64(i)*cell + (cell+0.7*fontSize)/2
+			page.Text(tx, ty, fontSize, pdf.Helvetica, fmt.Sprint(board[i][j]))
+		}
+	}
+}
+
+func difficultyName(difficulty models.Difficulty) string {
+	name := string(difficulty)
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -13,16 +16,55 @@ import (
 	"gorm.io/gorm"
 
 	"sudoku/internal/auth"
+	"sudoku/internal/blob"
+	"sudoku/internal/breaker"
+	"sudoku/internal/dataset"
+	"sudoku/internal/digest"
+	"sudoku/internal/event"
+	"sudoku/internal/expiry"
+	"sudoku/internal/goals"
 	"sudoku/internal/handlers"
+	"sudoku/internal/jobs"
+	"sudoku/internal/leaderboard"
+	"sudoku/internal/locale"
+	"sudoku/internal/mail"
+	"sudoku/internal/merge"
+	"sudoku/internal/migrate"
 	"sudoku/internal/models"
+	"sudoku/internal/moderation"
+	"sudoku/internal/ocr"
+	"sudoku/internal/pool"
+	"sudoku/internal/push"
+	"sudoku/internal/quota"
+	"sudoku/internal/race"
+	"sudoku/internal/replay"
+	"sudoku/internal/rivals"
+	"sudoku/internal/secrets"
+	"sudoku/internal/settings"
+	"sudoku/internal/slowlog"
+	"sudoku/internal/stats"
 	"sudoku/internal/sudoku"
+	"sudoku/internal/vault"
+	"sudoku/internal/wallet"
+	"sudoku/internal/web"
 )
 
+// Set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
+	// Secrets mounted as files, e.g. DATABASE_URL_FILE and JWT_SECRET_FILE
+	if err := secrets.LoadFiles(); err != nil {
+		log.Fatal("Failed to load secrets:", err)
+	}
+	configureTempDir()
+
+	// Encrypt sensitive columns when keys are configured
+	loadKeyring()
 
 	// Initialize database
 	db, err := initDB()
@@ -30,57 +72,300 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Auto-migrate models
-	if err := db.AutoMigrate(&models.User{}, &models.Puzzle{}, &models.GameResult{}); err != nil {
-		log.Fatal("Failed to migrate database:", err)
+	// Auto-migrate models, unless AUTO_MIGRATE leaves it to a separate release step
+	autoMigrate := loadAutoMigrate()
+	if autoMigrate != "false" {
+		migrateDB(db)
+	}
+	if autoMigrate == "only" {
+		log.Println("Database migrated, exiting as AUTO_MIGRATE=only")
+		return
+	}
+
+	// Lookups by email and device token need the stored blind indexes to match the index key
+	if err := migrate.CheckIndexes(db); err != nil {
+		log.Fatal("Failed to check blind indexes:", err)
+	}
+	if err := migrate.CheckComplete(db); err != nil {
+		log.Fatal("Failed to check blind indexes:", err)
+	}
+
+	// Log queries and handlers slower than the configured thresholds
+	slowThresholds := loadSlowThresholds()
+	if err := slowlog.Watch(db, slowThresholds.Query); err != nil {
+		log.Fatal("Failed to register slow query log:", err)
 	}
 
+	// Fail fast on non-critical endpoints while the database keeps erroring
+	dbBreaker := breaker.New(5, 30*time.Second)
+	if err := dbBreaker.Watch(db); err != nil {
+		log.Fatal("Failed to register circuit breaker:", err)
+	}
+	nonCritical := breaker.Middleware(dbBreaker)
+
 	// Initialize services
 	authService := auth.NewService(db)
+	configureJWTSecret(authService)
 	sudokuService := sudoku.NewService(db)
-	gameHandler := handlers.NewGameHandler(db, sudokuService)
-	authHandler := handlers.NewAuthHandler(authService)
-	puzzleHandler := handlers.NewPuzzleHandler(db)
+	sudokuService.SetSolveBudget(loadSolveBudget())
+	leaderboardService := leaderboard.NewService(db)
+	statsService := stats.NewService(db)
+	statsService.SetDecay(loadScoreDecay())
+	quotaService := quota.NewService(db)
+	pushService := push.NewService(db, nil)
+	moderationService := moderation.NewService(db, loadProfanityChecker())
+	configureUsernameSuggestions(moderationService)
+	digestService := digest.NewService(db, statsService, leaderboardService, loadMailSender())
+	blobService := blob.NewService(db, loadBlobStore(db))
+	replayService := replay.NewService(db, blobService)
+	eventService := event.NewService(db, pushService)
+	datasetService := dataset.NewService(db, blobService, sudokuService)
+	poolService := pool.NewService(db, sudokuService, loadPoolSize())
+	expiryService := expiry.NewService(db, pushService, loadExpiryPolicy())
+	goalService := goals.NewService(db, statsService, pushService)
+	rivalService := rivals.NewService(db, statsService, pushService)
+	walletService := wallet.NewService(db)
+	settingsService := settings.NewService(db, settings.Settings{CORSOrigins: []string{"http://localhost:3000"}})
+	if err := settingsService.Reload(); err != nil {
+		log.Fatal("Failed to load runtime settings:", err)
+	}
+	gameHandler := handlers.NewGameHandler(db, sudokuService, leaderboardService, poolService, eventService, goalService, walletService)
+	authHandler := handlers.NewAuthHandler(authService, moderationService)
+	puzzleHandler := handlers.NewPuzzleHandler(db, sudokuService)
+	analyzeHandler := handlers.NewAnalyzeHandler(sudokuService)
+	adminHandler := handlers.NewAdminHandler(db, sudokuService, statsService)
+	coachHandler := handlers.NewCoachHandler(db, moderationService)
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService)
+	featuredHandler := handlers.NewFeaturedHandler(db, sudokuService, leaderboardService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(db, quotaService)
+	deviceHandler := handlers.NewDeviceHandler(pushService)
+	goalHandler := handlers.NewGoalHandler(goalService)
+	rivalHandler := handlers.NewRivalHandler(rivalService)
+	paperHandler := handlers.NewPaperHandler(db, loadGridReader())
+	walletHandler := handlers.NewWalletHandler(walletService)
+	moderationHandler := handlers.NewModerationHandler(db, authService, moderationService)
+	onboardingHandler := handlers.NewOnboardingHandler(db, sudokuService)
+	announcementHandler := handlers.NewAnnouncementHandler(db, pushService)
+	replayHandler := handlers.NewReplayHandler(db, blobService, replayService)
+	worksheetHandler := handlers.NewWorksheetHandler(sudokuService)
+	statusHandler := handlers.NewStatusHandler(db, dbBreaker, poolService, version)
+	mergeHandler := handlers.NewMergeHandler(merge.NewService(db), pushService)
+	eventHandler := handlers.NewEventHandler(db, leaderboardService)
+	datasetHandler := handlers.NewDatasetHandler(db, blobService)
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	samuraiHandler := handlers.NewSamuraiHandler(db, sudokuService)
+	raceHandler := handlers.NewRaceHandler(db, sudokuService, poolService, rivalService, race.NewHub(db), settingsService.AllowOrigin)
+
+	// API key quotas. Analyze calls without a key get a small quota per client address; solve
+	// calls are made by signed-in players and only metered with a key.
+	analyzeQuota := quota.Middleware(quotaService, models.AnalyzeQuota, quota.AnonymousDailyAnalyzeLimit)
+	solveQuota := quota.Middleware(quotaService, models.SolveQuota, 0)
+
+	// Features admins can switch off at runtime
+	races := settings.RequireFeature(settingsService, settings.RacesFeature)
+	assistant := settings.RequireFeature(settingsService, settings.AssistantFeature)
+	paper := settings.RequireFeature(settingsService, settings.PaperFeature)
+	worksheets := settings.RequireFeature(settingsService, settings.WorksheetsFeature)
+
+	// Background jobs
+	go jobs.Every(context.Background(), "leaderboard-snapshots", time.Hour, leaderboardService.SnapshotDue)
+	go jobs.Every(context.Background(), "push-delivery", 30*time.Second, pushService.Deliver)
+	go jobs.Every(context.Background(), "weekly-digest", time.Hour, digestService.SendDue)
+	go jobs.Every(context.Background(), "blob-cleanup", time.Hour, blobService.Cleanup)
+	go jobs.Every(context.Background(), "puzzle-pool", 5*time.Minute, poolService.Refill)
+	go jobs.Every(context.Background(), "game-expiry", time.Hour, expiryService.ExpireDue)
+	go jobs.Every(context.Background(), "replay-verification", 5*time.Minute, replayService.VerifyPending)
+	go jobs.Every(context.Background(), "dataset-export", time.Minute, datasetService.ExportPending)
+	go jobs.Every(context.Background(), "score-decay", time.Hour, statsService.ApplyDecay)
+	go jobs.Every(context.Background(), "settings-reload", settings.ReloadInterval, settingsService.Reload)
+	go jobs.Every(context.Background(), "quota-prune", time.Hour, quotaService.PruneAnonymous)
 
 	// Initialize router
 	r := chi.NewRouter()
 
 	// Middleware
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
+	r.Use(slowlog.Middleware(slowThresholds.Handler))
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"},
+		AllowOriginFunc:  func(r *http.Request, origin string) bool { return settingsService.AllowOrigin(origin) },
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "X-API-Key", "X-Timezone"},
+		ExposedHeaders:   []string{"Link", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	r.Use(settings.Maintenance(settingsService))
+	r.Use(settings.RateLimit(settingsService))
 
 	// Public routes
 	r.Group(func(r chi.Router) {
+		r.Get("/status", statusHandler.GetStatus)
 		r.Post("/auth/register", authHandler.Register)
 		r.Post("/auth/login", authHandler.Login)
-		r.Get("/puzzles", puzzleHandler.GetPuzzles)
-		r.Get("/leaderboard", gameHandler.GetLeaderboard)
-		r.Get("/debug/games", gameHandler.GetAllCompletedGames) // Debug endpoint
+		r.With(nonCritical).Get("/puzzles", puzzleHandler.GetPuzzles)
+		r.With(nonCritical).Get("/puzzles/preview", puzzleHandler.GetPreview)
+		r.With(nonCritical).Get("/leaderboard", gameHandler.GetLeaderboard)
+		r.With(nonCritical).Get("/leaderboard/global", leaderboardHandler.GetGlobal)
+		r.With(nonCritical).Get("/leaderboard/regional", leaderboardHandler.GetRegional)
+		r.With(nonCritical).Get("/leaderboard/merged", leaderboardHandler.GetMerged)
+		r.With(nonCritical).Get("/leaderboard/archive", leaderboardHandler.GetArchive)
+		r.With(nonCritical).Get("/leaderboard/archive/periods", leaderboardHandler.GetArchivePeriods)
+		r.With(analyzeQuota).Post("/analyze/count", analyzeHandler.CountSolutions)
+		r.With(analyzeQuota).Post("/analyze/generate-pattern", analyzeHandler.GenerateFromPattern)
+		r.With(analyzeQuota).Post("/analyze/generate-sized", analyzeHandler.GenerateSized)
+		r.With(analyzeQuota).Post("/analyze/samurai", analyzeHandler.SolveSamurai)
+		r.With(analyzeQuota).Post("/puzzles/validate", analyzeHandler.ValidatePuzzle)
+		r.Get("/featured", featuredHandler.GetFeatured)
+		r.With(nonCritical).Get("/featured/results", featuredHandler.GetFeaturedResults)
+		r.Get("/events", eventHandler.GetEvents)
+		r.Get("/events/{id}", eventHandler.GetEvent)
+		r.With(nonCritical).Get("/events/{id}/leaderboard", eventHandler.GetEventLeaderboard)
+		r.With(nonCritical).Get("/datasets", datasetHandler.GetDatasets)
+		r.Get("/datasets/{id}/download", datasetHandler.DownloadDataset)
+		r.Get("/debug/games", gameHandler.GetAllCompletedGames)                    // Debug endpoint
 		r.Post("/debug/create-dummy-data", gameHandler.CreateDummyLeaderboardData) // Create dummy data
 	})
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
 		r.Use(auth.AuthMiddleware(authService))
+		r.Use(locale.Middleware(authService))
 
 		r.Get("/profile", authHandler.GetProfile)
 		r.Put("/profile", authHandler.UpdateProfile)
 
 		r.Post("/game/start", gameHandler.StartGame)
+		r.Post("/game/start-featured", gameHandler.StartFeaturedGame)
+		r.Post("/game/start-technique", gameHandler.StartTechniqueGame)
+		r.Post("/game/start-custom", gameHandler.StartCustomGame)
+		r.Post("/events/{id}/start", gameHandler.StartEventGame)
+		r.Get("/badges", eventHandler.GetBadges)
 		r.Post("/game/submit", gameHandler.SubmitGame)
-		r.Get("/game/history", gameHandler.GetGameHistory)
+		r.With(nonCritical).Get("/game/history", gameHandler.GetGameHistory)
+		r.With(nonCritical).Get("/puzzles/{id}/my-history", puzzleHandler.GetMyHistory)
+		r.With(paper).Post("/puzzles/{id}/paper", paperHandler.SubmitPaper)
+		r.Get("/game/{id}/diff", gameHandler.GetGameDiff)
+		r.Post("/game/{id}/skip", gameHandler.SkipGame)
+		r.Post("/game/{id}/retry", gameHandler.RetryGame)
+		r.Post("/game/{id}/heartbeat", gameHandler.Heartbeat)
+		r.Get("/game/{id}/notes", gameHandler.GetNotes)
+		r.Put("/game/{id}/notes", gameHandler.SaveNotes)
+		r.Post("/game/{id}/dispute", gameHandler.DisputeGame)
+		r.With(assistant).Post("/game/{id}/assistant", gameHandler.AskAssistant)
+		r.Get("/game/{id}/assistant", gameHandler.GetAssistantSessions)
+		r.Put("/game/{id}/replay", replayHandler.SaveReplay)
+		r.Get("/game/{id}/replay", replayHandler.GetReplay)
+		r.Get("/game/{id}/annotations", coachHandler.GetGameAnnotations)
+
+		r.Get("/coaches", coachHandler.GetMyCoaches)
+		r.Post("/coaches", coachHandler.GrantCoach)
+		r.Delete("/coaches/{coachID}", coachHandler.RevokeCoach)
+		r.Get("/recommendations", coachHandler.GetRecommendations)
+
+		r.Get("/coach/players", coachHandler.GetMyPlayers)
+		r.Get("/coach/players/{playerID}/games", coachHandler.GetPlayerGames)
+		r.Post("/coach/players/{playerID}/recommendations", coachHandler.RecommendTechnique)
+		r.Get("/coach/games/{id}", coachHandler.ReviewGame)
+		r.Post("/coach/games/{id}/annotations", coachHandler.AnnotateGame)
 
 		r.Post("/game/hint", gameHandler.GetHint)
-		r.Post("/game/solve", gameHandler.SolvePuzzle)
-		r.Post("/game/solve-step", gameHandler.SolveStep)
+		r.Post("/game/check", gameHandler.CheckGame)
+		r.Post("/game/check-cells", gameHandler.CheckCells)
+		r.Post("/game/candidates", gameHandler.GetCandidates)
+		r.With(solveQuota).Post("/game/solve", gameHandler.SolvePuzzle)
+		r.With(solveQuota).Post("/game/solve-step", gameHandler.SolveStep)
+		r.With(solveQuota).Post("/game/solve-steps", gameHandler.SolveSteps)
+		r.With(solveQuota).Post("/game/solve-path", gameHandler.SolvePath)
+
+		r.With(worksheets).Post("/worksheets", worksheetHandler.CreateWorksheet)
+
+		r.With(races).Post("/race", raceHandler.CreateRace)
+		r.With(races).Get("/race/{id}", raceHandler.GetRace)
+		r.With(races).Post("/race/{id}/join", raceHandler.JoinRace)
+		r.With(races).Post("/race/{id}/rematch", raceHandler.Rematch)
+		r.With(races).Get("/race/series/{id}", raceHandler.GetSeries)
+		r.With(races).Get("/race/{id}/ws", raceHandler.RaceSocket)
+
+		r.Post("/samurai", samuraiHandler.StartSamurai)
+		r.Get("/samurai/{id}", samuraiHandler.GetSamurai)
+		r.Put("/samurai/{id}", samuraiHandler.SaveSamurai)
+		r.Post("/samurai/{id}/check", samuraiHandler.CheckSamurai)
+		r.Post("/samurai/{id}/hint", samuraiHandler.GetSamuraiHint)
+		r.Post("/samurai/{id}/submit", samuraiHandler.SubmitSamurai)
+
+		r.Get("/rivals", rivalHandler.GetRivals)
+		r.With(races).Post("/rivals/{id}/challenge", raceHandler.ChallengeRival)
+
+		r.Get("/wallet", walletHandler.GetWallet)
+
+		r.Get("/onboarding", onboardingHandler.GetOnboarding)
+		r.Post("/onboarding/start", onboardingHandler.StartOnboarding)
+		r.Post("/onboarding/{id}/submit", onboardingHandler.SubmitOnboarding)
+
+		r.With(nonCritical).Get("/announcements", announcementHandler.GetAnnouncements)
+		r.Post("/announcements/{id}/dismiss", announcementHandler.DismissAnnouncement)
+
+		r.Get("/account/merges", mergeHandler.GetPendingMerges)
+		r.Post("/account/merges/{id}/confirm", mergeHandler.ConfirmMerge)
+		r.Post("/account/merges/{id}/decline", mergeHandler.DeclineMerge)
+
+		r.Get("/goals", goalHandler.GetGoals)
+		r.Post("/goals", goalHandler.CreateGoal)
+		r.Delete("/goals/{id}", goalHandler.DeleteGoal)
+
+		r.Post("/devices", deviceHandler.RegisterDevice)
+		r.Delete("/devices", deviceHandler.UnregisterDevice)
+
+		r.Get("/api-keys", apiKeyHandler.GetKeys)
+		r.Post("/api-keys", apiKeyHandler.CreateKey)
+	})
+
+	// Admin routes
+	r.Group(func(r chi.Router) {
+		r.Use(auth.AuthMiddleware(authService))
+		r.Use(auth.AdminMiddleware(authService))
+
+		r.Get("/admin/settings", settingsHandler.GetSettings)
+		r.Put("/admin/settings", settingsHandler.UpdateSettings)
+
+		r.Get("/admin/generation-profiles", adminHandler.GetGenerationProfiles)
+		r.Put("/admin/generation-profiles/{difficulty}", adminHandler.UpdateGenerationProfile)
+
+		r.Get("/admin/reviews", adminHandler.GetReviews)
+		r.Get("/admin/reviews/{id}", adminHandler.GetReview)
+		r.Post("/admin/reviews/{id}/clear", adminHandler.ClearReview)
+		r.Post("/admin/reviews/{id}/void", adminHandler.VoidReview)
+		r.Get("/admin/disputes", adminHandler.GetDisputes)
+
+		r.Post("/admin/jobs/recompute-totals", adminHandler.RecomputeTotals)
+		r.Post("/admin/leaderboard/purge", adminHandler.PurgeLeaderboard)
+		r.Get("/admin/datasets", datasetHandler.GetExports)
+		r.Post("/admin/datasets", datasetHandler.CreateExport)
+		r.Delete("/admin/datasets/{id}", datasetHandler.DeleteExport)
+
+		r.Post("/admin/featured", featuredHandler.CreateFeatured)
+		r.Post("/admin/events", eventHandler.CreateEvent)
+		r.Delete("/admin/events/{id}", eventHandler.DeleteEvent)
+
+		r.Put("/admin/api-keys/{id}/quota", apiKeyHandler.UpdateQuota)
+
+		r.Delete("/admin/users/{id}", adminHandler.DeleteUser)
+		r.Post("/admin/users/{id}/restore", adminHandler.RestoreUser)
+		r.Delete("/admin/puzzles/{id}", adminHandler.DeletePuzzle)
+		r.Post("/admin/puzzles/{id}/restore", adminHandler.RestorePuzzle)
+		r.Delete("/admin/games/{id}", adminHandler.DeleteGame)
+		r.Post("/admin/games/{id}/restore", adminHandler.RestoreGame)
+
+		r.Get("/admin/moderation/terms", moderationHandler.GetTerms)
+		r.Post("/admin/moderation/terms", moderationHandler.AddTerm)
+		r.Delete("/admin/moderation/terms/{id}", moderationHandler.DeleteTerm)
+		r.Put("/admin/users/{id}/username", moderationHandler.RenameUser)
+		r.Post("/admin/users/merge", mergeHandler.ProposeMerge)
+
+		r.Post("/admin/announcements", announcementHandler.CreateAnnouncement)
+		r.Delete("/admin/announcements/{id}", announcementHandler.DeleteAnnouncement)
 	})
 
 	// Start server
@@ -89,8 +374,285 @@ func main() {
 		port = "8080"
 	}
 
+	// Builds with the embedded frontend serve it alongside the API
+	var handler http.Handler = r
+	if assets := frontendAssets(); assets != nil {
+		handler = web.Frontend(assets, r)
+	}
+
 	log.Printf("Server starting on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	log.Fatal(http.ListenAndServe(":"+port, handler))
+}
+
+// Create and update the tables of every model
+func migrateDB(db *gorm.DB) {
+	if err := db.AutoMigrate(&models.User{}, &models.Puzzle{}, &models.Game{}, &models.GameResult{}, &models.GenerationProfile{},
+		&models.CoachGrant{}, &models.GameAnnotation{}, &models.TechniqueRecommendation{}, &models.PuzzleSkip{},
+		&models.LeaderboardSnapshot{}, &models.ResultReview{},
+		&models.FeaturedPuzzle{}, &models.APIKey{}, &models.APIUsage{}, &models.AnonymousUsage{},
+		&models.PushDevice{}, &models.PushNotification{}, &models.ModerationTerm{}, &models.GameDispute{},
+		&models.OnboardingQuiz{}, &models.OnboardingBoard{}, &models.Announcement{}, &models.AnnouncementDismissal{},
+		&models.AssistantSession{}, &models.AssistantPrompt{}, &models.Blob{}, &models.LargeObject{},
+		&models.PooledPuzzle{}, &models.Race{}, &models.RaceSeries{}, &models.AccountMerge{},
+		&models.Event{}, &models.EventPuzzle{}, &models.EventBadge{}, &models.DatasetExport{},
+		&models.Goal{}, &models.GoalCompletion{}, &models.RivalNudge{},
+		&models.TokenTransaction{}, &models.Setting{}, &models.SamuraiGame{}); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+}
+
+// Read AUTO_MIGRATE: true (the default) migrates on start, false skips it and "only" migrates then exits
+func loadAutoMigrate() string {
+	value := os.Getenv("AUTO_MIGRATE")
+	switch value {
+	case "":
+		return "true"
+	case "true", "false", "only":
+		return value
+	default:
+		log.Fatal("Invalid AUTO_MIGRATE, use true, false or only")
+		return ""
+	}
+}
+
+// Sign tokens with JWT_SECRET, keeping the development key when it is unset
+func configureJWTSecret(authService *auth.Service) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Println("No JWT_SECRET set, signing tokens with the development key")
+		return
+	}
+	authService.SetSecret(secret)
+}
+
+// Point temporary files at TEMP_DIR, for containers with a read-only root filesystem,
+// and warn early when the temp directory can't be written
+func configureTempDir() {
+	if dir := os.Getenv("TEMP_DIR"); dir != "" {
+		for _, name := range []string{"TMPDIR", "TMP", "TEMP"} {
+			os.Setenv(name, dir)
+		}
+	}
+	if err := checkWritable(os.TempDir()); err != nil {
+		log.Printf("Temp directory is not writable, set TEMP_DIR to a writable volume: %v", err)
+	}
+}
+
+// Check that files can be created in dir
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// Configure column encryption from ENCRYPTION_KEYS (or ENCRYPTION_KEYS_FILE) and the blind
+// indexes from ENCRYPTION_INDEX_KEY, which is required either way
+func loadKeyring() {
+	indexKey, err := vault.LoadIndexKey()
+	if err != nil {
+		log.Fatal("Invalid blind index key:", err)
+	}
+	vault.ConfigureIndex(indexKey)
+
+	keyring, err := vault.LoadEnv()
+	if err != nil {
+		log.Fatal("Invalid encryption keys:", err)
+	}
+	if keyring == nil {
+		log.Println("No ENCRYPTION_KEYS set, sensitive columns are stored in plain text")
+		return
+	}
+	vault.Configure(keyring)
+}
+
+// Read the number of puzzles kept ready per difficulty from PUZZLE_POOL_SIZE
+func loadPoolSize() int {
+	value := os.Getenv("PUZZLE_POOL_SIZE")
+	if value == "" {
+		return pool.DefaultSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		log.Fatal("Invalid PUZZLE_POOL_SIZE:", value)
+	}
+	return size
+}
+
+// Read the game expiry policy from GAME_EXPIRY and GAME_EXPIRY_WARNING, keeping the defaults for unset values
+func loadExpiryPolicy() expiry.Policy {
+	policy := expiry.DefaultPolicy
+	if value := os.Getenv("GAME_EXPIRY"); value != "" {
+		after, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid GAME_EXPIRY:", err)
+		}
+		policy.After = after
+	}
+	if value := os.Getenv("GAME_EXPIRY_WARNING"); value != "" {
+		warning, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid GAME_EXPIRY_WARNING:", err)
+		}
+		policy.WarnBefore = warning
+	}
+	if policy.After > 0 && (policy.WarnBefore <= 0 || policy.WarnBefore >= policy.After) {
+		log.Fatal("GAME_EXPIRY_WARNING must be positive and shorter than GAME_EXPIRY")
+	}
+	return policy
+}
+
+// Read the score decay window from SCORE_DECAY_WINDOW, leaving decay off when unset
+func loadScoreDecay() stats.DecayPolicy {
+	policy := stats.DefaultDecay
+	if value := os.Getenv("SCORE_DECAY_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < 0 {
+			log.Fatal("Invalid SCORE_DECAY_WINDOW:", value)
+		}
+		policy.Window = window
+	}
+	return policy
+}
+
+// Read solver limits from SOLVER_MAX_NODES and SOLVER_TIMEOUT, keeping the defaults for unset values
+func loadSolveBudget() sudoku.SolveBudget {
+	budget := sudoku.DefaultSolveBudget
+	if value := os.Getenv("SOLVER_MAX_NODES"); value != "" {
+		nodes, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatal("Invalid SOLVER_MAX_NODES:", err)
+		}
+		budget.MaxNodes = nodes
+	}
+	if value := os.Getenv("SOLVER_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid SOLVER_TIMEOUT:", err)
+		}
+		budget.Timeout = timeout
+	}
+	return budget
+}
+
+// Load the slow query and slow handler thresholds from SLOW_QUERY_THRESHOLD and SLOW_HANDLER_THRESHOLD
+func loadSlowThresholds() slowlog.Thresholds {
+	thresholds := slowlog.DefaultThresholds
+	if value := os.Getenv("SLOW_QUERY_THRESHOLD"); value != "" {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid SLOW_QUERY_THRESHOLD:", err)
+		}
+		thresholds.Query = threshold
+	}
+	if value := os.Getenv("SLOW_HANDLER_THRESHOLD"); value != "" {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid SLOW_HANDLER_THRESHOLD:", err)
+		}
+		thresholds.Handler = threshold
+	}
+	return thresholds
+}
+
+// Send email through SMTP_ADDR when it is set, otherwise only log it
+func loadMailSender() mail.Sender {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return mail.LogSender{}
+	}
+	return mail.SMTPSender{
+		Addr:     addr,
+		From:     os.Getenv("SMTP_FROM"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+	}
+}
+
+// Read photographed grids through the recognition service at OCR_URL, if it is set
+func loadGridReader() ocr.Reader {
+	url := os.Getenv("OCR_URL")
+	if url == "" {
+		return nil
+	}
+	return ocr.NewHTTPReader(url, os.Getenv("OCR_TOKEN"))
+}
+
+// Pick the blob store named by BLOB_STORE: postgres large objects (the default), a directory or an S3 bucket
+func loadBlobStore(db *gorm.DB) blob.Store {
+	switch os.Getenv("BLOB_STORE") {
+	case "", "postgres":
+		return blob.NewPostgresStore(db)
+	case "file":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			log.Fatal("BLOB_DIR is required for the file blob store")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Fatal("Failed to create BLOB_DIR:", err)
+		}
+		if err := checkWritable(dir); err != nil {
+			log.Fatal("BLOB_DIR is not writable:", err)
+		}
+		return blob.FileStore{Dir: dir}
+	case "s3":
+		store := blob.S3Store{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		}
+		if store.Endpoint == "" || store.Region == "" || store.Bucket == "" {
+			log.Fatal("S3_ENDPOINT, S3_REGION and S3_BUCKET are required for the s3 blob store")
+		}
+		return store
+	default:
+		log.Fatal("Invalid BLOB_STORE, use postgres, file or s3")
+		return nil
+	}
+}
+
+// Read username suggestion words from the files named by USERNAME_ADJECTIVES and USERNAME_NOUNS,
+// and the number of suggestions from USERNAME_SUGGESTIONS, keeping the defaults for unset values
+func configureUsernameSuggestions(moderationService *moderation.Service) {
+	var adjectives, nouns []string
+	var err error
+	if path := os.Getenv("USERNAME_ADJECTIVES"); path != "" {
+		if adjectives, err = moderation.ReadWords(path); err != nil {
+			log.Fatal("Failed to load username adjectives:", err)
+		}
+	}
+	if path := os.Getenv("USERNAME_NOUNS"); path != "" {
+		if nouns, err = moderation.ReadWords(path); err != nil {
+			log.Fatal("Failed to load username nouns:", err)
+		}
+	}
+	moderationService.SetSuggestionWords(adjectives, nouns)
+
+	if value := os.Getenv("USERNAME_SUGGESTIONS"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil || count <= 0 {
+			log.Fatal("Invalid USERNAME_SUGGESTIONS:", value)
+		}
+		moderationService.SetSuggestionCount(count)
+	}
+}
+
+// Load the profanity word list named by PROFANITY_WORDLIST, if any
+func loadProfanityChecker() moderation.Checker {
+	path := os.Getenv("PROFANITY_WORDLIST")
+	if path == "" {
+		return nil
+	}
+
+	checker, err := moderation.LoadWordList(path)
+	if err != nil {
+		log.Fatal("Failed to load profanity word list:", err)
+	}
+	return checker
 }
 
 func initDB() (*gorm.DB, error) {